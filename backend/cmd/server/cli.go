@@ -0,0 +1,96 @@
+package main
+
+import (
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+const usage = `Usage:
+  server [--config FILE]                             Run the server
+  server [--config FILE] config print [--redacted]   Print the effective configuration
+
+Configuration is read from built-in defaults, then the YAML or JSON file given
+by --config or CONFIG_FILE, then environment variables, each overriding the
+one before. config print shows every setting with the layer it came from;
+--redacted hides secrets such as database.password.
+
+The running server reloads its configuration on SIGHUP and when the file
+or a secret file changes. Only logger.level, database.password,
+server.cors.*, server.rate_limit.default.*, server.rate_limit.procedures and
+server.request_timeouts.* take effect without a restart; a reload that
+changes anything else is rejected and its diff logged.
+
+Secrets can be read from files with DB_PASSWORD_FILE and
+CALENDAR_FEED_TOKEN_FILE, or the password_file and feed_token_file keys.
+
+TLS_CERT_FILE and TLS_KEY_FILE serve TLS instead of plaintext HTTP/2; the
+certificates are reloaded on SIGHUP and when they change. TLS_CLIENT_CA_FILE
+verifies client certificates (mTLS). DB_SSL_MODE=custom with DB_SSL_CA
+verifies the database server against a private CA.
+`
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// commandLine is the parsed server command line
+type commandLine struct {
+	// configPath is the configuration file to read; empty reads none
+	configPath string
+	// args are the subcommand and its arguments; empty runs the server
+	args []string
+}
+
+// parseCommandLine parses the global flags in args
+func parseCommandLine(args []string) (*commandLine, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", os.Getenv(config.ConfigFileEnv), "configuration file")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return &commandLine{configPath: *configPath, args: fs.Args()}, nil
+}
+
+// runSubcommand runs the subcommand in cl.args and returns the exit code
+func runSubcommand(cl *commandLine, stdout, stderr io.Writer) int {
+	if len(cl.args) < 2 || cl.args[0] != "config" || cl.args[1] != "print" {
+		fmt.Fprintf(stderr, "server: unknown command %q\n\n%s", cl.args, usage)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("server config print", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	redacted := fs.Bool("redacted", false, "hide secret values")
+	if err := fs.Parse(cl.args[2:]); err != nil {
+		if stderrors.Is(err, flag.ErrHelp) {
+			fmt.Fprint(stdout, usage)
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "server: %v\n\n%s", err, usage)
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "server: config print takes no arguments\n\n%s", usage)
+		return exitUsage
+	}
+
+	resolved, err := config.Resolve(cl.configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "server: failed to load configuration: %v\n", err)
+		return exitError
+	}
+	if err := resolved.Write(stdout, *redacted); err != nil {
+		fmt.Fprintf(stderr, "server: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
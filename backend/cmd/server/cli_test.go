@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "/etc/todo/from-env.yaml")
+
+	cl, err := parseCommandLine(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/todo/from-env.yaml", cl.configPath)
+	assert.Empty(t, cl.args)
+
+	cl, err = parseCommandLine([]string{"--config", "todo.yaml", "config", "print", "--redacted"})
+	require.NoError(t, err)
+	assert.Equal(t, "todo.yaml", cl.configPath)
+	assert.Equal(t, []string{"config", "print", "--redacted"}, cl.args)
+
+	_, err = parseCommandLine([]string{"--port", "80"})
+	assert.Error(t, err)
+}
+
+func TestRunSubcommand_ConfigPrint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 9090\n"), 0o600))
+	t.Setenv("DB_PASSWORD", "hunter2")
+
+	var stdout, stderr strings.Builder
+	code := runSubcommand(&commandLine{configPath: path, args: []string{"config", "print", "--redacted"}}, &stdout, &stderr)
+
+	require.Equal(t, exitOK, code, stderr.String())
+	assert.Regexp(t, `server\.port\s+"9090"\s+file `, stdout.String())
+	assert.Regexp(t, `database\.password\s+"\[redacted\]"\s+env DB_PASSWORD`, stdout.String())
+	assert.NotContains(t, stdout.String(), "hunter2")
+}
+
+func TestRunSubcommand_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		wantCode int
+		wantErr  string
+	}{
+		{"unknown command", []string{"serve"}, nil, exitUsage, "unknown command"},
+		{"unknown flag", []string{"config", "print", "--all"}, nil, exitUsage, "flag provided but not defined"},
+		{"extra argument", []string{"config", "print", "server.port"}, nil, exitUsage, "takes no arguments"},
+		{"malformed value", []string{"config", "print"}, map[string]string{"SERVER_PORT": "80a"}, exitError, "invalid SERVER_PORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			var stdout, stderr strings.Builder
+			code := runSubcommand(&commandLine{args: tt.args}, &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stderr.String(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+// Updated with latest protobuf dependencies including UpdateTask
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/wcygan/todo/backend/internal/app"
+	"github.com/wcygan/todo/backend/internal/certs"
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/gen/task/v2/taskv2connect"
+	"github.com/wcygan/todo/backend/internal/health"
+	"github.com/wcygan/todo/backend/internal/logger"
+	"github.com/wcygan/todo/backend/internal/metrics"
+	"github.com/wcygan/todo/backend/internal/middleware"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/internal/tracing"
+)
+
+func main() {
+	cl, err := parseCommandLine(os.Args[1:])
+	if err != nil {
+		if stderrors.Is(err, flag.ErrHelp) {
+			fmt.Print(usage)
+			os.Exit(exitOK)
+		}
+		fmt.Fprintf(os.Stderr, "server: %v\n\n%s", err, usage)
+		os.Exit(exitUsage)
+	}
+	if len(cl.args) > 0 {
+		os.Exit(runSubcommand(cl, os.Stdout, os.Stderr))
+	}
+
+	// Load configuration
+	resolved, err := config.Resolve(cl.configPath)
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+	cfg := resolved.Config
+	cfgStore := config.NewStore(resolved)
+
+	// Initialize logger
+	log := logger.New(cfg)
+	log.LogInfo(context.Background(), "starting Todo ConnectRPC server", 
+		"port", cfg.Server.Port,
+		"development", cfg.IsDevelopment(),
+		"log_level", cfg.Logger.Level,
+	)
+	cfgStore.Subscribe(log.ApplyConfig)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		log.LogError(context.Background(), "failed to initialize tracing", err)
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.LogError(context.Background(), "failed to flush traces", err)
+		}
+	}()
+	log.LogInfo(context.Background(), "tracing initialized", "exporter", cfg.Tracing.Exporter)
+
+	// Initialize database store manager
+	storeManager, err := store.NewManager(cfg)
+	if err != nil {
+		log.LogError(context.Background(), "failed to initialize store manager", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := storeManager.Close(); err != nil {
+			log.LogError(context.Background(), "failed to close store manager", err)
+		}
+	}()
+	// Rotated passwords, such as a new DB_PASSWORD_FILE, apply to new connections
+	cfgStore.Subscribe(func(cfg *config.Config) {
+		if err := storeManager.SetPassword(cfg.Database.Password); err != nil {
+			log.LogError(context.Background(), "failed to rotate database password", err)
+		}
+	})
+
+	// Initialize dependencies with logging
+	taskService := service.NewTaskService(storeManager.TaskStore(),
+		service.WithIdempotency(storeManager.IdempotencyStore(), cfg.Idempotency.TTL),
+		service.WithIdempotencyLease(cfg.Idempotency.Lease),
+	)
+
+	log.LogInfo(context.Background(), "dependencies initialized")
+
+	// Initialize metrics
+	serverMetrics := metrics.New()
+	serverMetrics.RegisterDBPools(storeManager.Pools())
+	serverMetrics.RegisterTaskCounts(storeManager.CountTasks)
+	if _, ok := storeManager.CacheStats(); ok {
+		serverMetrics.RegisterCacheStats(func() (uint64, uint64) {
+			stats, _ := storeManager.CacheStats()
+			return stats.Hits, stats.Misses
+		})
+	}
+
+	// Register liveness, readiness and startup probes
+	healthRegistry := health.NewRegistry("todo-backend", cfg.Health.CheckTimeout)
+	healthRegistry.AddReadinessCheck("database", storeManager.HealthCheck)
+	healthRegistry.AddReadinessCheck("migrations", storeManager.CheckMigrations)
+	healthRegistry.AddReadinessCheck("connection_pools",
+		health.PoolSaturationCheck(storeManager.Pools(), cfg.Health.PoolSaturationThreshold))
+	healthRegistry.AddStartupCheck("migrations", storeManager.CheckMigrations)
+
+	// Build the handler for every endpoint
+	tracedHandler, err := app.NewHandler(cfgStore, log, app.Deps{
+		TaskService: taskService,
+		Health:      healthRegistry,
+		Metrics:     serverMetrics,
+	})
+	if err != nil {
+		log.LogError(context.Background(), "failed to build handler", err)
+		os.Exit(1)
+	}
+
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	var certReloader *certs.Reloader
+	if cfg.Server.TLS.Enabled() {
+		// Terminate TLS here, identifying callers with client certificates
+		certReloader, err = certs.NewReloader(&cfg.Server.TLS)
+		if err != nil {
+			log.LogError(context.Background(), "failed to load TLS certificates", err)
+			os.Exit(1)
+		}
+		server.TLSConfig = certReloader.TLSConfig()
+		server.Handler = middleware.ClientIdentityMiddleware(tracedHandler)
+		log.LogInfo(context.Background(), "tls enabled",
+			"client_auth", cfg.Server.TLS.ClientAuthMode(),
+			"certificate_expires", certReloader.Certificate().NotAfter,
+		)
+	} else {
+		// Support HTTP/2 without TLS for local development
+		server.Handler = h2c.NewHandler(tracedHandler, &http2.Server{})
+	}
+
+	// Bind the listener before reporting startup as complete
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.LogError(context.Background(), "server failed to start", err)
+		os.Exit(1)
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.LogInfo(context.Background(), "server listening", 
+			"addr", server.Addr,
+			"tls", certReloader != nil,
+			"endpoints", []string{
+				"/livez",
+				"/readyz",
+				"/startupz",
+				"/metrics",
+				taskconnect.TaskServiceCreateTaskProcedure,
+				taskconnect.TaskServiceGetTaskProcedure,
+				taskconnect.TaskServiceGetAllTasksProcedure,
+				taskconnect.TaskServiceUpdateTaskProcedure,
+				taskconnect.TaskServiceDeleteTaskProcedure,
+				"/" + taskv2connect.TaskServiceName + "/",
+			},
+		)
+
+		serve := server.Serve
+		if certReloader != nil {
+			serve = func(l net.Listener) error { return server.ServeTLS(l, "", "") }
+		}
+		if err := serve(listener); err != nil && err != http.ErrServerClosed {
+			log.LogError(context.Background(), "server failed to start", err)
+			os.Exit(1)
+		}
+	}()
+	healthRegistry.MarkStarted()
+
+	// Apply safe configuration changes on SIGHUP or when the file changes
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go watchConfig(watchCtx, cfgStore, log)
+	if certReloader != nil {
+		go watchCertificates(watchCtx, certReloader, log)
+	}
+
+	// Wait for interrupt signal to gracefully shutdown the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.LogInfo(context.Background(), "shutting down server")
+
+	// Fail readiness first so load balancers stop sending new requests
+	// while the listener is still accepting them
+	healthRegistry.MarkShuttingDown()
+	if cfg.Health.ShutdownDelay > 0 {
+		log.LogInfo(context.Background(), "readiness disabled, draining traffic", "delay", cfg.Health.ShutdownDelay)
+		time.Sleep(cfg.Health.ShutdownDelay)
+	}
+
+	// Graceful shutdown with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.LogError(context.Background(), "server forced to shutdown", err)
+		os.Exit(1)
+	}
+
+	log.LogInfo(context.Background(), "server shutdown complete")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/wcygan/todo/backend/internal/certs"
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/logger"
+)
+
+// configPollInterval is how often the configuration file is checked for
+// changes
+const configPollInterval = 5 * time.Second
+
+// watchConfig reloads the configuration on SIGHUP and whenever the
+// configuration file changes, until ctx is done
+func watchConfig(ctx context.Context, store *config.Store, log *logger.Logger) {
+	onReload := func(result *config.ReloadResult, err error) {
+		logReload(ctx, log, result, err)
+	}
+	go store.Watch(ctx, configPollInterval, onReload)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.LogInfo(ctx, "reloading configuration", "trigger", "SIGHUP")
+			onReload(store.Reload())
+		}
+	}
+}
+
+// watchCertificates reloads the TLS certificates on SIGHUP and whenever
+// the certificate files change, until ctx is done
+func watchCertificates(ctx context.Context, reloader *certs.Reloader, log *logger.Logger) {
+	onReload := func(err error) {
+		if err != nil {
+			log.LogError(ctx, "certificate reload failed, keeping current certificates", err)
+			return
+		}
+		log.LogInfo(ctx, "certificates reloaded", "certificate_expires", reloader.Certificate().NotAfter)
+	}
+	go reloader.Watch(ctx, configPollInterval, onReload)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if changed, err := reloader.Reload(); err != nil || changed {
+				onReload(err)
+			}
+		}
+	}
+}
+
+// logReload reports the outcome of a configuration reload, including the
+// diff of a rejected one
+func logReload(ctx context.Context, log *logger.Logger, result *config.ReloadResult, err error) {
+	var unsafe *config.UnsafeChangeError
+	switch {
+	case stderrors.As(err, &unsafe):
+		log.LogError(ctx, "configuration reload rejected", err,
+			"unsafe_keys", unsafe.Unsafe,
+			"diff", changeStrings(unsafe.Changes),
+		)
+	case err != nil:
+		log.LogError(ctx, "configuration reload failed, keeping current configuration", err)
+	case len(result.Changes) == 0:
+		log.LogInfo(ctx, "configuration reloaded, nothing changed")
+	default:
+		log.LogInfo(ctx, "configuration reloaded", "diff", changeStrings(result.Changes))
+	}
+}
+
+// changeStrings formats changes for logging
+func changeStrings(changes []config.Change) []string {
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		lines[i] = c.String()
+	}
+	return lines
+}
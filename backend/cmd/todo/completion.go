@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"connectrpc.com/connect"
+)
+
+// completeIDsCommand is a hidden command that completion scripts call to
+// list task IDs
+const completeIDsCommand = "__complete-ids"
+
+// runCompleteIDs prints the ID of every task, one per line
+func runCompleteIDs(ctx context.Context, env *environment, args []string) error {
+	resp, err := env.client.GetAllTasks(ctx, connect.NewRequest(&taskv1.GetAllTasksRequest{}))
+	if err != nil {
+		return err
+	}
+	for _, task := range resp.Msg.Tasks {
+		fmt.Fprintln(env.stdout, task.Id)
+	}
+	return nil
+}
+
+// completionScripts holds the completion script for each supported shell.
+// Commands that take task IDs complete them from the server.
+var completionScripts = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  "autoload -U +X bashcompinit && bashcompinit\n" + bashCompletion,
+	"fish": fishCompletion,
+}
+
+// printCompletion writes the completion script for the shell in args
+func printCompletion(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return usageError{"completion needs a shell: bash, zsh or fish"}
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return usageError{fmt.Sprintf("unsupported shell %q (must be bash, zsh or fish)", args[0])}
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+const bashCompletion = `# bash completion for todo; load with: source <(todo completion bash)
+_todo() {
+    local cur prev cmd i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    case "$prev" in
+        -o|--output|-output)
+            COMPREPLY=($(compgen -W "table json plain" -- "$cur"))
+            return ;;
+        --server|-server|--token|-token|--timeout|-timeout)
+            return ;;
+    esac
+
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            -o|--output|-output|--server|-server|--token|-token|--timeout|-timeout) ((i++)) ;;
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    if [[ "$cur" == -* ]]; then
+        local flags="--server --token --output --timeout"
+        case "$cmd" in
+            ls) flags="$flags --done --pending" ;;
+            done) flags="$flags --undo" ;;
+        esac
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+        return
+    fi
+
+    case "$cmd" in
+        "")
+            COMPREPLY=($(compgen -W "add ls show done edit rm completion help" -- "$cur")) ;;
+        show|done|edit|rm)
+            COMPREPLY=($(compgen -W "$(todo __complete-ids 2>/dev/null)" -- "$cur")) ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+    esac
+}
+complete -F _todo todo
+`
+
+const fishCompletion = `# fish completion for todo; load with: todo completion fish | source
+set -l commands add ls show done edit rm completion help
+complete -c todo -f
+complete -c todo -n "not __fish_seen_subcommand_from $commands" -a add -d "Create a task"
+complete -c todo -n "not __fish_seen_subcommand_from $commands" -a ls -d "List tasks"
+complete -c todo -n "not __fish_seen_subcommand_from $commands" -a show -d "Show a task"
+complete -c todo -n "not __fish_seen_subcommand_from $commands" -a done -d "Mark tasks as completed"
+complete -c todo -n "not __fish_seen_subcommand_from $commands" -a edit -d "Change the description of a task"
+complete -c todo -n "not __fish_seen_subcommand_from $commands" -a rm -d "Delete tasks"
+complete -c todo -n "not __fish_seen_subcommand_from $commands" -a completion -d "Print a completion script"
+complete -c todo -n "__fish_seen_subcommand_from show done edit rm" -a "(todo __complete-ids 2>/dev/null)"
+complete -c todo -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c todo -n "__fish_seen_subcommand_from ls" -l done -d "Only completed tasks"
+complete -c todo -n "__fish_seen_subcommand_from ls" -l pending -d "Only pending tasks"
+complete -c todo -n "__fish_seen_subcommand_from done" -l undo -d "Mark tasks as not completed"
+complete -c todo -l server -r -d "Server URL"
+complete -c todo -l token -r -d "Bearer token"
+complete -c todo -s o -l output -r -a "table json plain" -d "Output format"
+complete -c todo -l timeout -r -d "Request timeout"
+`
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultServer is used when no server is configured
+	defaultServer = "http://localhost:8080"
+	// dotfileName is read from the home directory unless TODO_CONFIG names
+	// another file
+	dotfileName = ".todorc"
+)
+
+// settings holds where to reach the server and how to authenticate
+type settings struct {
+	Server string
+	Token  string
+}
+
+// loadSettings reads the dotfile and then the TODO_SERVER and TODO_TOKEN
+// environment variables, which take precedence. A missing dotfile is not an
+// error.
+func loadSettings(getenv func(string) string) (settings, error) {
+	s := settings{Server: defaultServer}
+
+	path := getenv("TODO_CONFIG")
+	explicit := path != ""
+	if !explicit {
+		if home := getenv("HOME"); home != "" {
+			path = filepath.Join(home, dotfileName)
+		}
+	}
+	if path != "" {
+		if err := readDotfile(path, &s); err != nil {
+			if !os.IsNotExist(err) || explicit {
+				return s, err
+			}
+		}
+	}
+
+	if server := getenv("TODO_SERVER"); server != "" {
+		s.Server = server
+	}
+	if token := getenv("TODO_TOKEN"); token != "" {
+		s.Token = token
+	}
+	return s, nil
+}
+
+// readDotfile reads "key = value" lines into s. Blank lines and lines
+// starting with # are ignored; unknown keys are errors so that typos are
+// not silently dropped.
+func readDotfile(path string, s *settings) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected key = value", path, line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch key {
+		case "server":
+			s.Server = value
+		case "token":
+			s.Token = value
+		default:
+			return fmt.Errorf("%s:%d: unknown key %q", path, line, key)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	stderrors "errors"
+
+	"connectrpc.com/connect"
+)
+
+// Exit codes. Connect error codes are grouped by what a script would do
+// about them.
+const (
+	exitOK          = 0
+	exitError       = 1 // internal, unknown and other errors
+	exitUsage       = 2 // bad command line or invalid_argument
+	exitNotFound    = 3 // not_found
+	exitConflict    = 4 // already_exists, aborted, failed_precondition
+	exitAuth        = 5 // unauthenticated, permission_denied
+	exitRateLimited = 6 // resource_exhausted
+	exitUnavailable = 7 // unavailable, deadline_exceeded; worth retrying
+)
+
+// usageError reports a bad command line
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// exitCode returns the exit code for err
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var usage usageError
+	if stderrors.As(err, &usage) {
+		return exitUsage
+	}
+
+	switch connect.CodeOf(err) {
+	case connect.CodeInvalidArgument, connect.CodeOutOfRange:
+		return exitUsage
+	case connect.CodeNotFound:
+		return exitNotFound
+	case connect.CodeAlreadyExists, connect.CodeAborted, connect.CodeFailedPrecondition:
+		return exitConflict
+	case connect.CodeUnauthenticated, connect.CodePermissionDenied:
+		return exitAuth
+	case connect.CodeResourceExhausted:
+		return exitRateLimited
+	case connect.CodeUnavailable, connect.CodeDeadlineExceeded:
+		return exitUnavailable
+	default:
+		return exitError
+	}
+}
@@ -0,0 +1,352 @@
+// Command todo is a command-line client for the TaskService.
+//
+// Usage:
+//
+//	todo [flags] <command> [arguments]
+//
+// The server URL and token come from ~/.todorc (or the file named by
+// TODO_CONFIG), then TODO_SERVER and TODO_TOKEN, then --server and --token.
+// Run "todo help" for the commands and "todo completion bash" for shell
+// completion.
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"connectrpc.com/connect"
+)
+
+const usage = `Usage: todo [flags] <command> [arguments]
+
+Commands:
+  add <description>          Create a task
+  ls [--done | --pending]    List tasks, newest first
+  show <id>                  Show a task
+  done [--undo] <id>...      Mark tasks as completed, or not with --undo
+  edit <id> <description>    Change the description of a task
+  rm <id>...                 Delete tasks
+  completion <shell>         Print a completion script for bash, zsh or fish
+
+Flags:
+  --server URL               Server URL (TODO_SERVER, default http://localhost:8080)
+  --token TOKEN              Bearer token sent with every request (TODO_TOKEN)
+  -o, --output FORMAT        Output format: table, json or plain (default table)
+  --timeout DURATION         Request timeout (default 10s)
+
+Exit codes:
+  0 success, 1 error, 2 usage or invalid argument, 3 not found,
+  4 conflict, 5 authentication, 6 rate limited, 7 unavailable or timed out
+`
+
+// options are the flags accepted before or after the command name
+type options struct {
+	server  string
+	token   string
+	output  string
+	timeout time.Duration
+}
+
+// bind registers the shared flags on fs
+func (o *options) bind(fs *flag.FlagSet) {
+	fs.StringVar(&o.server, "server", o.server, "server URL")
+	fs.StringVar(&o.token, "token", o.token, "bearer token")
+	fs.StringVar(&o.output, "output", o.output, "output format")
+	fs.StringVar(&o.output, "o", o.output, "output format")
+	fs.DurationVar(&o.timeout, "timeout", o.timeout, "request timeout")
+}
+
+// command registers the flags of a subcommand on fs and returns the
+// function that runs it with the remaining arguments
+type command func(fs *flag.FlagSet) runFunc
+
+// runFunc runs a subcommand
+type runFunc func(ctx context.Context, env *environment, args []string) error
+
+// environment is what commands run against
+type environment struct {
+	client  taskconnect.TaskServiceClient
+	printer printer
+	stdout  io.Writer
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run executes the command line in args and returns the exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	code, err := execute(ctx, args, stdout, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "todo: %v\n", err)
+		var usageErr usageError
+		if stderrors.As(err, &usageErr) {
+			fmt.Fprint(stderr, "Run 'todo help' for usage.\n")
+		}
+	}
+	return code
+}
+
+// execute parses args and runs the command they name
+func execute(ctx context.Context, args []string, stdout io.Writer, getenv func(string) string) (int, error) {
+	cfg, err := loadSettings(getenv)
+	if err != nil {
+		return exitUsage, usageError{err.Error()}
+	}
+	opts := &options{server: cfg.Server, token: cfg.Token, output: "table", timeout: 10 * time.Second}
+
+	global := newFlagSet("todo")
+	opts.bind(global)
+	if err := global.Parse(args); err != nil {
+		return parseError(stdout, err)
+	}
+	if global.NArg() == 0 {
+		fmt.Fprint(stdout, usage)
+		return exitUsage, nil
+	}
+
+	name := global.Arg(0)
+	switch name {
+	case "help":
+		fmt.Fprint(stdout, usage)
+		return exitOK, nil
+	case "completion":
+		err := printCompletion(stdout, global.Args()[1:])
+		return exitCode(err), err
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		return exitUsage, usageError{fmt.Sprintf("unknown command %q", name)}
+	}
+
+	fs := newFlagSet("todo " + name)
+	opts.bind(fs)
+	runCommand := cmd(fs)
+	if err := fs.Parse(global.Args()[1:]); err != nil {
+		return parseError(stdout, err)
+	}
+
+	p, err := newPrinter(opts.output, stdout)
+	if err != nil {
+		return exitUsage, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	env := &environment{
+		client:  newClient(opts.server, opts.token),
+		printer: p,
+		stdout:  stdout,
+	}
+	err = runCommand(ctx, env, fs.Args())
+	return exitCode(err), err
+}
+
+// parseError handles a flag parsing error; -h and --help print the usage
+func parseError(stdout io.Writer, err error) (int, error) {
+	if stderrors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(stdout, usage)
+		return exitOK, nil
+	}
+	return exitUsage, usageError{err.Error()}
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// newClient returns a Connect client for server that sends token as a
+// bearer token
+func newClient(server, token string) taskconnect.TaskServiceClient {
+	var clientOpts []connect.ClientOption
+	if token != "" {
+		clientOpts = append(clientOpts, connect.WithInterceptors(bearerToken(token)))
+	}
+	return taskconnect.NewTaskServiceClient(http.DefaultClient, strings.TrimSuffix(server, "/"), clientOpts...)
+}
+
+// bearerToken adds an Authorization header to every request
+func bearerToken(token string) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			req.Header().Set("Authorization", "Bearer "+token)
+			return next(ctx, req)
+		}
+	}
+}
+
+// commands lists every subcommand by name
+var commands = map[string]command{
+	"add":              withoutFlags(runAdd),
+	"ls":               listCommand,
+	"show":             withoutFlags(runShow),
+	"done":             doneCommand,
+	"edit":             withoutFlags(runEdit),
+	"rm":               withoutFlags(runRemove),
+	completeIDsCommand: withoutFlags(runCompleteIDs),
+}
+
+// withoutFlags adapts a subcommand that has only the shared flags
+func withoutFlags(run runFunc) command {
+	return func(*flag.FlagSet) runFunc {
+		return run
+	}
+}
+
+// runAdd creates a task from the remaining arguments
+func runAdd(ctx context.Context, env *environment, args []string) error {
+	description := strings.Join(args, " ")
+	if description == "" {
+		return usageError{"add needs a description"}
+	}
+
+	resp, err := env.client.CreateTask(ctx, connect.NewRequest(&taskv1.CreateTaskRequest{Description: description}))
+	if err != nil {
+		return err
+	}
+	return env.printer.tasks([]*taskv1.Task{resp.Msg.Task})
+}
+
+// listCommand lists tasks, optionally only completed or pending ones
+func listCommand(fs *flag.FlagSet) runFunc {
+	done := fs.Bool("done", false, "only completed tasks")
+	pending := fs.Bool("pending", false, "only tasks that are not completed")
+
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) > 0 {
+			return usageError{"ls takes no arguments"}
+		}
+		if *done && *pending {
+			return usageError{"--done and --pending cannot be combined"}
+		}
+
+		resp, err := env.client.GetAllTasks(ctx, connect.NewRequest(&taskv1.GetAllTasksRequest{}))
+		if err != nil {
+			return err
+		}
+
+		tasks := resp.Msg.Tasks
+		if *done || *pending {
+			var filtered []*taskv1.Task
+			for _, task := range tasks {
+				if task.Completed == *done {
+					filtered = append(filtered, task)
+				}
+			}
+			tasks = filtered
+		}
+		return env.printer.tasks(tasks)
+	}
+}
+
+// runShow prints one task
+func runShow(ctx context.Context, env *environment, args []string) error {
+	if len(args) != 1 {
+		return usageError{"show needs exactly one task ID"}
+	}
+
+	resp, err := env.client.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: args[0]}))
+	if err != nil {
+		return err
+	}
+	return env.printer.tasks([]*taskv1.Task{resp.Msg.Task})
+}
+
+// doneCommand marks tasks as completed, or not completed with --undo
+func doneCommand(fs *flag.FlagSet) runFunc {
+	undo := fs.Bool("undo", false, "mark tasks as not completed")
+
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) == 0 {
+			return usageError{"done needs at least one task ID"}
+		}
+
+		var updated []*taskv1.Task
+		for _, id := range args {
+			task, err := updateTask(ctx, env.client, id, func(task *taskv1.Task) {
+				task.Completed = !*undo
+			})
+			if err != nil {
+				return err
+			}
+			updated = append(updated, task)
+		}
+		return env.printer.tasks(updated)
+	}
+}
+
+// runEdit replaces the description of a task
+func runEdit(ctx context.Context, env *environment, args []string) error {
+	if len(args) < 2 {
+		return usageError{"edit needs a task ID and a description"}
+	}
+	description := strings.Join(args[1:], " ")
+
+	task, err := updateTask(ctx, env.client, args[0], func(task *taskv1.Task) {
+		task.Description = description
+	})
+	if err != nil {
+		return err
+	}
+	return env.printer.tasks([]*taskv1.Task{task})
+}
+
+// updateTask reads a task, applies change and writes it back, since
+// task.v1 UpdateTask replaces both fields
+func updateTask(ctx context.Context, client taskconnect.TaskServiceClient, id string, change func(task *taskv1.Task)) (*taskv1.Task, error) {
+	current, err := client.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: id}))
+	if err != nil {
+		return nil, err
+	}
+
+	task := current.Msg.Task
+	change(task)
+	resp, err := client.UpdateTask(ctx, connect.NewRequest(&taskv1.UpdateTaskRequest{
+		Id:          id,
+		Description: task.Description,
+		Completed:   task.Completed,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg.Task, nil
+}
+
+// runRemove deletes tasks, stopping at the first failure
+func runRemove(ctx context.Context, env *environment, args []string) error {
+	if len(args) == 0 {
+		return usageError{"rm needs at least one task ID"}
+	}
+
+	var deleted []string
+	for _, id := range args {
+		resp, err := env.client.DeleteTask(ctx, connect.NewRequest(&taskv1.DeleteTaskRequest{Id: id}))
+		if err == nil && !resp.Msg.Success {
+			// Servers running with LEGACY_DELETE_RESPONSES report failures
+			// in the response
+			err = connect.NewError(connect.CodeUnknown, fmt.Errorf("%s", resp.Msg.Message))
+		}
+		if err != nil {
+			if len(deleted) > 0 {
+				env.printer.deleted(deleted)
+			}
+			return err
+		}
+		deleted = append(deleted, id)
+	}
+	return env.printer.deleted(deleted)
+}
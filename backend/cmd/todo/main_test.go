@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/handler"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// testServer serves the real TaskService handler over a mock store and
+// records the Authorization header of the last request
+type testServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	authorization string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	mux := http.NewServeMux()
+	mux.Handle(taskconnect.NewTaskServiceHandler(
+		handler.NewTaskHandler(service.NewTaskService(testutil.NewMockStore())),
+	))
+
+	s := &testServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.authorization = r.Header.Get("Authorization")
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// lastAuthorization returns the Authorization header of the last request
+func (s *testServer) lastAuthorization() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authorization
+}
+
+// todo runs the CLI against s and returns the exit code and output
+func (s *testServer) todo(t *testing.T, env map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	if env == nil {
+		env = map[string]string{}
+	}
+	if _, ok := env["TODO_SERVER"]; !ok {
+		env["TODO_SERVER"] = s.URL
+	}
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr, func(key string) string { return env[key] })
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLI_Workflow(t *testing.T) {
+	server := newTestServer(t)
+
+	code, out, _ := server.todo(t, nil, "-o", "plain", "add", "Buy", "milk")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "1\tfalse\tBuy milk\n", out)
+
+	code, _, _ = server.todo(t, nil, "add", "Walk dog")
+	require.Equal(t, exitOK, code)
+
+	code, out, _ = server.todo(t, nil, "done", "-o", "plain", "1")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "1\ttrue\tBuy milk\n", out)
+
+	code, out, _ = server.todo(t, nil, "ls", "-o", "plain", "--pending")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "2\tfalse\tWalk dog\n", out)
+
+	code, out, _ = server.todo(t, nil, "ls", "--done")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "ID")
+	assert.Contains(t, out, "[x]")
+	assert.NotContains(t, out, "Walk dog")
+
+	code, out, _ = server.todo(t, nil, "-o", "plain", "edit", "1", "Buy", "oat", "milk")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "1\ttrue\tBuy oat milk\n", out, "edit keeps the completed state")
+
+	code, out, _ = server.todo(t, nil, "-o", "plain", "done", "--undo", "1")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "1\tfalse\tBuy oat milk\n", out)
+
+	code, out, _ = server.todo(t, nil, "show", "-o", "json", "2")
+	require.Equal(t, exitOK, code)
+	var shown struct {
+		Tasks []struct {
+			ID          string `json:"id"`
+			Description string `json:"description"`
+			Completed   bool   `json:"completed"`
+		} `json:"tasks"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &shown))
+	require.Len(t, shown.Tasks, 1)
+	assert.Equal(t, "Walk dog", shown.Tasks[0].Description)
+	assert.False(t, shown.Tasks[0].Completed)
+
+	code, out, _ = server.todo(t, nil, "-o", "plain", "rm", "1", "2")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "1\n2\n", out)
+
+	code, out, _ = server.todo(t, nil, "ls")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "No tasks\n", out)
+}
+
+func TestCLI_ExitCodes(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no_command", nil, exitUsage},
+		{"unknown_command", []string{"frobnicate"}, exitUsage},
+		{"unknown_flag", []string{"ls", "--all"}, exitUsage},
+		{"unknown_output", []string{"-o", "yaml", "ls"}, exitUsage},
+		{"missing_description", []string{"add"}, exitUsage},
+		{"conflicting_filters", []string{"ls", "--done", "--pending"}, exitUsage},
+		{"not_found", []string{"show", "999"}, exitNotFound},
+		{"rm_not_found", []string{"rm", "999"}, exitNotFound},
+		{"help", []string{"help"}, exitOK},
+		{"help_flag", []string{"-h"}, exitOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := server.todo(t, nil, tt.args...)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+
+	code, _, stderr := server.todo(t, map[string]string{"TODO_SERVER": "http://127.0.0.1:1"}, "ls")
+	assert.Equal(t, exitUnavailable, code)
+	assert.Contains(t, stderr, "todo: unavailable")
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, exitOK, exitCode(nil))
+	assert.Equal(t, exitUsage, exitCode(usageError{"bad"}))
+	assert.Equal(t, exitUsage, exitCode(connect.NewError(connect.CodeInvalidArgument, nil)))
+	assert.Equal(t, exitNotFound, exitCode(connect.NewError(connect.CodeNotFound, nil)))
+	assert.Equal(t, exitConflict, exitCode(connect.NewError(connect.CodeAborted, nil)))
+	assert.Equal(t, exitAuth, exitCode(connect.NewError(connect.CodeUnauthenticated, nil)))
+	assert.Equal(t, exitRateLimited, exitCode(connect.NewError(connect.CodeResourceExhausted, nil)))
+	assert.Equal(t, exitUnavailable, exitCode(connect.NewError(connect.CodeDeadlineExceeded, nil)))
+	assert.Equal(t, exitError, exitCode(connect.NewError(connect.CodeInternal, nil)))
+}
+
+func TestCLI_Settings(t *testing.T) {
+	server := newTestServer(t)
+
+	dir := t.TempDir()
+	dotfile := filepath.Join(dir, ".todorc")
+	require.NoError(t, os.WriteFile(dotfile, []byte("# todo settings\nserver = "+server.URL+"\ntoken = \"from-file\"\n"), 0o600))
+
+	// The dotfile in HOME is read by default
+	code, _, _ := server.todo(t, map[string]string{"TODO_SERVER": "", "HOME": dir}, "ls")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "Bearer from-file", server.lastAuthorization())
+
+	// Environment variables override the dotfile, and flags override both
+	code, _, _ = server.todo(t, map[string]string{"HOME": dir, "TODO_TOKEN": "from-env"}, "ls")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "Bearer from-env", server.lastAuthorization())
+
+	code, _, _ = server.todo(t, map[string]string{"HOME": dir, "TODO_TOKEN": "from-env"}, "--token", "from-flag", "ls")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "Bearer from-flag", server.lastAuthorization())
+
+	// TODO_CONFIG must exist when set, and unknown keys are rejected
+	code, _, _ = server.todo(t, map[string]string{"TODO_CONFIG": filepath.Join(dir, "missing")}, "ls")
+	assert.Equal(t, exitUsage, code)
+
+	require.NoError(t, os.WriteFile(dotfile, []byte("sever = typo\n"), 0o600))
+	code, _, stderr := server.todo(t, map[string]string{"TODO_CONFIG": dotfile}, "ls")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown key "sever"`)
+
+	// A missing dotfile in HOME is fine
+	code, _, _ = server.todo(t, map[string]string{"HOME": filepath.Join(dir, "nowhere")}, "ls")
+	assert.Equal(t, exitOK, code)
+}
+
+func TestCLI_Completion(t *testing.T) {
+	server := newTestServer(t)
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		code, out, _ := server.todo(t, nil, "completion", shell)
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, out, completeIDsCommand, shell)
+	}
+
+	code, _, _ := server.todo(t, nil, "completion", "powershell")
+	assert.Equal(t, exitUsage, code)
+
+	server.todo(t, nil, "add", "Buy milk")
+	server.todo(t, nil, "add", "Walk dog")
+	code, out, _ := server.todo(t, nil, completeIDsCommand)
+	assert.Equal(t, exitOK, code)
+	assert.ElementsMatch(t, []string{"1", "2"}, strings.Fields(out))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// printer renders command results in one output format
+type printer interface {
+	tasks(tasks []*taskv1.Task) error
+	deleted(ids []string) error
+}
+
+// newPrinter returns the printer for format
+func newPrinter(format string, w io.Writer) (printer, error) {
+	switch format {
+	case "table":
+		return tablePrinter{w: w}, nil
+	case "json":
+		return jsonPrinter{w: w}, nil
+	case "plain":
+		return plainPrinter{w: w}, nil
+	default:
+		return nil, usageError{fmt.Sprintf("unknown output format %q (must be table, json or plain)", format)}
+	}
+}
+
+// tablePrinter writes aligned columns with a header, for people
+type tablePrinter struct {
+	w io.Writer
+}
+
+func (p tablePrinter) tasks(tasks []*taskv1.Task) error {
+	if len(tasks) == 0 {
+		_, err := fmt.Fprintln(p.w, "No tasks")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDONE\tDESCRIPTION\tCREATED")
+	for _, task := range tasks {
+		done := "[ ]"
+		if task.Completed {
+			done = "[x]"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			task.Id, done, singleLine(task.Description),
+			task.CreatedAt.AsTime().Local().Format(time.DateTime))
+	}
+	return tw.Flush()
+}
+
+func (p tablePrinter) deleted(ids []string) error {
+	_, err := fmt.Fprintf(p.w, "Deleted %s\n", strings.Join(ids, ", "))
+	return err
+}
+
+// jsonPrinter writes protobuf JSON, for tools such as jq
+type jsonPrinter struct {
+	w io.Writer
+}
+
+// taskJSON includes every field, so "completed": false is never omitted
+var taskJSON = protojson.MarshalOptions{Multiline: true, Indent: "  ", EmitUnpopulated: true}
+
+func (p jsonPrinter) tasks(tasks []*taskv1.Task) error {
+	data, err := taskJSON.Marshal(&taskv1.GetAllTasksResponse{Tasks: tasks})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(p.w, string(data))
+	return err
+}
+
+func (p jsonPrinter) deleted(ids []string) error {
+	data, err := json.MarshalIndent(map[string][]string{"deleted": ids}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(p.w, string(data))
+	return err
+}
+
+// plainPrinter writes one tab-separated line per task with no header, for
+// shell pipelines: ID, completed and description
+type plainPrinter struct {
+	w io.Writer
+}
+
+func (p plainPrinter) tasks(tasks []*taskv1.Task) error {
+	for _, task := range tasks {
+		if _, err := fmt.Fprintf(p.w, "%s\t%s\t%s\n",
+			task.Id, strconv.FormatBool(task.Completed), singleLine(task.Description)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p plainPrinter) deleted(ids []string) error {
+	for _, id := range ids {
+		if _, err := fmt.Fprintln(p.w, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// singleLine collapses whitespace so that a description fits on one line
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
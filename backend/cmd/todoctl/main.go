@@ -0,0 +1,263 @@
+// Command todoctl runs maintenance operations against the task database.
+//
+// Usage:
+//
+//	todoctl <command> [flags] [arguments]
+//
+// It reads the same configuration as the server (see config.Load) and connects
+// to the database directly, so it works while the server is down. Unlike the
+// server it never migrates implicitly: run "todoctl migrate up" first. Run
+// "todoctl help" for the commands.
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/store"
+)
+
+const usage = `Usage: todoctl <command> [flags] [arguments]
+
+Commands:
+  migrate up                          Apply every pending migration
+  migrate down [--all] [N]            Roll back N migrations (default 1), or all of them
+  migrate status                      Show the schema version and pending migrations
+  migrate force <version|none>        Mark version as applied and clear the dirty flag
+  migrate goto <version>              Migrate up or down to version
+  db check                            Check the connection and schema; exits 1 if unhealthy
+  tasks export [--format F] [-o FILE] Write every task to FILE (default stdout)
+  tasks import [--format F] [--dry-run] [FILE]
+                                      Create tasks from FILE (default stdin)
+  tasks purge --completed-before T [--dry-run]
+                                      Delete completed tasks last updated before T
+  seed --count N                      Create N sample tasks
+
+Formats are ndjson, csv and markdown; import infers the format from the file
+extension when --format is not given. T is a time (2006-01-02 or RFC 3339) or
+an age such as 30d or 12h.
+
+The database is configured like the server: from the file named by CONFIG_FILE
+and the environment variables (DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME,
+...) that override it.
+
+Exit codes:
+  0 success, 1 error, 2 usage
+`
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// usageError reports a bad command line
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// taskStore is the part of store.MySQLTaskStore that commands use
+type taskStore interface {
+	store.TaskRepository
+	store.TaskImporter
+	PurgeCompletedTasks(ctx context.Context, cutoff time.Time) (int64, error)
+	CountTasks(ctx context.Context) (total, completed int64, err error)
+	HealthCheck(ctx context.Context) error
+	Close() error
+}
+
+// migrator is the part of store.Migrator that commands use
+type migrator interface {
+	Up() error
+	Down(steps int) error
+	DownAll() error
+	Goto(version uint) error
+	Force(version int) error
+	Status() (*store.MigrationStatus, error)
+	Close() error
+}
+
+// Verify that the store package provides every method commands use
+var (
+	_ taskStore = (*store.MySQLTaskStore)(nil)
+	_ migrator  = (*store.Migrator)(nil)
+)
+
+// connectFunc opens the task store and a migrator on the same database
+type connectFunc func() (taskStore, migrator, error)
+
+// command registers the flags of a subcommand on fs and returns the
+// function that runs it with the remaining arguments
+type command func(fs *flag.FlagSet) runFunc
+
+// runFunc runs a subcommand
+type runFunc func(ctx context.Context, env *environment, args []string) error
+
+// environment is what commands run against
+type environment struct {
+	connect connectFunc
+	stdin   io.Reader
+	stdout  io.Writer
+	now     func() time.Time
+}
+
+// openTasks connects to the database and checks that the schema is current,
+// since task commands must not run against a half-migrated schema
+func (env *environment) openTasks() (taskStore, error) {
+	s, m, err := env.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	status, err := m.Status()
+	if err == nil {
+		err = schemaProblem(status)
+	}
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// commands lists every command by name; grouped commands are keyed by both
+// words
+var commands = map[string]command{
+	"migrate up":     withoutFlags(runMigrateUp),
+	"migrate down":   migrateDownCommand,
+	"migrate status": withoutFlags(runMigrateStatus),
+	"migrate force":  withoutFlags(runMigrateForce),
+	"migrate goto":   withoutFlags(runMigrateGoto),
+	"db check":       withoutFlags(runDBCheck),
+	"tasks export":   exportCommand,
+	"tasks import":   importCommand,
+	"tasks purge":    purgeCommand,
+	"seed":           seedCommand,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	env := &environment{
+		connect: connectFromEnv,
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+		now:     time.Now,
+	}
+	os.Exit(run(ctx, os.Args[1:], env, os.Stderr))
+}
+
+// connectFromEnv loads the server configuration and opens the database
+// without running migrations
+func connectFromEnv() (taskStore, migrator, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	s, err := store.OpenMySQLTaskStore(&cfg.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := store.NewMigrator(s.GetDB(), &cfg.Database)
+	if err != nil {
+		s.Close()
+		return nil, nil, err
+	}
+	return s, m, nil
+}
+
+// run executes the command line in args and returns the exit code
+func run(ctx context.Context, args []string, env *environment, stderr io.Writer) int {
+	code, err := execute(ctx, args, env)
+	if err != nil {
+		fmt.Fprintf(stderr, "todoctl: %v\n", err)
+		var usageErr usageError
+		if stderrors.As(err, &usageErr) {
+			fmt.Fprint(stderr, "Run 'todoctl help' for usage.\n")
+		}
+	}
+	return code
+}
+
+// execute finds the command named by args and runs it
+func execute(ctx context.Context, args []string, env *environment) (int, error) {
+	if len(args) == 0 {
+		fmt.Fprint(env.stdout, usage)
+		return exitUsage, nil
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(env.stdout, usage)
+		return exitOK, nil
+	}
+
+	name, rest := args[0], args[1:]
+	cmd, ok := commands[name]
+	if !ok && len(args) > 1 {
+		name, rest = args[0]+" "+args[1], args[2:]
+		cmd, ok = commands[name]
+	}
+	if !ok {
+		if isGroup(args[0]) {
+			if len(args) == 1 {
+				return exitUsage, usageError{fmt.Sprintf("%s needs a subcommand", args[0])}
+			}
+			return exitUsage, usageError{fmt.Sprintf("unknown command %q", args[0]+" "+args[1])}
+		}
+		return exitUsage, usageError{fmt.Sprintf("unknown command %q", args[0])}
+	}
+
+	fs := flag.NewFlagSet("todoctl "+name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	runCommand := cmd(fs)
+	if err := fs.Parse(rest); err != nil {
+		if stderrors.Is(err, flag.ErrHelp) {
+			fmt.Fprint(env.stdout, usage)
+			return exitOK, nil
+		}
+		return exitUsage, usageError{err.Error()}
+	}
+
+	if err := runCommand(ctx, env, fs.Args()); err != nil {
+		var usageErr usageError
+		if stderrors.As(err, &usageErr) {
+			return exitUsage, err
+		}
+		return exitError, err
+	}
+	return exitOK, nil
+}
+
+// isGroup reports whether name is the first word of a grouped command
+func isGroup(name string) bool {
+	for key := range commands {
+		if strings.HasPrefix(key, name+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutFlags adapts a command that takes no flags
+func withoutFlags(run runFunc) command {
+	return func(*flag.FlagSet) runFunc {
+		return run
+	}
+}
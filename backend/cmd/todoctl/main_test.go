@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// fakeStore adds the admin operations of MySQLTaskStore to the mock store
+type fakeStore struct {
+	*testutil.MockStore
+}
+
+func (s *fakeStore) ImportTasks(ctx context.Context, tasks []store.NewTask) ([]*taskv1.Task, error) {
+	imported := make([]*taskv1.Task, len(tasks))
+	for i, task := range tasks {
+		created, err := s.CreateTask(ctx, task.Description)
+		if err != nil {
+			return nil, err
+		}
+		if task.Completed {
+			if created, err = s.UpdateTask(ctx, created.Id, task.Description, true); err != nil {
+				return nil, err
+			}
+		}
+		imported[i] = created
+	}
+	return imported, nil
+}
+
+func (s *fakeStore) PurgeCompletedTasks(ctx context.Context, cutoff time.Time) (int64, error) {
+	tasks, err := s.ListTasks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, task := range tasks {
+		if task.Completed && task.UpdatedAt.AsTime().Before(cutoff) {
+			if err := s.DeleteTask(ctx, task.Id); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *fakeStore) CountTasks(ctx context.Context) (total, completed int64, err error) {
+	tasks, err := s.ListTasks(ctx)
+	for _, task := range tasks {
+		total++
+		if task.Completed {
+			completed++
+		}
+	}
+	return total, completed, err
+}
+
+func (s *fakeStore) HealthCheck(ctx context.Context) error { return nil }
+
+func (s *fakeStore) Close() error { return nil }
+
+// fakeMigrator tracks a schema version over the migrations 1 and 2
+type fakeMigrator struct {
+	version uint
+	dirty   bool
+	// applied is the checksum recorded for the schema, if any
+	applied string
+}
+
+var fakeVersions = []uint{1, 2}
+
+func (m *fakeMigrator) Up() error { m.version = 2; return nil }
+
+func (m *fakeMigrator) Down(steps int) error {
+	m.version -= min(uint(steps), m.version)
+	return nil
+}
+
+func (m *fakeMigrator) DownAll() error { m.version = 0; return nil }
+
+func (m *fakeMigrator) Goto(version uint) error {
+	if !slices.Contains(fakeVersions, version) {
+		return fmt.Errorf("migration version %d does not exist", version)
+	}
+	m.version = version
+	return nil
+}
+
+func (m *fakeMigrator) Force(version int) error {
+	m.version = uint(max(version, 0))
+	m.dirty = false
+	return nil
+}
+
+func (m *fakeMigrator) Status() (*store.MigrationStatus, error) {
+	status := &store.MigrationStatus{Version: m.version, Dirty: m.dirty, Latest: 2, AppliedChecksum: m.applied}
+	if m.applied != "" {
+		status.Checksum = fmt.Sprintf("checksum-%d", m.version)
+	}
+	for _, v := range fakeVersions {
+		if v > m.version {
+			status.Pending = append(status.Pending, v)
+		}
+	}
+	return status, nil
+}
+
+func (m *fakeMigrator) Close() error { return nil }
+
+// testDB is an in-memory database for running todoctl commands
+type testDB struct {
+	store    *fakeStore
+	migrator *fakeMigrator
+	now      time.Time
+}
+
+func newTestDB() *testDB {
+	return &testDB{
+		store:    &fakeStore{MockStore: testutil.NewMockStore()},
+		migrator: &fakeMigrator{version: 2},
+		now:      time.Now(),
+	}
+}
+
+// todoctl runs a command line and returns the exit code and output
+func (db *testDB) todoctl(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	env := &environment{
+		connect: func() (taskStore, migrator, error) {
+			return db.store, db.migrator, nil
+		},
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		now:    func() time.Time { return db.now },
+	}
+	code := run(context.Background(), args, env, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestTodoctl_Usage(t *testing.T) {
+	db := newTestDB()
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no_command", nil, exitUsage},
+		{"help", []string{"help"}, exitOK},
+		{"help_flag", []string{"tasks", "purge", "-h"}, exitOK},
+		{"unknown_command", []string{"frobnicate"}, exitUsage},
+		{"unknown_subcommand", []string{"migrate", "sideways"}, exitUsage},
+		{"missing_subcommand", []string{"tasks"}, exitUsage},
+		{"unknown_flag", []string{"migrate", "up", "--all"}, exitUsage},
+		{"down_bad_steps", []string{"migrate", "down", "0"}, exitUsage},
+		{"down_all_and_steps", []string{"migrate", "down", "--all", "1"}, exitUsage},
+		{"goto_bad_version", []string{"migrate", "goto", "two"}, exitUsage},
+		{"force_missing_version", []string{"migrate", "force"}, exitUsage},
+		{"purge_missing_cutoff", []string{"tasks", "purge"}, exitUsage},
+		{"purge_bad_cutoff", []string{"tasks", "purge", "--completed-before", "last week"}, exitUsage},
+		{"seed_missing_count", []string{"seed"}, exitUsage},
+		{"unknown_format", []string{"tasks", "export", "--format", "yaml"}, exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := db.todoctl(t, "", tt.args...)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+
+	code, _, stderr := db.todoctl(t, "", "migrate", "sideways")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, `unknown command "migrate sideways"`)
+}
+
+func TestTodoctl_Migrate(t *testing.T) {
+	db := newTestDB()
+
+	code, out, _ := db.todoctl(t, "", "migrate", "status")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "Schema version: 2 (clean)\nLatest version: 2\nPending: none\n", out)
+
+	code, out, _ = db.todoctl(t, "", "migrate", "down")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Schema version: 1 (clean)")
+	assert.Contains(t, out, "Pending: 2")
+
+	code, out, _ = db.todoctl(t, "", "migrate", "down", "--all")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Schema version: none (clean)")
+	assert.Contains(t, out, "Pending: 1, 2")
+
+	code, _, _ = db.todoctl(t, "", "migrate", "goto", "1")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, uint(1), db.migrator.version)
+
+	code, _, stderr := db.todoctl(t, "", "migrate", "goto", "7")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "does not exist")
+
+	code, _, _ = db.todoctl(t, "", "migrate", "up")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, uint(2), db.migrator.version)
+
+	db.migrator.dirty = true
+	code, out, _ = db.todoctl(t, "", "migrate", "force", "2")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Schema version: 2 (clean)")
+
+	code, out, _ = db.todoctl(t, "", "migrate", "force", "none")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Schema version: none (clean)")
+}
+
+func TestTodoctl_DBCheck(t *testing.T) {
+	db := newTestDB()
+	db.store.AddTask(testutil.CreateTestTaskWithID("1", "Buy milk"))
+	db.store.AddTask(testutil.CreateCompletedTestTask("2", "Walk dog"))
+
+	code, out, _ := db.todoctl(t, "", "db", "check")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Connection: ok")
+	assert.Contains(t, out, "Tasks: 2 (1 completed)")
+
+	db.migrator.version = 1
+	code, _, stderr := db.todoctl(t, "", "db", "check")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "run 'todoctl migrate up'")
+
+	db.migrator.version, db.migrator.dirty = 2, true
+	code, _, stderr = db.todoctl(t, "", "db", "check")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "is dirty")
+
+	db.migrator.dirty, db.migrator.applied = false, "checksum-2"
+	code, _, _ = db.todoctl(t, "", "db", "check")
+	assert.Equal(t, exitOK, code)
+
+	db.migrator.applied = "edited"
+	code, out, stderr = db.todoctl(t, "", "db", "check")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, out, "Applied checksum: edited")
+	assert.Contains(t, stderr, "run 'todoctl migrate force 2'")
+}
+
+func TestTodoctl_Tasks(t *testing.T) {
+	db := newTestDB()
+
+	code, out, _ := db.todoctl(t, "", "seed", "--count", "3")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "Created 3 tasks\n", out)
+	assert.Equal(t, 3, db.store.TaskCount())
+
+	file := filepath.Join(t.TempDir(), "tasks.csv")
+	code, _, _ = db.todoctl(t, "", "tasks", "export", "--format", "csv", "-o", file)
+	require.Equal(t, exitOK, code)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Sample task 3,true")
+
+	// Importing the export back skips every row as a duplicate
+	code, out, _ = db.todoctl(t, "", "tasks", "import", file)
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Imported 0 tasks (3 duplicates skipped)")
+
+	code, out, _ = db.todoctl(t, "- [ ] Water plants\n- [x] File taxes\n", "tasks", "import", "--format", "markdown", "--dry-run")
+	require.Equal(t, exitOK, code)
+	assert.Equal(t, "Would import 2 tasks (0 duplicates skipped)\n", out)
+	assert.Equal(t, 3, db.store.TaskCount())
+
+	code, out, stderr := db.todoctl(t, "{\"description\": \"\"}\n", "tasks", "import")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, out, "line 1: description")
+	assert.Contains(t, stderr, "nothing was imported")
+
+	code, out, _ = db.todoctl(t, "", "tasks", "export")
+	require.Equal(t, exitOK, code)
+	assert.Len(t, strings.Split(strings.TrimSpace(out), "\n"), 3)
+
+	// Only "Sample task 3" is completed, and it was last updated before now
+	db.now = time.Now().Add(time.Hour)
+	code, out, _ = db.todoctl(t, "", "tasks", "purge", "--completed-before", "30m", "--dry-run")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Would purge 1 completed tasks")
+	assert.Equal(t, 3, db.store.TaskCount())
+
+	code, out, _ = db.todoctl(t, "", "tasks", "purge", "--completed-before", "30m")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "Purged 1 completed tasks")
+	assert.Equal(t, 2, db.store.TaskCount())
+
+	// Task commands refuse to run against a schema that is behind
+	db.migrator.version = 1
+	code, _, stderr = db.todoctl(t, "", "seed", "--count", "1")
+	assert.Equal(t, exitError, code)
+	assert.Contains(t, stderr, "run 'todoctl migrate up'")
+	assert.Equal(t, 2, db.store.TaskCount())
+}
+
+func TestParseCutoff(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-31T08:30:00Z", time.Date(2024, 1, 31, 8, 30, 0, 0, time.UTC)},
+		{"30d", now.AddDate(0, 0, -30)},
+		{"12h", now.Add(-12 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := parseCutoff(tt.value, now)
+		require.NoError(t, err, tt.value)
+		assert.True(t, tt.want.Equal(got), "%s: got %v", tt.value, got)
+	}
+
+	for _, value := range []string{"", "yesterday", "-5d", "0h", "xd"} {
+		_, err := parseCutoff(value, now)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	assert.Equal(t, "csv", formatForPath("tasks.CSV"))
+	assert.Equal(t, "markdown", formatForPath("notes.md"))
+	assert.Equal(t, "ndjson", formatForPath("tasks.ndjson"))
+	assert.Equal(t, "ndjson", formatForPath("-"))
+}
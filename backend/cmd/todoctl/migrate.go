@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/wcygan/todo/backend/internal/store"
+)
+
+// withMigrator connects to the database and runs fn with its migrator
+func withMigrator(env *environment, fn func(m migrator) error) error {
+	s, m, err := env.connect()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	defer m.Close()
+	return fn(m)
+}
+
+// runMigrateUp applies every pending migration
+func runMigrateUp(ctx context.Context, env *environment, args []string) error {
+	if len(args) > 0 {
+		return usageError{"migrate up takes no arguments"}
+	}
+	return withMigrator(env, func(m migrator) error {
+		if err := m.Up(); err != nil {
+			return err
+		}
+		return printStatus(env, m)
+	})
+}
+
+// migrateDownCommand rolls back a number of migrations, or all of them with
+// --all
+func migrateDownCommand(fs *flag.FlagSet) runFunc {
+	all := fs.Bool("all", false, "roll back every migration")
+
+	return func(ctx context.Context, env *environment, args []string) error {
+		steps := 1
+		switch {
+		case len(args) > 1:
+			return usageError{"migrate down takes at most one step count"}
+		case len(args) == 1 && *all:
+			return usageError{"--all cannot be combined with a step count"}
+		case len(args) == 1:
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return usageError{fmt.Sprintf("invalid step count %q", args[0])}
+			}
+			steps = n
+		}
+
+		return withMigrator(env, func(m migrator) error {
+			var err error
+			if *all {
+				err = m.DownAll()
+			} else {
+				err = m.Down(steps)
+			}
+			if err != nil {
+				return err
+			}
+			return printStatus(env, m)
+		})
+	}
+}
+
+// runMigrateStatus prints the schema version and pending migrations
+func runMigrateStatus(ctx context.Context, env *environment, args []string) error {
+	if len(args) > 0 {
+		return usageError{"migrate status takes no arguments"}
+	}
+	return withMigrator(env, func(m migrator) error {
+		return printStatus(env, m)
+	})
+}
+
+// runMigrateForce records a version without running migrations; "none"
+// records that no migration has run
+func runMigrateForce(ctx context.Context, env *environment, args []string) error {
+	if len(args) != 1 {
+		return usageError{"migrate force needs a version, or none"}
+	}
+	version := -1
+	if args[0] != "none" {
+		v, err := parseVersion(args[0])
+		if err != nil {
+			return err
+		}
+		version = int(v)
+	}
+
+	return withMigrator(env, func(m migrator) error {
+		if err := m.Force(version); err != nil {
+			return err
+		}
+		return printStatus(env, m)
+	})
+}
+
+// runMigrateGoto migrates up or down to a version
+func runMigrateGoto(ctx context.Context, env *environment, args []string) error {
+	if len(args) != 1 {
+		return usageError{"migrate goto needs a version"}
+	}
+	version, err := parseVersion(args[0])
+	if err != nil {
+		return err
+	}
+
+	return withMigrator(env, func(m migrator) error {
+		if err := m.Goto(version); err != nil {
+			return err
+		}
+		return printStatus(env, m)
+	})
+}
+
+// parseVersion parses a migration version such as 2
+func parseVersion(value string) (uint, error) {
+	version, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || version == 0 {
+		return 0, usageError{fmt.Sprintf("invalid migration version %q", value)}
+	}
+	return uint(version), nil
+}
+
+// printStatus reads and prints the migration status of m
+func printStatus(env *environment, m migrator) error {
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	writeStatus(env.stdout, status)
+	return nil
+}
+
+// writeStatus writes status in a human-readable form
+func writeStatus(w io.Writer, status *store.MigrationStatus) {
+	state := "clean"
+	if status.Dirty {
+		state = "dirty"
+	}
+	version := "none"
+	if status.Version > 0 {
+		version = strconv.FormatUint(uint64(status.Version), 10)
+	}
+	pending := "none"
+	if len(status.Pending) > 0 {
+		versions := make([]string, len(status.Pending))
+		for i, v := range status.Pending {
+			versions[i] = strconv.FormatUint(uint64(v), 10)
+		}
+		pending = strings.Join(versions, ", ")
+	}
+
+	fmt.Fprintf(w, "Schema version: %s (%s)\n", version, state)
+	fmt.Fprintf(w, "Latest version: %d\n", status.Latest)
+	fmt.Fprintf(w, "Pending: %s\n", pending)
+	if status.Checksum != "" {
+		fmt.Fprintf(w, "Checksum: %s\n", status.Checksum)
+	}
+	if status.AppliedChecksum != "" && status.AppliedChecksum != status.Checksum {
+		fmt.Fprintf(w, "Applied checksum: %s\n", status.AppliedChecksum)
+	}
+	if status.Source != "" {
+		fmt.Fprintf(w, "Migrations: %s\n", status.Source)
+	}
+}
+
+// schemaProblem describes why the schema is not usable, or returns nil
+func schemaProblem(status *store.MigrationStatus) error {
+	switch {
+	case status.Dirty:
+		return fmt.Errorf("schema version %d is dirty; repair it and run 'todoctl migrate force %d'", status.Version, status.Version)
+	case status.Drifted():
+		return fmt.Errorf("migrations checksum %s does not match %s applied at version %d; reconcile the schema and run 'todoctl migrate force %d'",
+			status.Checksum, status.AppliedChecksum, status.Version, status.Version)
+	case len(status.Pending) > 0:
+		return fmt.Errorf("schema version %d is behind %d; run 'todoctl migrate up'", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// runDBCheck checks the connection and the schema and prints task counts
+func runDBCheck(ctx context.Context, env *environment, args []string) error {
+	if len(args) > 0 {
+		return usageError{"db check takes no arguments"}
+	}
+
+	s, m, err := env.connect()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	defer m.Close()
+
+	if err := s.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("database is not reachable: %w", err)
+	}
+	fmt.Fprintln(env.stdout, "Connection: ok")
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	writeStatus(env.stdout, status)
+	if err := schemaProblem(status); err != nil {
+		return err
+	}
+
+	total, completed, err := s.CountTasks(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "Tasks: %d (%d completed)\n", total, completed)
+	return nil
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/internal/store"
+)
+
+// seedBatchSize is the number of tasks seed writes per transaction
+const seedBatchSize = 1000
+
+// exportCommand writes every task to a file or stdout
+func exportCommand(fs *flag.FlagSet) runFunc {
+	formatName := fs.String("format", "ndjson", "file format")
+	output := fs.String("output", "", "output file")
+	fs.StringVar(output, "o", "", "output file")
+
+	return func(ctx context.Context, env *environment, args []string) (err error) {
+		if len(args) > 0 {
+			return usageError{"tasks export takes no arguments"}
+		}
+		format, err := parseFormat(*formatName)
+		if err != nil {
+			return err
+		}
+
+		s, err := env.openTasks()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		w := env.stdout
+		if *output != "" && *output != "-" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if closeErr := f.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					os.Remove(*output)
+				}
+			}()
+			w = f
+		}
+
+		bw := bufio.NewWriter(w)
+		ctx = store.WithPrimaryReads(ctx)
+		if err := service.NewTaskService(s).ExportTasks(ctx, format, bw); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+}
+
+// importCommand creates tasks from a file or stdin
+func importCommand(fs *flag.FlagSet) runFunc {
+	formatName := fs.String("format", "", "file format; inferred from the file extension by default")
+	dryRun := fs.Bool("dry-run", false, "validate without writing")
+
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) > 1 {
+			return usageError{"tasks import takes at most one file"}
+		}
+		path := "-"
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		name := *formatName
+		if name == "" {
+			name = formatForPath(path)
+		}
+		format, err := parseFormat(name)
+		if err != nil {
+			return err
+		}
+
+		r := env.stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+
+		s, err := env.openTasks()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		result, err := service.NewTaskService(s).ImportTasks(ctx, format, r, service.ImportOptions{DryRun: *dryRun})
+		if err != nil {
+			return err
+		}
+
+		for _, duplicate := range result.Duplicates {
+			fmt.Fprintf(env.stdout, "line %d: skipped duplicate %q\n", duplicate.Line, duplicate.Description)
+		}
+		if len(result.Errors) > 0 {
+			for _, rowErr := range result.Errors {
+				fmt.Fprintf(env.stdout, "line %d: %s: %s\n", rowErr.Line, rowErr.Field, rowErr.Message)
+			}
+			return fmt.Errorf("%d invalid rows; nothing was imported", len(result.Errors))
+		}
+
+		verb := "Imported"
+		if result.DryRun {
+			verb = "Would import"
+		}
+		fmt.Fprintf(env.stdout, "%s %d tasks (%d duplicates skipped)\n", verb, len(result.Tasks), len(result.Duplicates))
+		return nil
+	}
+}
+
+// purgeCommand deletes completed tasks last updated before a cutoff
+func purgeCommand(fs *flag.FlagSet) runFunc {
+	before := fs.String("completed-before", "", "time or age, such as 2024-01-31 or 30d")
+	dryRun := fs.Bool("dry-run", false, "count the tasks without deleting them")
+
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) > 0 {
+			return usageError{"tasks purge takes no arguments"}
+		}
+		if *before == "" {
+			return usageError{"tasks purge needs --completed-before"}
+		}
+		cutoff, err := parseCutoff(*before, env.now())
+		if err != nil {
+			return err
+		}
+
+		s, err := env.openTasks()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		if *dryRun {
+			tasks, err := s.ListTasks(store.WithPrimaryReads(ctx))
+			if err != nil {
+				return err
+			}
+			count := 0
+			for _, task := range tasks {
+				if task.Completed && task.UpdatedAt.AsTime().Before(cutoff) {
+					count++
+				}
+			}
+			fmt.Fprintf(env.stdout, "Would purge %d completed tasks last updated before %s\n", count, cutoff.Format(time.RFC3339))
+			return nil
+		}
+
+		purged, err := s.PurgeCompletedTasks(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env.stdout, "Purged %d completed tasks last updated before %s\n", purged, cutoff.Format(time.RFC3339))
+		return nil
+	}
+}
+
+// seedCommand creates sample tasks, every third one completed
+func seedCommand(fs *flag.FlagSet) runFunc {
+	count := fs.Int("count", 0, "number of tasks to create")
+
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) > 0 {
+			return usageError{"seed takes no arguments"}
+		}
+		if *count <= 0 {
+			return usageError{"seed needs a positive --count"}
+		}
+
+		s, err := env.openTasks()
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		created := 0
+		for created < *count {
+			batch := make([]store.NewTask, min(seedBatchSize, *count-created))
+			for i := range batch {
+				n := created + i + 1
+				batch[i] = store.NewTask{
+					Description: fmt.Sprintf("Sample task %d", n),
+					Completed:   n%3 == 0,
+				}
+			}
+			tasks, err := s.ImportTasks(ctx, batch)
+			if err != nil {
+				return fmt.Errorf("created %d of %d tasks: %w", created, *count, err)
+			}
+			created += len(tasks)
+		}
+		fmt.Fprintf(env.stdout, "Created %d tasks\n", created)
+		return nil
+	}
+}
+
+// parseFormat parses a --format value
+func parseFormat(name string) (service.TaskFormat, error) {
+	format, err := service.ParseTaskFormat(name)
+	if err != nil {
+		return 0, usageError{fmt.Sprintf("unknown format %q (must be ndjson, csv or markdown)", name)}
+	}
+	return format, nil
+}
+
+// formatForPath infers the format of an import file from its extension,
+// defaulting to ndjson
+func formatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv"
+	case ".md", ".markdown":
+		return "markdown"
+	default:
+		return "ndjson"
+	}
+}
+
+// parseCutoff parses a --completed-before value: a date, an RFC 3339 time,
+// or an age before now such as 30d or 12h
+func parseCutoff(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.UTC); err == nil {
+		return t, nil
+	}
+
+	var age time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return time.Time{}, invalidCutoff(value)
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return time.Time{}, invalidCutoff(value)
+		}
+		age = d
+	}
+	if age <= 0 {
+		return time.Time{}, usageError{fmt.Sprintf("--completed-before age must be positive, got %q", value)}
+	}
+	return now.Add(-age), nil
+}
+
+// invalidCutoff reports a --completed-before value that cannot be parsed
+func invalidCutoff(value string) error {
+	return usageError{fmt.Sprintf("invalid --completed-before %q (want 2006-01-02, an RFC 3339 time, or an age such as 30d)", value)}
+}
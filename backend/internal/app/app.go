@@ -0,0 +1,171 @@
+// Package app assembles the HTTP handler that serves every endpoint of the
+// backend, so the server binary and end-to-end tests run the same stack
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
+	"connectrpc.com/connect"
+	"connectrpc.com/grpcreflect"
+	"connectrpc.com/otelconnect"
+
+	"github.com/wcygan/todo/backend/internal/calendar"
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/cors"
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/gen/task/v2/taskv2connect"
+	"github.com/wcygan/todo/backend/internal/handler"
+	"github.com/wcygan/todo/backend/internal/health"
+	"github.com/wcygan/todo/backend/internal/logger"
+	"github.com/wcygan/todo/backend/internal/metrics"
+	"github.com/wcygan/todo/backend/internal/middleware"
+	"github.com/wcygan/todo/backend/internal/ratelimit"
+	"github.com/wcygan/todo/backend/internal/rest"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/internal/tracing"
+)
+
+// Protocols lists the RPC protocols every TaskService endpoint accepts.
+// Browsers use Connect or gRPC-Web over HTTP/1.1 or HTTP/2; gRPC needs
+// HTTP/2, which plaintext listeners provide through h2c.
+var Protocols = []string{"connect", "grpc", "grpc-web"}
+
+// Deps are the services behind the handler
+type Deps struct {
+	TaskService *service.TaskService
+	// Health serves /livez, /readyz, /startupz and /health
+	Health *health.Registry
+	// Metrics serves /metrics and records RPCs
+	Metrics *metrics.Metrics
+}
+
+// NewHandler returns the handler for every endpoint: the task.v1 and
+// task.v2 RPCs, the REST gateway, the calendar feed, health probes, metrics
+// and reflection, wrapped in CORS, request logging and trace propagation.
+// Safe settings follow configuration reloads from cfgStore.
+func NewHandler(cfgStore *config.Store, log *logger.Logger, deps Deps) (http.Handler, error) {
+	cfg := cfgStore.Current()
+	taskService := deps.TaskService
+
+	// Create HTTP mux
+	mux := http.NewServeMux()
+
+	// Register liveness, readiness and startup probes
+	deps.Health.Register(mux)
+
+	// Keep /health for existing clients; it reports readiness
+	mux.Handle("/health", deps.Health.ReadyzHandler())
+	log.LogInfo(context.Background(), "health endpoints registered",
+		"paths", []string{"/livez", "/readyz", "/startupz", "/health"},
+	)
+
+	// Register metrics endpoint
+	mux.Handle("/metrics", deps.Metrics.Handler())
+	log.LogInfo(context.Background(), "metrics endpoint registered", "path", "/metrics")
+
+	// Create per-RPC tracing interceptor that joins the caller's trace
+	otelInterceptor, err := otelconnect.NewInterceptor(
+		otelconnect.WithTrustRemote(),
+		otelconnect.WithoutMetrics(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing interceptor: %w", err)
+	}
+
+	// Build the interceptor chain, outermost first. The status interceptor
+	// sees the final Connect code for request logs; metrics wrap rate
+	// limiting and deadlines so rejected and timed-out calls are counted.
+	interceptors := []connect.Interceptor{logger.StatusInterceptor()}
+	if cfg.IsProduction() {
+		// Keep database errors and other internal causes out of responses
+		interceptors = append(interceptors, errors.ScrubInternalErrors())
+	}
+	interceptors = append(interceptors,
+		otelInterceptor,
+		deps.Metrics.Interceptor(),
+	)
+	var limiter *ratelimit.Limiter
+	if cfg.Server.RateLimit.Enabled {
+		limiter = ratelimit.New(&cfg.Server.RateLimit, ratelimit.NewMemoryStore(),
+			ratelimit.WithErrorHandler(func(ctx context.Context, err error) {
+				log.LogError(ctx, "rate limiter store failed, allowing request", err)
+			}),
+		)
+		interceptors = append(interceptors, limiter.Interceptor())
+		cfgStore.Subscribe(func(cfg *config.Config) {
+			limiter.SetConfig(&cfg.Server.RateLimit)
+		})
+		log.LogInfo(context.Background(), "rate limiting enabled",
+			"requests_per_second", cfg.Server.RateLimit.Default.RequestsPerSecond,
+			"burst", cfg.Server.RateLimit.Default.Burst,
+			"procedure_overrides", len(cfg.Server.RateLimit.Procedures),
+		)
+	}
+	timeouts := middleware.NewRequestTimeouts(&cfg.Server.RequestTimeouts)
+	cfgStore.Subscribe(func(cfg *config.Config) {
+		timeouts.Set(&cfg.Server.RequestTimeouts)
+	})
+	interceptors = append(interceptors, timeouts.Interceptor(log, deps.Metrics))
+
+	// Register TaskService. Connect handlers accept the Connect, gRPC and
+	// gRPC-Web protocols without further options.
+	taskHandler := handler.NewTaskHandler(taskService,
+		handler.WithLegacyDeleteResponses(cfg.Server.LegacyDeleteResponses),
+	)
+	path, serviceHandler := taskconnect.NewTaskServiceHandler(taskHandler,
+		connect.WithInterceptors(interceptors...),
+	)
+	mux.Handle(path, serviceHandler)
+	log.LogInfo(context.Background(), "task service registered", "path", path, "protocols", Protocols)
+
+	// task.v2 is served alongside task.v1 from the same TaskService
+	path, serviceHandler = taskv2connect.NewTaskServiceHandler(handler.NewTaskV2Handler(taskService),
+		connect.WithInterceptors(interceptors...),
+	)
+	mux.Handle(path, serviceHandler)
+	log.LogInfo(context.Background(), "task service registered", "path", path, "protocols", Protocols)
+
+	// REST/JSON routes under /api and the OpenAPI document at /openapi.json
+	restOptions := []rest.Option{rest.WithTimeouts(timeouts)}
+	if limiter != nil {
+		restOptions = append(restOptions, rest.WithRateLimiter(limiter))
+	}
+	if cfg.IsProduction() {
+		restOptions = append(restOptions, rest.WithScrubbedErrors())
+	}
+	rest.New(taskService, restOptions...).Register(mux)
+	log.LogInfo(context.Background(), "rest gateway registered", "path", "/api/tasks")
+
+	// iCalendar feed and read-only CalDAV collection for calendar apps
+	if cfg.Calendar.FeedToken != "" {
+		calendar.New(taskService, cfg.Calendar.FeedToken.Reveal()).Register(mux)
+		log.LogInfo(context.Background(), "calendar feed registered",
+			"paths", []string{calendar.FeedPath, calendar.CollectionPath},
+		)
+	}
+
+	// Add reflection support for development and testing
+	reflector := grpcreflect.NewStaticReflector(
+		taskconnect.TaskServiceName,
+		taskv2connect.TaskServiceName,
+	)
+	mux.Handle(grpcreflect.NewHandlerV1(reflector))
+	mux.Handle(grpcreflect.NewHandlerV1Alpha(reflector))
+	log.LogInfo(context.Background(), "grpc reflection enabled")
+
+	// Add CORS support for web clients, following reloads of server.cors
+	corsPolicy := cors.New(&cfg.Server.CORS)
+	cfgStore.Subscribe(func(cfg *config.Config) {
+		corsPolicy.SetConfig(&cfg.Server.CORS)
+	})
+	corsHandler := corsPolicy.Handler(mux)
+
+	// Add request logging middleware
+	loggedHandler := logger.RequestLoggingMiddleware(log)(corsHandler)
+
+	// Extract incoming W3C trace context before anything logs
+	return tracing.Middleware(loggedHandler), nil
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/health"
+	"github.com/wcygan/todo/backend/internal/logger"
+	"github.com/wcygan/todo/backend/internal/metrics"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// newTestHandler builds the handler over a mock store with the
+// configuration in cfgStore
+func newTestHandler(t *testing.T, cfgStore *config.Store) http.Handler {
+	t.Helper()
+	cfg := cfgStore.Current()
+	cfg.Logger.Level = "error"
+	h, err := NewHandler(cfgStore, logger.New(cfg), Deps{
+		TaskService: service.NewTaskService(testutil.NewMockStore()),
+		Health:      health.NewRegistry("todo-backend", time.Second),
+		Metrics:     metrics.New(),
+	})
+	require.NoError(t, err)
+	return h
+}
+
+func TestNewHandler_Routes(t *testing.T) {
+	resolved, err := config.Resolve("")
+	require.NoError(t, err)
+	h := newTestHandler(t, config.NewStore(resolved))
+
+	for path, want := range map[string]int{
+		"/livez":              http.StatusOK,
+		"/metrics":            http.StatusOK,
+		"/openapi.json":       http.StatusOK,
+		"/api/tasks":          http.StatusOK,
+		"/calendar/tasks.ics": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, rec.Code, path)
+		assert.NotEmpty(t, rec.Header().Get("X-Request-ID"), path)
+	}
+}
+
+func TestNewHandler_FollowsCORSReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  cors:\n    allowed_origins: [https://old.example.com]\n"), 0o600))
+	resolved, err := config.Resolve(path)
+	require.NoError(t, err)
+	cfgStore := config.NewStore(resolved)
+	h := newTestHandler(t, cfgStore)
+
+	allowedOrigin := func(origin string) string {
+		req := httptest.NewRequest(http.MethodGet, "/livez", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+	assert.Equal(t, "https://old.example.com", allowedOrigin("https://old.example.com"))
+	assert.Empty(t, allowedOrigin("https://new.example.com"))
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  cors:\n    allowed_origins: [https://new.example.com]\n"), 0o600))
+	_, err = cfgStore.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, allowedOrigin("https://old.example.com"))
+	assert.Equal(t, "https://new.example.com", allowedOrigin("https://new.example.com"))
+}
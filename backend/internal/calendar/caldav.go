@@ -0,0 +1,224 @@
+package calendar
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+)
+
+const (
+	// davNS and caldavNS are the WebDAV and CalDAV XML namespaces
+	davNS    = "DAV:"
+	caldavNS = "urn:ietf:params:xml:ns:caldav"
+	// maxReportBytes bounds REPORT request bodies
+	maxReportBytes = 1 << 20
+)
+
+// reportRequest is a calendar-query or calendar-multiget REPORT body. Only
+// the requested properties, the component filter and the hrefs are used;
+// property filters and time ranges are ignored, so a query may return more
+// tasks than it asked for, which RFC 4791 clients tolerate.
+type reportRequest struct {
+	XMLName xml.Name
+	Prop    struct {
+		Names []element `xml:",any"`
+	} `xml:"DAV: prop"`
+	Filter struct {
+		CompFilter compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+	Hrefs []string `xml:"DAV: href"`
+}
+
+// element is an XML element known only by name
+type element struct {
+	XMLName xml.Name
+}
+
+// compFilter is a CalDAV comp-filter element
+type compFilter struct {
+	Name        string       `xml:"name,attr"`
+	CompFilters []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// matchesTodos reports whether a query filter can select VTODO components
+func (f compFilter) matchesTodos() bool {
+	if f.Name != "" && !strings.EqualFold(f.Name, "VCALENDAR") {
+		return false
+	}
+	if len(f.CompFilters) == 0 {
+		return true
+	}
+	for _, child := range f.CompFilters {
+		if strings.EqualFold(child.Name, "VTODO") {
+			return true
+		}
+	}
+	return false
+}
+
+// multistatus is a WebDAV 207 Multi-Status body. Element names carry fixed
+// prefixes bound on the root, as calendar clients expect.
+type multistatus struct {
+	XMLName   xml.Name   `xml:"D:multistatus"`
+	DAV       string     `xml:"xmlns:D,attr"`
+	CalDAV    string     `xml:"xmlns:C,attr"`
+	Responses []response `xml:"D:response"`
+}
+
+// response describes one resource in a multistatus
+type response struct {
+	Href     string     `xml:"D:href"`
+	Status   string     `xml:"D:status,omitempty"`
+	Propstat []propstat `xml:"D:propstat"`
+}
+
+// propstat groups properties that share a status
+type propstat struct {
+	Prop   prop   `xml:"D:prop"`
+	Status string `xml:"D:status"`
+}
+
+// prop holds the properties this collection supports, plus empty elements
+// for requested properties it does not
+type prop struct {
+	ETag         string    `xml:"D:getetag,omitempty"`
+	CalendarData string    `xml:"C:calendar-data,omitempty"`
+	Unsupported  []element `xml:",any"`
+}
+
+// serveReport handles the calendar-query and calendar-multiget REPORTs on
+// the task collection
+func (f *Feed) serveReport(w http.ResponseWriter, r *http.Request) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReportBytes))
+	if err != nil {
+		return errors.Validation("body", "failed to read request body")
+	}
+	var req reportRequest
+	if err := xml.Unmarshal(body, &req); err != nil {
+		return errors.Validation("body", "invalid REPORT body")
+	}
+
+	var tasks []*taskv1.Task
+	var missing []string
+	switch {
+	case req.XMLName.Space == caldavNS && req.XMLName.Local == "calendar-query":
+		if req.Filter.CompFilter.matchesTodos() {
+			if tasks, err = f.service.ListTasks(r.Context()); err != nil {
+				return err
+			}
+		}
+	case req.XMLName.Space == caldavNS && req.XMLName.Local == "calendar-multiget":
+		for _, href := range req.Hrefs {
+			task, err := f.taskForHref(r, href)
+			if errors.IsNotFound(err) {
+				missing = append(missing, href)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+	default:
+		http.Error(w, "unsupported REPORT", http.StatusForbidden)
+		return nil
+	}
+
+	result := multistatus{DAV: davNS, CalDAV: caldavNS}
+	for _, task := range tasks {
+		result.Responses = append(result.Responses, taskResponse(task, req))
+	}
+	for _, href := range missing {
+		result.Responses = append(result.Responses, response{Href: href, Status: statusLine(http.StatusNotFound)})
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, xml.Header)
+	return xml.NewEncoder(w).Encode(result)
+}
+
+// taskForHref loads the task a multiget href refers to. Hrefs may be paths
+// or absolute URLs.
+func (f *Feed) taskForHref(r *http.Request, href string) (*taskv1.Task, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, errors.NotFound("calendar resource", href)
+	}
+	name, ok := strings.CutPrefix(u.Path, CollectionPath)
+	if !ok {
+		return nil, errors.NotFound("calendar resource", href)
+	}
+	id, ok := parseResourceName(name)
+	if !ok {
+		return nil, errors.NotFound("calendar resource", href)
+	}
+	return f.service.GetTask(r.Context(), id)
+}
+
+// taskResponse renders the requested properties of a task. With no prop
+// element, both getetag and calendar-data are returned.
+func taskResponse(task *taskv1.Task, req reportRequest) response {
+	data := encodeCalendar([]*taskv1.Task{task})
+
+	var found prop
+	var unsupported []element
+	names := req.Prop.Names
+	if len(names) == 0 {
+		found = prop{ETag: entityTag(data), CalendarData: string(data)}
+	}
+	for _, name := range names {
+		switch name.XMLName {
+		case xml.Name{Space: davNS, Local: "getetag"}:
+			found.ETag = entityTag(data)
+		case xml.Name{Space: caldavNS, Local: "calendar-data"}:
+			found.CalendarData = string(data)
+		default:
+			unsupported = append(unsupported, name)
+		}
+	}
+
+	resp := response{Href: resourcePath(task.Id)}
+	if found.ETag != "" || found.CalendarData != "" {
+		resp.Propstat = append(resp.Propstat, propstat{Prop: found, Status: statusLine(http.StatusOK)})
+	}
+	if len(unsupported) > 0 {
+		resp.Propstat = append(resp.Propstat, propstat{
+			Prop:   prop{Unsupported: unsupported},
+			Status: statusLine(http.StatusNotFound),
+		})
+	}
+	return resp
+}
+
+// resourcePath returns the CalDAV path of a task
+func resourcePath(id string) string {
+	return CollectionPath + id + ".ics"
+}
+
+// parseResourceName returns the task ID from a resource name such as
+// "42.ics". Task IDs are numeric, so anything else names no task.
+func parseResourceName(name string) (string, bool) {
+	id, ok := strings.CutSuffix(name, ".ics")
+	if !ok || id == "" {
+		return "", false
+	}
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return "", false
+		}
+	}
+	return id, true
+}
+
+// statusLine formats a DAV:status value
+func statusLine(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
@@ -0,0 +1,164 @@
+// Package calendar publishes tasks as an iCalendar feed of VTODO components
+// (RFC 5545) and a read-only CalDAV collection (RFC 4791), so that calendar
+// and todo apps can subscribe to them
+package calendar
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/service"
+)
+
+const (
+	// FeedPath is the iCalendar feed of every task
+	FeedPath = "/calendar/tasks.ics"
+	// CollectionPath is the CalDAV collection holding one resource per task
+	CollectionPath = "/calendar/tasks/"
+	// TokenParam carries the feed token for clients that can only subscribe
+	// to a URL
+	TokenParam = "token"
+	// contentType is the media type of iCalendar data
+	contentType = "text/calendar; charset=utf-8"
+	// realm is reported to clients that must authenticate
+	realm = "todo calendar"
+)
+
+// Feed serves tasks to calendar clients. Every request must present the
+// feed token as a bearer token, as the password of HTTP Basic
+// authentication, or in the token query parameter.
+type Feed struct {
+	service *service.TaskService
+	token   string
+}
+
+// New creates a Feed for taskService that accepts token
+func New(taskService *service.TaskService, token string) *Feed {
+	return &Feed{
+		service: taskService,
+		token:   token,
+	}
+}
+
+// Register adds the feed, the task resources and the CalDAV REPORT method to
+// mux. GET routes also answer HEAD.
+func (f *Feed) Register(mux *http.ServeMux) {
+	mux.Handle("GET "+FeedPath, f.wrap(f.serveFeed))
+	mux.Handle("GET "+CollectionPath+"{resource}", f.wrap(f.serveTask))
+	mux.Handle("REPORT "+CollectionPath+"{$}", f.wrap(f.serveReport))
+	mux.Handle("REPORT "+strings.TrimSuffix(CollectionPath, "/"), f.wrap(f.serveReport))
+}
+
+// wrap authenticates requests and renders errors as plain text
+func (f *Feed) wrap(handle func(w http.ResponseWriter, r *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !f.authenticated(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		if err := handle(w, r); err != nil {
+			writeError(w, err)
+		}
+	})
+}
+
+// authenticated reports whether r carries the feed token
+func (f *Feed) authenticated(r *http.Request) bool {
+	var presented string
+	if _, password, ok := r.BasicAuth(); ok {
+		presented = password
+	} else if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		presented = token
+	} else {
+		presented = r.URL.Query().Get(TokenParam)
+	}
+	return presented != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(f.token)) == 1
+}
+
+// writeError maps err to an HTTP status. Messages are kept generic, since
+// calendar clients show them to users at best.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.IsNotFound(err):
+		status = http.StatusNotFound
+	case errors.IsValidation(err):
+		status = http.StatusBadRequest
+	case errors.IsTimeout(err):
+		status = http.StatusGatewayTimeout
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// serveFeed handles GET /calendar/tasks.ics
+func (f *Feed) serveFeed(w http.ResponseWriter, r *http.Request) error {
+	tasks, err := f.service.ListTasks(r.Context())
+	if err != nil {
+		return err
+	}
+	writeCalendar(w, r, encodeCalendar(tasks))
+	return nil
+}
+
+// serveTask handles GET /calendar/tasks/{id}.ics
+func (f *Feed) serveTask(w http.ResponseWriter, r *http.Request) error {
+	id, ok := parseResourceName(r.PathValue("resource"))
+	if !ok {
+		return errors.NotFound("calendar resource", r.PathValue("resource"))
+	}
+
+	task, err := f.service.GetTask(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	writeCalendar(w, r, encodeCalendar([]*taskv1.Task{task}))
+	return nil
+}
+
+// writeCalendar writes data with a strong ETag, or 304 Not Modified when
+// the client already has it
+func writeCalendar(w http.ResponseWriter, r *http.Request, data []byte) {
+	etag := entityTag(data)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(data)
+	}
+}
+
+// entityTag returns a strong ETag for data
+func entityTag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// the weak comparison RFC 9110 requires for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package calendar
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+const testToken = "0123456789abcdef"
+
+// newTestServer serves a Feed over a mock store holding two tasks
+func newTestServer(t *testing.T) *httptest.Server {
+	repo := testutil.NewMockStore()
+	repo.AddTask(testutil.CreateTestTaskWithID("1", "Buy milk"))
+	repo.AddTask(testutil.CreateCompletedTestTask("2", "Walk dog"))
+
+	mux := http.NewServeMux()
+	New(service.NewTaskService(repo), testToken).Register(mux)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// do sends an authenticated request with optional body and headers
+func do(t *testing.T, server *httptest.Server, method, path, body string, headers ...string) *http.Response {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// readBody returns the response body as a string
+func readBody(t *testing.T, resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestFeed_Authentication(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		setup  func(req *http.Request)
+		status int
+	}{
+		{"none", func(req *http.Request) {}, http.StatusUnauthorized},
+		{"wrong_bearer", func(req *http.Request) { req.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized},
+		{"bearer", func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+testToken) }, http.StatusOK},
+		{"basic", func(req *http.Request) { req.SetBasicAuth("anyone", testToken) }, http.StatusOK},
+		{"wrong_basic", func(req *http.Request) { req.SetBasicAuth(testToken, "nope") }, http.StatusUnauthorized},
+		{"query", func(req *http.Request) { req.URL.RawQuery = TokenParam + "=" + testToken }, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+FeedPath, nil)
+			require.NoError(t, err)
+			tt.setup(req)
+
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status == http.StatusUnauthorized {
+				assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Basic")
+			}
+		})
+	}
+}
+
+func TestFeed_ETag(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := do(t, server, http.MethodGet, FeedPath, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+	body := readBody(t, resp)
+	assert.Equal(t, 2, strings.Count(body, "BEGIN:VTODO"))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	resp = do(t, server, http.MethodGet, FeedPath, "", "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Empty(t, readBody(t, resp))
+
+	resp = do(t, server, http.MethodGet, FeedPath, "", "If-None-Match", `"stale", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	resp = do(t, server, http.MethodGet, FeedPath, "", "If-None-Match", `"stale"`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = do(t, server, http.MethodHead, FeedPath, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, etag, resp.Header.Get("ETag"))
+}
+
+func TestFeed_Task(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := do(t, server, http.MethodGet, CollectionPath+"2.ics", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body := readBody(t, resp)
+	assert.Contains(t, body, "UID:task-2@todo.wcygan.github.com\r\n")
+	assert.Contains(t, body, "STATUS:COMPLETED\r\n")
+
+	resp = do(t, server, http.MethodGet, CollectionPath+"99.ics", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	for _, name := range []string{"2", "abc.ics", ".ics"} {
+		resp = do(t, server, http.MethodGet, CollectionPath+name, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, name)
+	}
+}
+
+func TestFeed_CalendarQuery(t *testing.T) {
+	server := newTestServer(t)
+
+	query := `<?xml version="1.0" encoding="utf-8" ?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/><D:displayname/></D:prop>
+  <C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"/></C:comp-filter></C:filter>
+</C:calendar-query>`
+	resp := do(t, server, "REPORT", CollectionPath, query, "Depth", "1")
+	require.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	body := readBody(t, resp)
+	assert.Equal(t, 2, strings.Count(body, "<D:response>"))
+	assert.Contains(t, body, "<D:href>/calendar/tasks/1.ics</D:href>")
+	assert.Contains(t, body, "<D:getetag>")
+	assert.Contains(t, body, "BEGIN:VTODO")
+	assert.Contains(t, body, "HTTP/1.1 404 Not Found", "unsupported properties are reported missing")
+
+	events := strings.Replace(query, `name="VTODO"`, `name="VEVENT"`, 1)
+	resp = do(t, server, "REPORT", strings.TrimSuffix(CollectionPath, "/"), events)
+	require.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.NotContains(t, readBody(t, resp), "<D:response>")
+}
+
+func TestFeed_CalendarMultiget(t *testing.T) {
+	server := newTestServer(t)
+
+	multiget := `<?xml version="1.0" encoding="utf-8" ?>
+<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/></D:prop>
+  <D:href>/calendar/tasks/1.ics</D:href>
+  <D:href>http://example.com/calendar/tasks/99.ics</D:href>
+  <D:href>/calendar/tasks/abc.ics</D:href>
+</C:calendar-multiget>`
+	resp := do(t, server, "REPORT", CollectionPath, multiget)
+	require.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	body := readBody(t, resp)
+	assert.Equal(t, 3, strings.Count(body, "<D:response>"))
+	assert.Equal(t, 2, strings.Count(body, "HTTP/1.1 404 Not Found"))
+	assert.NotContains(t, body, "BEGIN:VTODO", "only the requested properties are returned")
+
+	resp = do(t, server, "REPORT", CollectionPath, `<D:sync-collection xmlns:D="DAV:"/>`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = do(t, server, "REPORT", CollectionPath, `<not xml`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,98 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+)
+
+const (
+	// prodID identifies this server as the producer of the calendar
+	prodID = "-//wcygan//todo//EN"
+	// uidDomain makes task UIDs globally unique, as RFC 5545 recommends
+	uidDomain = "todo.wcygan.github.com"
+	// maxLineOctets is the longest content line allowed before folding
+	maxLineOctets = 75
+	// timestampFormat is the RFC 5545 DATE-TIME form in UTC
+	timestampFormat = "20060102T150405Z"
+)
+
+// textEscaper escapes TEXT property values (RFC 5545 section 3.3.11)
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// encodeCalendar renders tasks as a VCALENDAR of VTODO components
+func encodeCalendar(tasks []*taskv1.Task) []byte {
+	var b bytes.Buffer
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+prodID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "X-WR-CALNAME:Tasks")
+	for _, task := range tasks {
+		encodeTodo(&b, task)
+	}
+	writeLine(&b, "END:VCALENDAR")
+	return b.Bytes()
+}
+
+// encodeTodo writes task as a VTODO component. DTSTAMP is the creation
+// time and COMPLETED the last update of a completed task, since the store
+// does not record when a task was completed.
+func encodeTodo(b *bytes.Buffer, task *taskv1.Task) {
+	createdAt := formatTime(task.CreatedAt.AsTime())
+	updatedAt := formatTime(task.UpdatedAt.AsTime())
+
+	writeLine(b, "BEGIN:VTODO")
+	writeLine(b, "UID:"+taskUID(task.Id))
+	writeLine(b, "DTSTAMP:"+createdAt)
+	writeLine(b, "CREATED:"+createdAt)
+	writeLine(b, "LAST-MODIFIED:"+updatedAt)
+	writeLine(b, "SUMMARY:"+textEscaper.Replace(task.Description))
+	if task.Completed {
+		writeLine(b, "STATUS:COMPLETED")
+		writeLine(b, "COMPLETED:"+updatedAt)
+		writeLine(b, "PERCENT-COMPLETE:100")
+	} else {
+		writeLine(b, "STATUS:NEEDS-ACTION")
+	}
+	writeLine(b, "END:VTODO")
+}
+
+// taskUID returns the UID of the VTODO for a task
+func taskUID(id string) string {
+	return "task-" + id + "@" + uidDomain
+}
+
+// formatTime formats t as a UTC DATE-TIME
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timestampFormat)
+}
+
+// writeLine writes a content line terminated by CRLF, folding it so that no
+// line exceeds 75 octets. Folds never split a UTF-8 sequence.
+func writeLine(b *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts toward the limit
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
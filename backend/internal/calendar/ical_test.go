@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestEncodeCalendar(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	tasks := []*taskv1.Task{
+		{
+			Id:          "1",
+			Description: "Buy milk, eggs; bread\nand butter",
+			CreatedAt:   timestamppb.New(created),
+			UpdatedAt:   timestamppb.New(updated),
+		},
+		{
+			Id:          "2",
+			Description: "Walk dog",
+			Completed:   true,
+			CreatedAt:   timestamppb.New(created),
+			UpdatedAt:   timestamppb.New(updated),
+		},
+	}
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//wcygan//todo//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Tasks",
+		"BEGIN:VTODO",
+		"UID:task-1@todo.wcygan.github.com",
+		"DTSTAMP:20240102T030405Z",
+		"CREATED:20240102T030405Z",
+		"LAST-MODIFIED:20240102T040405Z",
+		`SUMMARY:Buy milk\, eggs\; bread\nand butter`,
+		"STATUS:NEEDS-ACTION",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:task-2@todo.wcygan.github.com",
+		"DTSTAMP:20240102T030405Z",
+		"CREATED:20240102T030405Z",
+		"LAST-MODIFIED:20240102T040405Z",
+		"SUMMARY:Walk dog",
+		"STATUS:COMPLETED",
+		"COMPLETED:20240102T040405Z",
+		"PERCENT-COMPLETE:100",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	assert.Equal(t, expected, string(encodeCalendar(tasks)))
+}
+
+func TestWriteLine_Folding(t *testing.T) {
+	var b bytes.Buffer
+	line := "SUMMARY:" + strings.Repeat("é", 100)
+	writeLine(&b, line)
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	unfolded := lines[0]
+	for _, l := range lines {
+		assert.LessOrEqual(t, len(l), maxLineOctets)
+	}
+	for _, l := range lines[1:] {
+		assert.True(t, strings.HasPrefix(l, " "), "continuation lines start with a space")
+		unfolded += l[1:]
+	}
+	assert.Equal(t, line, unfolded, "folds never split a UTF-8 sequence")
+}
@@ -0,0 +1,149 @@
+// Package certstest issues throwaway certificates for tests that exercise
+// TLS: a CA, server certificates and client certificates written as PEM
+// files into a test's temporary directory.
+package certstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// serial numbers certificates uniquely within a test binary
+var serial atomic.Int64
+
+// CA is a certificate authority for tests
+type CA struct {
+	Cert *x509.Certificate
+	// CertFile is the PEM file holding the CA certificate
+	CertFile string
+
+	key *ecdsa.PrivateKey
+}
+
+// Issued is a certificate signed by a CA, with its key
+type Issued struct {
+	Cert     *x509.Certificate
+	CertFile string
+	KeyFile  string
+}
+
+// Options describes the subject of an issued certificate
+type Options struct {
+	CommonName string
+	DNSNames   []string
+	IPs        []net.IP
+	URIs       []string
+	// Client issues a client certificate instead of a server certificate
+	Client bool
+}
+
+// NewCA creates a CA named name and writes its certificate to
+// <name>-ca.pem in a temporary directory
+func NewCA(t *testing.T, name string) *CA {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial.Add(1)),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &CA{
+		Cert:     cert,
+		CertFile: writePEM(t, name+"-ca.pem", "CERTIFICATE", der),
+		key:      key,
+	}
+}
+
+// Issue signs a certificate for opts and writes it and its key to
+// <CommonName>.pem and <CommonName>-key.pem in a temporary directory
+func (ca *CA) Issue(t *testing.T, opts Options) *Issued {
+	t.Helper()
+	key := newKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial.Add(1)),
+		Subject:      pkix.Name{CommonName: opts.CommonName},
+		DNSNames:     opts.DNSNames,
+		IPAddresses:  opts.IPs,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if opts.Client {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	for _, raw := range opts.URIs {
+		uri, err := url.Parse(raw)
+		require.NoError(t, err)
+		template.URIs = append(template.URIs, uri)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return &Issued{
+		Cert:     cert,
+		CertFile: writePEM(t, opts.CommonName+".pem", "CERTIFICATE", der),
+		KeyFile:  writePEM(t, opts.CommonName+"-key.pem", "PRIVATE KEY", keyDER),
+	}
+}
+
+// Pool returns a certificate pool trusting the CA
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return pool
+}
+
+// TLSCertificate loads the issued certificate for use in a tls.Config
+func (i *Issued) TLSCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	cert, err := tls.LoadX509KeyPair(i.CertFile, i.KeyFile)
+	require.NoError(t, err)
+	return cert
+}
+
+// newKey generates a P-256 private key
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+// writePEM writes one PEM block to name in a temporary directory
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
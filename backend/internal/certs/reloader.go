@@ -0,0 +1,184 @@
+// Package certs serves the server's TLS certificate and client CA pool,
+// reloading them from disk when the files change
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"hash"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+// bundle is one loaded generation of the certificate files
+type bundle struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	digest    []byte
+}
+
+// Reloader holds the server certificate and the CAs trusted for client
+// certificates. Handshakes always use the most recently loaded files, so
+// certificates can be rotated without restarting the server.
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+
+	current atomic.Pointer[bundle]
+}
+
+// NewReloader loads the certificate files named in cfg
+func NewReloader(cfg *config.TLSConfig) (*Reloader, error) {
+	r := &Reloader{
+		certFile:     cfg.CertFile,
+		keyFile:      cfg.KeyFile,
+		clientCAFile: cfg.ClientCAFile,
+	}
+	switch cfg.ClientAuthMode() {
+	case config.ClientAuthOptional:
+		r.clientAuth = tls.VerifyClientCertIfGiven
+	case config.ClientAuthRequire:
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		r.clientAuth = tls.NoClientCert
+	}
+
+	b, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.current.Store(b)
+	return r, nil
+}
+
+// TLSConfig returns a server TLS configuration backed by the reloader
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.configFor(r.current.Load()), nil
+		},
+	}
+}
+
+// configFor returns the configuration for handshakes using b
+func (r *Reloader) configFor(b *bundle) *tls.Config {
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{*b.cert},
+		ClientAuth:   r.clientAuth,
+		ClientCAs:    b.clientCAs,
+	}
+}
+
+// Reload re-reads the certificate files, reporting whether they changed.
+// On error the previously loaded certificates stay in use.
+func (r *Reloader) Reload() (bool, error) {
+	b, err := r.load()
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(b.digest, r.current.Load().digest) {
+		return false, nil
+	}
+	r.current.Store(b)
+	return true, nil
+}
+
+// Watch polls the certificate files every interval and reloads them when
+// their contents change, reporting each attempt to onReload, until ctx is
+// done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, onReload func(error)) {
+	last := r.current.Load().digest
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			digest := filesDigest(r.files())
+			if bytes.Equal(digest, last) {
+				continue
+			}
+			last = digest
+			_, err := r.Reload()
+			onReload(err)
+		}
+	}
+}
+
+// Certificate returns the leaf of the server certificate in use
+func (r *Reloader) Certificate() *x509.Certificate {
+	return r.current.Load().cert.Leaf
+}
+
+// files lists the files the reloader reads
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+// load reads and parses the certificate files
+func (r *Reloader) load() (*bundle, error) {
+	certPEM, err := os.ReadFile(r.certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS key: %w", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate %s: %w", r.certFile, err)
+	}
+
+	b := &bundle{cert: &cert}
+	h := sha256.New()
+	writeFile(h, r.certFile, certPEM)
+	writeFile(h, r.keyFile, keyPEM)
+	if r.clientCAFile != "" {
+		caPEM, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS client CA file: %w", err)
+		}
+		b.clientCAs = x509.NewCertPool()
+		if !b.clientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("TLS client CA file %s contains no certificates", r.clientCAFile)
+		}
+		writeFile(h, r.clientCAFile, caPEM)
+	}
+	b.digest = h.Sum(nil)
+	return b, nil
+}
+
+// filesDigest fingerprints the contents of files the way load does
+func filesDigest(files []string) []byte {
+	h := sha256.New()
+	for _, path := range files {
+		data, _ := os.ReadFile(path)
+		writeFile(h, path, data)
+	}
+	return h.Sum(nil)
+}
+
+// writeFile adds one file to a digest
+func writeFile(h hash.Hash, path string, data []byte) {
+	fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+	h.Write(data)
+}
@@ -0,0 +1,232 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/certs/certstest"
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/middleware"
+)
+
+// certFiles are the fixed paths a test server reads its certificates from
+type certFiles struct {
+	cfg config.TLSConfig
+}
+
+// newCertFiles copies server and, when given, the client CA into a
+// directory the test can overwrite to rotate them
+func newCertFiles(t *testing.T, server *certstest.Issued, clientCA *certstest.CA, clientAuth string) *certFiles {
+	t.Helper()
+	dir := t.TempDir()
+	f := &certFiles{cfg: config.TLSConfig{
+		CertFile:   filepath.Join(dir, "tls.crt"),
+		KeyFile:    filepath.Join(dir, "tls.key"),
+		ClientAuth: clientAuth,
+	}}
+	f.install(t, server)
+	if clientCA != nil {
+		f.cfg.ClientCAFile = filepath.Join(dir, "ca.crt")
+		copyFile(t, clientCA.CertFile, f.cfg.ClientCAFile)
+	}
+	return f
+}
+
+// install replaces the server certificate and key
+func (f *certFiles) install(t *testing.T, server *certstest.Issued) {
+	t.Helper()
+	copyFile(t, server.CertFile, f.cfg.CertFile)
+	copyFile(t, server.KeyFile, f.cfg.KeyFile)
+}
+
+func copyFile(t *testing.T, from, to string) {
+	t.Helper()
+	data, err := os.ReadFile(from)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(to, data, 0o600))
+}
+
+// startServer serves the client identity, or "anonymous", over TLS from r
+func startServer(t *testing.T, r *Reloader) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(middleware.ClientIdentityMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := middleware.ClientIdentityFromContext(r.Context()); ok {
+			io.WriteString(w, id.Name())
+			return
+		}
+		io.WriteString(w, "anonymous")
+	})))
+	srv.TLS = r.TLSConfig()
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newClient returns a client that trusts roots and presents certs
+func newClient(roots *x509.CertPool, certs ...tls.Certificate) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+		DisableKeepAlives: true,
+	}}
+}
+
+// get returns the response body for a GET of url
+func get(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+// peerSerial returns the serial number of the certificate the server at
+// addr presents
+func peerSerial(t *testing.T, addr string, roots *x509.CertPool) string {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots})
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.String()
+}
+
+var localhost = certstest.Options{CommonName: "localhost", DNSNames: []string{"localhost"}, IPs: []net.IP{net.IPv4(127, 0, 0, 1)}}
+
+func TestReloader_ServesCertificate(t *testing.T) {
+	ca := certstest.NewCA(t, "server")
+	files := newCertFiles(t, ca.Issue(t, localhost), nil, "")
+
+	r, err := NewReloader(&files.cfg)
+	require.NoError(t, err)
+	srv := startServer(t, r)
+
+	body, err := get(newClient(ca.Pool()), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "anonymous", body)
+}
+
+func TestReloader_RequiresClientCertificate(t *testing.T) {
+	serverCA := certstest.NewCA(t, "server")
+	clientCA := certstest.NewCA(t, "clients")
+	files := newCertFiles(t, serverCA.Issue(t, localhost), clientCA, "")
+
+	r, err := NewReloader(&files.cfg)
+	require.NoError(t, err)
+	srv := startServer(t, r)
+
+	client := clientCA.Issue(t, certstest.Options{
+		CommonName: "worker",
+		URIs:       []string{"spiffe://cluster.local/ns/todo-app/sa/worker"},
+		Client:     true,
+	})
+	body, err := get(newClient(serverCA.Pool(), client.TLSCertificate(t)), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "spiffe://cluster.local/ns/todo-app/sa/worker", body)
+
+	_, err = get(newClient(serverCA.Pool()), srv.URL)
+	assert.Error(t, err, "a client without a certificate must be rejected")
+
+	untrusted := certstest.NewCA(t, "other").Issue(t, certstest.Options{CommonName: "intruder", Client: true})
+	_, err = get(newClient(serverCA.Pool(), untrusted.TLSCertificate(t)), srv.URL)
+	assert.Error(t, err, "a certificate from an unknown CA must be rejected")
+}
+
+func TestReloader_OptionalClientCertificate(t *testing.T) {
+	serverCA := certstest.NewCA(t, "server")
+	clientCA := certstest.NewCA(t, "clients")
+	files := newCertFiles(t, serverCA.Issue(t, localhost), clientCA, config.ClientAuthOptional)
+
+	r, err := NewReloader(&files.cfg)
+	require.NoError(t, err)
+	srv := startServer(t, r)
+
+	body, err := get(newClient(serverCA.Pool()), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "anonymous", body)
+
+	client := clientCA.Issue(t, certstest.Options{CommonName: "cli", Client: true})
+	body, err = get(newClient(serverCA.Pool(), client.TLSCertificate(t)), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "cli", body)
+}
+
+func TestReloader_Reload(t *testing.T) {
+	ca := certstest.NewCA(t, "server")
+	first := ca.Issue(t, localhost)
+	files := newCertFiles(t, first, nil, "")
+
+	r, err := NewReloader(&files.cfg)
+	require.NoError(t, err)
+	srv := startServer(t, r)
+	addr := srv.Listener.Addr().String()
+	assert.Equal(t, first.Cert.SerialNumber.String(), peerSerial(t, addr, ca.Pool()))
+
+	changed, err := r.Reload()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	second := ca.Issue(t, localhost)
+	files.install(t, second)
+	changed, err = r.Reload()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, second.Cert.SerialNumber.String(), peerSerial(t, addr, ca.Pool()))
+	assert.Equal(t, second.Cert.SerialNumber, r.Certificate().SerialNumber)
+
+	// A broken key keeps the previous certificate in use
+	require.NoError(t, os.WriteFile(files.cfg.KeyFile, []byte("not a key"), 0o600))
+	_, err = r.Reload()
+	require.Error(t, err)
+	assert.Equal(t, second.Cert.SerialNumber.String(), peerSerial(t, addr, ca.Pool()))
+}
+
+func TestReloader_Watch(t *testing.T) {
+	ca := certstest.NewCA(t, "server")
+	files := newCertFiles(t, ca.Issue(t, localhost), nil, "")
+	r, err := NewReloader(&files.cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan error, 4)
+	go r.Watch(ctx, 10*time.Millisecond, func(err error) { results <- err })
+
+	next := ca.Issue(t, localhost)
+	files.install(t, next)
+	deadline := time.After(5 * time.Second)
+	for r.Certificate().SerialNumber.Cmp(next.Cert.SerialNumber) != 0 {
+		select {
+		case <-results:
+		case <-deadline:
+			t.Fatal("certificate change was not detected")
+		}
+	}
+}
+
+func TestNewReloader_Errors(t *testing.T) {
+	ca := certstest.NewCA(t, "server")
+	server := ca.Issue(t, localhost)
+	other := ca.Issue(t, certstest.Options{CommonName: "other"})
+
+	_, err := NewReloader(&config.TLSConfig{CertFile: filepath.Join(t.TempDir(), "missing.pem"), KeyFile: server.KeyFile})
+	assert.ErrorContains(t, err, "failed to read TLS certificate")
+
+	_, err = NewReloader(&config.TLSConfig{CertFile: server.CertFile, KeyFile: other.KeyFile})
+	assert.ErrorContains(t, err, "invalid TLS certificate")
+
+	_, err = NewReloader(&config.TLSConfig{CertFile: server.CertFile, KeyFile: server.KeyFile, ClientCAFile: server.KeyFile})
+	assert.ErrorContains(t, err, "contains no certificates")
+}
@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,23 +15,154 @@ type Config struct {
 	Server   ServerConfig   `json:"server"`
 	Logger   LoggerConfig   `json:"logger"`
 	Database DatabaseConfig `json:"database"`
+	Cache    CacheConfig    `json:"cache"`
+	Tracing  TracingConfig  `json:"tracing"`
+	Health   HealthConfig   `json:"health"`
+	Calendar CalendarConfig `json:"calendar"`
+
+	Idempotency IdempotencyConfig `json:"idempotency"`
 }
 
 // ServerConfig holds server-specific configuration
 type ServerConfig struct {
-	Port            int           `json:"port"`
-	ReadTimeout     time.Duration `json:"read_timeout"`
-	WriteTimeout    time.Duration `json:"write_timeout"`
-	IdleTimeout     time.Duration `json:"idle_timeout"`
-	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
-	CORS            CORSConfig    `json:"cors"`
+	Port            int             `json:"port"`
+	ReadTimeout     time.Duration   `json:"read_timeout"`
+	WriteTimeout    time.Duration   `json:"write_timeout"`
+	IdleTimeout     time.Duration   `json:"idle_timeout"`
+	ShutdownTimeout time.Duration   `json:"shutdown_timeout"`
+	CORS            CORSConfig      `json:"cors"`
+	RateLimit       RateLimitConfig `json:"rate_limit"`
+	RequestTimeouts TimeoutConfig   `json:"request_timeouts"`
+	TLS             TLSConfig       `json:"tls"`
+	// LegacyDeleteResponses makes DeleteTask report failures as
+	// success=false responses, as it did before it returned Connect errors.
+	// It exists for clients that have not migrated yet.
+	LegacyDeleteResponses bool `json:"legacy_delete_responses"`
 }
 
 // CORSConfig holds CORS configuration
 type CORSConfig struct {
+	// AllowedOrigins lists origins such as https://app.example.com; a
+	// pattern like https://*.example.com allows any subdomain and "*"
+	// allows every origin
 	AllowedOrigins []string `json:"allowed_origins"`
 	AllowedMethods []string `json:"allowed_methods"`
+	// AllowedHeaders lists the request headers browsers may send; "*"
+	// allows any
 	AllowedHeaders []string `json:"allowed_headers"`
+	// ExposedHeaders lists the response headers browser scripts may read
+	ExposedHeaders []string `json:"exposed_headers"`
+	// AllowCredentials lets browsers send cookies and TLS client
+	// certificates; it cannot be combined with the "*" origin
+	AllowCredentials bool `json:"allow_credentials"`
+	// MaxAge is how long browsers may cache a preflight response; zero
+	// leaves it to the browser
+	MaxAge time.Duration `json:"max_age"`
+}
+
+// TimeoutConfig caps how long an RPC may run. Clients may ask for less via
+// Connect-Timeout-Ms or grpc-timeout but never for more. Procedures overrides
+// Default for individual procedures, keyed like RateLimitConfig.Procedures.
+// A zero Default leaves RPCs without a client deadline unbounded.
+type TimeoutConfig struct {
+	Default    time.Duration            `json:"default"`
+	Procedures map[string]time.Duration `json:"procedures"`
+}
+
+// ForProcedure returns the maximum duration for a Connect procedure
+func (t *TimeoutConfig) ForProcedure(procedure string) time.Duration {
+	if timeout, ok := lookupProcedure(t.Procedures, procedure); ok {
+		return timeout
+	}
+	return t.Default
+}
+
+// RateLimitConfig holds per-client request quotas. Every client gets one
+// token bucket per procedure; Procedures overrides Default for individual
+// procedures, keyed by method name ("CreateTask") or full procedure path.
+type RateLimitConfig struct {
+	Enabled    bool                 `json:"enabled"`
+	Default    RateLimit            `json:"default"`
+	Procedures map[string]RateLimit `json:"procedures"`
+	// TrustedProxies lists the addresses or CIDR ranges of the proxies in
+	// front of the server, such as the cluster ingress. Requests from them
+	// are charged to the client address they put in X-Forwarded-For.
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// RateLimit is a token bucket refilled at RequestsPerSecond up to Burst
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+}
+
+// ForProcedure returns the limit that applies to a Connect procedure such as
+// "/task.v1.TaskService/CreateTask"
+func (r *RateLimitConfig) ForProcedure(procedure string) RateLimit {
+	if limit, ok := lookupProcedure(r.Procedures, procedure); ok {
+		return limit
+	}
+	return r.Default
+}
+
+// lookupProcedure finds a per-procedure setting by full procedure path, then
+// by method name
+func lookupProcedure[T any](settings map[string]T, procedure string) (T, bool) {
+	if value, ok := settings[procedure]; ok {
+		return value, true
+	}
+	if i := strings.LastIndex(procedure, "/"); i >= 0 {
+		if value, ok := settings[procedure[i+1:]]; ok {
+			return value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// CacheConfig holds configuration for the task read cache
+type CacheConfig struct {
+	Enabled  bool          `json:"enabled"`
+	Capacity int           `json:"capacity"`
+	TTL      time.Duration `json:"ttl"`
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Exporter     string  `json:"exporter"` // "none", "stdout" or "otlp"
+	OTLPEndpoint string  `json:"otlp_endpoint"`
+	ServiceName  string  `json:"service_name"`
+	SampleRatio  float64 `json:"sample_ratio"`
+}
+
+// HealthConfig holds configuration for the liveness, readiness and startup probes
+type HealthConfig struct {
+	CheckTimeout time.Duration `json:"check_timeout"`
+	// PoolSaturationThreshold is the fraction of MaxOpenConns in use above
+	// which the instance reports itself as not ready
+	PoolSaturationThreshold float64 `json:"pool_saturation_threshold"`
+	// ShutdownDelay is how long /readyz reports unavailable before the
+	// listener closes, giving load balancers time to stop routing traffic
+	ShutdownDelay time.Duration `json:"shutdown_delay"`
+}
+
+// IdempotencyConfig controls how long Idempotency-Key responses are kept
+type IdempotencyConfig struct {
+	TTL           time.Duration `json:"ttl"`
+	PurgeInterval time.Duration `json:"purge_interval"`
+	// Lease is how long a request holds its key before a retry may take
+	// it over; it should outlast the longest request timeout
+	Lease time.Duration `json:"lease"`
+}
+
+// minFeedTokenLength keeps calendar feed tokens from being guessable
+const minFeedTokenLength = 16
+
+// CalendarConfig holds configuration for the iCalendar feed
+type CalendarConfig struct {
+	// FeedToken authenticates calendar clients; the feed is disabled when it
+	// is empty
+	FeedToken Secret `json:"feed_token"`
 }
 
 // LoggerConfig holds logging configuration
@@ -42,54 +176,79 @@ type DatabaseConfig struct {
 	Host            string        `json:"host"`
 	Port            int           `json:"port"`
 	User            string        `json:"user"`
-	Password        string        `json:"password"`
+	Password        Secret        `json:"password"`
 	Database        string        `json:"database"`
 	MaxOpenConns    int           `json:"max_open_conns"`
 	MaxIdleConns    int           `json:"max_idle_conns"`
 	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"`
-	SSLMode         string        `json:"ssl_mode"`
+	// SSLMode is one of the SSLMode* modes; empty means SSLModeDisabled
+	SSLMode string `json:"ssl_mode"`
+	// SSLCA is the CA bundle that verifies the server for SSLModeCustom
+	SSLCA string `json:"ssl_ca"`
+	// SSLCert and SSLKey are an optional client certificate for
+	// SSLModeCustom
+	SSLCert string `json:"ssl_cert"`
+	SSLKey  string `json:"ssl_key"`
+
+	// ReadReplicas lists optional read-only endpoints that serve GetTask and
+	// ListTasks. They share credentials and pool settings with the primary.
+	ReadReplicas []ReplicaConfig `json:"read_replicas"`
+	// ReplicaHealthInterval controls how often replicas are pinged
+	ReplicaHealthInterval time.Duration `json:"replica_health_interval"`
+	// ReplicaHealthTimeout bounds each replica ping during a health check
+	ReplicaHealthTimeout time.Duration `json:"replica_health_timeout"`
+
+	// MigrateOnStartup is one of the Migrate* modes; empty means MigrateAuto
+	MigrateOnStartup string `json:"migrate_on_startup"`
+	// MigrateLockTimeout bounds how long MigrateAuto waits for another
+	// instance to finish migrating; zero means the store default
+	MigrateLockTimeout time.Duration `json:"migrate_lock_timeout"`
+	// MigrationsDir overrides the migrations embedded in the binary with the
+	// *.sql files in a directory, for hotfixes
+	MigrationsDir string `json:"migrations_dir"`
 }
 
-// Load loads configuration from environment variables with defaults
+// DefaultDatabasePassword is the development password used when none is
+// configured. Validate refuses it in production.
+const DefaultDatabasePassword = "todouser123"
+
+// Schema migration modes for DatabaseConfig.MigrateOnStartup
+const (
+	// MigrateAuto applies pending migrations at startup, one instance at a
+	// time
+	MigrateAuto = "auto"
+	// MigrateVerifyOnly refuses to start unless the schema is current
+	MigrateVerifyOnly = "verify-only"
+	// MigrateOff neither applies nor checks migrations at startup
+	MigrateOff = "off"
+)
+
+// ReplicaConfig holds the address of a single read replica
+type ReplicaConfig struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// String returns the replica address in host:port form
+func (r ReplicaConfig) String() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
+
+// Load loads configuration from defaults, the file named by CONFIG_FILE if
+// it is set, and environment variables, in increasing order of precedence
 func Load() (*Config, error) {
-	config := &Config{
-		Server: ServerConfig{
-			Port:            getEnvAsInt("SERVER_PORT", 8080),
-			ReadTimeout:     getEnvAsDuration("SERVER_READ_TIMEOUT", "30s"),
-			WriteTimeout:    getEnvAsDuration("SERVER_WRITE_TIMEOUT", "30s"),
-			IdleTimeout:     getEnvAsDuration("SERVER_IDLE_TIMEOUT", "60s"),
-			ShutdownTimeout: getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", "15s"),
-			CORS: CORSConfig{
-				AllowedOrigins: getEnvAsStringSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
-				AllowedMethods: getEnvAsStringSlice("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-				AllowedHeaders: getEnvAsStringSlice("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Connect-Protocol-Version", "Connect-Timeout-Ms"}),
-			},
-		},
-		Logger: LoggerConfig{
-			Level:  getEnvAsString("LOG_LEVEL", "info"),
-			Format: getEnvAsString("LOG_FORMAT", "json"),
-		},
-		Database: DatabaseConfig{
-			Host:            getEnvAsString("DB_HOST", "todo-mariadb.todo-app.svc.cluster.local"),
-			Port:            getEnvAsInt("DB_PORT", 3306),
-			User:            getEnvAsString("DB_USER", "todoapp"),
-			Password:        getEnvAsString("DB_PASSWORD", "todouser123"),
-			Database:        getEnvAsString("DB_NAME", "todoapp"),
-			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
-			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 10),
-			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", "5m"),
-			ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", "5m"),
-			SSLMode:         getEnvAsString("DB_SSL_MODE", "false"),
-		},
-	}
-
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
-	}
-
-	return config, nil
+	return LoadFile(os.Getenv(ConfigFileEnv))
+}
+
+// LoadFile loads configuration like Load, reading the YAML or JSON file at
+// path instead of CONFIG_FILE. An empty path reads no file.
+func LoadFile(path string) (*Config, error) {
+	resolved, err := Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.Config, nil
 }
 
 // Validate validates the configuration
@@ -113,6 +272,41 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid shutdown timeout: %v (must be positive)", c.Server.ShutdownTimeout)
 	}
 
+	// Validate request timeouts
+	if c.Server.RequestTimeouts.Default < 0 {
+		return fmt.Errorf("invalid request timeout: %v (must not be negative)", c.Server.RequestTimeouts.Default)
+	}
+	for procedure, timeout := range c.Server.RequestTimeouts.Procedures {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid request timeout for %s: %v (must be positive)", procedure, timeout)
+		}
+	}
+
+	// Validate rate limits
+	if c.Server.RateLimit.Enabled {
+		if err := c.Server.RateLimit.Default.validate("default"); err != nil {
+			return err
+		}
+		for procedure, limit := range c.Server.RateLimit.Procedures {
+			if err := limit.validate(procedure); err != nil {
+				return err
+			}
+		}
+		if _, err := ParsePrefixes(c.Server.RateLimit.TrustedProxies); err != nil {
+			return fmt.Errorf("invalid rate limit trusted proxies: %w", err)
+		}
+	}
+
+	// Validate CORS
+	if err := c.Server.CORS.validate(); err != nil {
+		return err
+	}
+
+	// Validate TLS
+	if err := c.Server.TLS.validate(); err != nil {
+		return err
+	}
+
 	// Validate log level
 	validLevels := map[string]bool{
 		"debug": true,
@@ -151,62 +345,234 @@ func (c *Config) Validate() error {
 	if c.Database.MaxIdleConns > c.Database.MaxOpenConns {
 		return fmt.Errorf("max idle connections cannot exceed max open connections")
 	}
+	for _, replica := range c.Database.ReadReplicas {
+		if replica.Host == "" {
+			return fmt.Errorf("read replica host cannot be empty")
+		}
+		if replica.Port <= 0 || replica.Port > 65535 {
+			return fmt.Errorf("invalid read replica port: %d (must be between 1 and 65535)", replica.Port)
+		}
+	}
+	if len(c.Database.ReadReplicas) > 0 && c.Database.ReplicaHealthInterval <= 0 {
+		return fmt.Errorf("invalid replica health interval: %v (must be positive)", c.Database.ReplicaHealthInterval)
+	}
+	if len(c.Database.ReadReplicas) > 0 && c.Database.ReplicaHealthTimeout <= 0 {
+		return fmt.Errorf("invalid replica health timeout: %v (must be positive)", c.Database.ReplicaHealthTimeout)
+	}
+	switch c.Database.MigrateOnStartup {
+	case "", MigrateAuto, MigrateVerifyOnly, MigrateOff:
+	default:
+		return fmt.Errorf("invalid migrate on startup mode: %s (must be one of: auto, verify-only, off)", c.Database.MigrateOnStartup)
+	}
+	if c.Database.MigrateLockTimeout < 0 {
+		return fmt.Errorf("invalid migrate lock timeout: %v (must not be negative)", c.Database.MigrateLockTimeout)
+	}
+	if err := c.Database.validateTLS(); err != nil {
+		return err
+	}
+	if c.IsProduction() && c.Database.Password == DefaultDatabasePassword {
+		return fmt.Errorf("the default database password is not allowed in production; set DB_PASSWORD or DB_PASSWORD_FILE")
+	}
+
+	// Validate cache configuration
+	if c.Cache.Enabled {
+		if c.Cache.Capacity <= 0 {
+			return fmt.Errorf("cache capacity must be positive")
+		}
+		if c.Cache.TTL <= 0 {
+			return fmt.Errorf("invalid cache TTL: %v (must be positive)", c.Cache.TTL)
+		}
+	}
+
+	// Validate tracing configuration
+	switch c.Tracing.Exporter {
+	case "", "none", "stdout", "otlp":
+	default:
+		return fmt.Errorf("invalid tracing exporter: %s (must be one of: none, stdout, otlp)", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("invalid tracing sample ratio: %v (must be between 0 and 1)", c.Tracing.SampleRatio)
+	}
+
+	// Validate health configuration
+	if c.Health.CheckTimeout < 0 {
+		return fmt.Errorf("invalid health check timeout: %v (must not be negative)", c.Health.CheckTimeout)
+	}
+	if c.Health.PoolSaturationThreshold < 0 || c.Health.PoolSaturationThreshold > 1 {
+		return fmt.Errorf("invalid pool saturation threshold: %v (must be between 0 and 1)", c.Health.PoolSaturationThreshold)
+	}
+	if c.Health.ShutdownDelay < 0 {
+		return fmt.Errorf("invalid health shutdown delay: %v (must not be negative)", c.Health.ShutdownDelay)
+	}
+
+	// Validate calendar configuration
+	if c.Calendar.FeedToken != "" && len(c.Calendar.FeedToken) < minFeedTokenLength {
+		return fmt.Errorf("calendar feed token must be at least %d characters", minFeedTokenLength)
+	}
+
+	// Validate idempotency configuration
+	if c.Idempotency.TTL < 0 {
+		return fmt.Errorf("invalid idempotency TTL: %v (must not be negative)", c.Idempotency.TTL)
+	}
+	if c.Idempotency.PurgeInterval < 0 {
+		return fmt.Errorf("invalid idempotency purge interval: %v (must not be negative)", c.Idempotency.PurgeInterval)
+	}
+	if c.Idempotency.Lease < 0 {
+		return fmt.Errorf("invalid idempotency lease: %v (must not be negative)", c.Idempotency.Lease)
+	}
 
 	return nil
 }
 
-// DSN returns the database connection string
+// DSN returns the database connection string. With SSLModeCustom it names
+// the "custom" TLS configuration, which must be registered with the MySQL
+// driver from TLSClientConfig.
 func (d *DatabaseConfig) DSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		d.User, d.Password, d.Host, d.Port, d.Database)
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		d.User, d.Password.Reveal(), d.Host, d.Port, d.Database)
+	if d.SSLMode != "" && d.SSLMode != SSLModeDisabled {
+		dsn += "&tls=" + d.SSLMode
+	}
+	return dsn
 }
 
-// IsDevelopment returns true if running in development mode
-func (c *Config) IsDevelopment() bool {
-	return getEnvAsString("ENVIRONMENT", "development") == "development"
+// ForReplica returns a copy of the configuration that points at the given
+// replica instead of the primary
+func (d *DatabaseConfig) ForReplica(replica ReplicaConfig) DatabaseConfig {
+	replicaCfg := *d
+	replicaCfg.Host = replica.Host
+	replicaCfg.Port = replica.Port
+	replicaCfg.ReadReplicas = nil
+	return replicaCfg
 }
 
-// IsProduction returns true if running in production mode
-func (c *Config) IsProduction() bool {
-	return getEnvAsString("ENVIRONMENT", "development") == "production"
+// ParseReplicas parses a comma-separated list of host[:port] entries. Entries
+// without a port use defaultPort.
+func ParseReplicas(value string, defaultPort int) ([]ReplicaConfig, error) {
+	var replicas []ReplicaConfig
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, portStr, err := net.SplitHostPort(entry)
+		if err != nil {
+			// No port given; the whole entry is the host
+			replicas = append(replicas, ReplicaConfig{Host: entry, Port: defaultPort})
+			continue
+		}
+
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in replica %q: %w", entry, err)
+		}
+		replicas = append(replicas, ReplicaConfig{Host: host, Port: port})
+	}
+	return replicas, nil
 }
 
-// Helper functions for environment variable parsing
+// ParseRateLimits parses a comma-separated list of procedure=rps:burst
+// entries, e.g. "CreateTask=2:5,DeleteTask=1:2"
+func ParseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
 
-func getEnvAsString(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+		procedure, spec, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(procedure) == "" {
+			return nil, fmt.Errorf("invalid rate limit %q (expected procedure=rps:burst)", entry)
+		}
+		rpsStr, burstStr, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q (expected procedure=rps:burst)", entry)
+		}
+
+		rps, err := strconv.ParseFloat(strings.TrimSpace(rpsStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate in %q: %w", entry, err)
+		}
+		burst, err := strconv.Atoi(strings.TrimSpace(burstStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid burst in %q: %w", entry, err)
+		}
+
+		limits[strings.TrimSpace(procedure)] = RateLimit{RequestsPerSecond: rps, Burst: burst}
 	}
-	return defaultValue
+	return limits, nil
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if valueStr, exists := os.LookupEnv(key); exists {
-		if value, err := strconv.Atoi(valueStr); err == nil {
-			return value
+// ParsePrefixes parses addresses and CIDR ranges such as "10.0.0.0/8" or
+// "192.168.1.10"; a bare address is a range of one
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if addr, err := netip.ParseAddr(value); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
 		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an address or CIDR range", value)
+		}
+		prefixes = append(prefixes, prefix.Masked())
 	}
-	return defaultValue
+	return prefixes, nil
 }
 
-func getEnvAsDuration(key string, defaultValue string) time.Duration {
-	if valueStr, exists := os.LookupEnv(key); exists {
-		if value, err := time.ParseDuration(valueStr); err == nil {
-			return value
+// ParseProcedureTimeouts parses a comma-separated list of procedure=duration
+// entries, e.g. "GetAllTasks=10s,CreateTask=2s"
+func ParseProcedureTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		procedure, durationStr, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(procedure) == "" {
+			return nil, fmt.Errorf("invalid timeout %q (expected procedure=duration)", entry)
 		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(durationStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration in %q: %w", entry, err)
+		}
+
+		timeouts[strings.TrimSpace(procedure)] = timeout
+	}
+	return timeouts, nil
+}
+
+// validate checks that the limit describes a usable token bucket
+func (r RateLimit) validate(name string) error {
+	if r.RequestsPerSecond <= 0 {
+		return fmt.Errorf("invalid rate limit for %s: %v requests per second (must be positive)", name, r.RequestsPerSecond)
 	}
-	// Parse default value
-	if duration, err := time.ParseDuration(defaultValue); err == nil {
-		return duration
+	if r.Burst <= 0 {
+		return fmt.Errorf("invalid rate limit burst for %s: %d (must be positive)", name, r.Burst)
 	}
-	return 30 * time.Second // fallback
+	return nil
+}
+
+// IsDevelopment returns true if running in development mode
+func (c *Config) IsDevelopment() bool {
+	return getEnvAsString("ENVIRONMENT", "development") == "development"
+}
+
+// IsProduction returns true if running in production mode
+func (c *Config) IsProduction() bool {
+	return getEnvAsString("ENVIRONMENT", "development") == "production"
 }
 
-func getEnvAsStringSlice(key string, defaultValue []string) []string {
-	if valueStr, exists := os.LookupEnv(key); exists {
-		// Simple implementation: assume comma-separated values
-		// In production, you might want to use a more sophisticated parser
-		return []string{valueStr}
+// getEnvAsString returns the environment variable key, or defaultValue when
+// it is unset
+func getEnvAsString(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
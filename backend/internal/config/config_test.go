@@ -281,6 +281,401 @@ func TestConfig_IsProduction(t *testing.T) {
 	}
 }
 
+func TestLoad_ReadReplicas(t *testing.T) {
+	setEnvVars(map[string]string{
+		"DB_PORT":          "3307",
+		"DB_READ_REPLICAS": "replica-a:3306, replica-b",
+	})
+	defer clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, []ReplicaConfig{
+		{Host: "replica-a", Port: 3306},
+		{Host: "replica-b", Port: 3307},
+	}, config.Database.ReadReplicas)
+	assert.Equal(t, 10*time.Second, config.Database.ReplicaHealthInterval)
+	assert.Equal(t, 2*time.Second, config.Database.ReplicaHealthTimeout)
+}
+
+func TestLoad_InvalidReadReplicas(t *testing.T) {
+	setEnvVars(map[string]string{
+		"DB_READ_REPLICAS": "replica-a:notaport",
+	})
+	defer clearEnvVars()
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_READ_REPLICAS")
+}
+
+func TestLoad_InvalidReplicaHealthTimeout(t *testing.T) {
+	setEnvVars(map[string]string{
+		"DB_READ_REPLICAS":          "replica-a",
+		"DB_REPLICA_HEALTH_TIMEOUT": "0s",
+	})
+	defer clearEnvVars()
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid replica health timeout")
+}
+
+func TestLoad_Cache(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.False(t, config.Cache.Enabled)
+	assert.Equal(t, 1000, config.Cache.Capacity)
+	assert.Equal(t, 30*time.Second, config.Cache.TTL)
+
+	setEnvVars(map[string]string{
+		"CACHE_ENABLED":  "true",
+		"CACHE_CAPACITY": "50",
+		"CACHE_TTL":      "5s",
+	})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	assert.True(t, config.Cache.Enabled)
+	assert.Equal(t, 50, config.Cache.Capacity)
+	assert.Equal(t, 5*time.Second, config.Cache.TTL)
+}
+
+func TestLoad_Tracing(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "none", config.Tracing.Exporter)
+	assert.Equal(t, "todo-backend", config.Tracing.ServiceName)
+	assert.Equal(t, 1.0, config.Tracing.SampleRatio)
+
+	setEnvVars(map[string]string{
+		"TRACING_EXPORTER":            "otlp",
+		"TRACING_SAMPLE_RATIO":        "0.25",
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318",
+	})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "otlp", config.Tracing.Exporter)
+	assert.Equal(t, 0.25, config.Tracing.SampleRatio)
+	assert.Equal(t, "http://collector:4318", config.Tracing.OTLPEndpoint)
+
+	os.Setenv("TRACING_EXPORTER", "zipkin")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid tracing exporter")
+}
+
+func TestLoad_Health(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, config.Health.CheckTimeout)
+	assert.Equal(t, 0.9, config.Health.PoolSaturationThreshold)
+	assert.Equal(t, 5*time.Second, config.Health.ShutdownDelay)
+
+	setEnvVars(map[string]string{
+		"HEALTH_CHECK_TIMEOUT":             "500ms",
+		"HEALTH_POOL_SATURATION_THRESHOLD": "0.75",
+		"HEALTH_SHUTDOWN_DELAY":            "0s",
+	})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, config.Health.CheckTimeout)
+	assert.Equal(t, 0.75, config.Health.PoolSaturationThreshold)
+	assert.Equal(t, time.Duration(0), config.Health.ShutdownDelay)
+
+	os.Setenv("HEALTH_POOL_SATURATION_THRESHOLD", "1.5")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid pool saturation threshold")
+}
+
+func TestLoad_RateLimit(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.False(t, config.Server.RateLimit.Enabled)
+	assert.Equal(t, RateLimit{RequestsPerSecond: 10, Burst: 20}, config.Server.RateLimit.Default)
+	assert.Empty(t, config.Server.RateLimit.Procedures)
+	assert.Empty(t, config.Server.RateLimit.TrustedProxies)
+
+	setEnvVars(map[string]string{
+		"RATE_LIMIT_ENABLED":         "true",
+		"RATE_LIMIT_RPS":             "5",
+		"RATE_LIMIT_BURST":           "10",
+		"RATE_LIMIT_PROCEDURES":      "CreateTask=0.5:2, /task.v1.TaskService/DeleteTask=1:1",
+		"RATE_LIMIT_TRUSTED_PROXIES": "10.0.0.0/8, 192.168.1.10",
+	})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	limits := config.Server.RateLimit
+	assert.True(t, limits.Enabled)
+	assert.Equal(t, RateLimit{RequestsPerSecond: 0.5, Burst: 2}, limits.ForProcedure("/task.v1.TaskService/CreateTask"))
+	assert.Equal(t, RateLimit{RequestsPerSecond: 1, Burst: 1}, limits.ForProcedure("/task.v1.TaskService/DeleteTask"))
+	assert.Equal(t, RateLimit{RequestsPerSecond: 5, Burst: 10}, limits.ForProcedure("/task.v1.TaskService/GetTask"))
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.10"}, limits.TrustedProxies)
+
+	os.Setenv("RATE_LIMIT_TRUSTED_PROXIES", "ingress")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid rate limit trusted proxies")
+	os.Setenv("RATE_LIMIT_TRUSTED_PROXIES", "")
+
+	os.Setenv("RATE_LIMIT_PROCEDURES", "CreateTask=0:2")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid rate limit for CreateTask")
+
+	os.Setenv("RATE_LIMIT_PROCEDURES", "CreateTask")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid RATE_LIMIT_PROCEDURES")
+}
+
+func TestLoad_Idempotency(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, config.Idempotency.TTL)
+	assert.Equal(t, time.Hour, config.Idempotency.PurgeInterval)
+	assert.Equal(t, time.Minute, config.Idempotency.Lease)
+	assert.Contains(t, config.Server.CORS.AllowedHeaders, "Idempotency-Key")
+
+	setEnvVars(map[string]string{
+		"IDEMPOTENCY_TTL":            "1h",
+		"IDEMPOTENCY_PURGE_INTERVAL": "0s",
+		"IDEMPOTENCY_LEASE":          "5m",
+	})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, config.Idempotency.TTL)
+	assert.Equal(t, time.Duration(0), config.Idempotency.PurgeInterval)
+	assert.Equal(t, 5*time.Minute, config.Idempotency.Lease)
+}
+
+func TestLoad_LegacyDeleteResponses(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.False(t, config.Server.LegacyDeleteResponses)
+
+	setEnvVars(map[string]string{"LEGACY_DELETE_RESPONSES": "true"})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	assert.True(t, config.Server.LegacyDeleteResponses)
+}
+
+func TestLoad_Calendar(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, config.Calendar.FeedToken)
+
+	setEnvVars(map[string]string{"CALENDAR_FEED_TOKEN": "0123456789abcdef"})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, Secret("0123456789abcdef"), config.Calendar.FeedToken)
+
+	os.Setenv("CALENDAR_FEED_TOKEN", "short")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "calendar feed token")
+}
+
+func TestLoad_MigrateOnStartup(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, MigrateAuto, config.Database.MigrateOnStartup)
+	assert.Equal(t, 5*time.Minute, config.Database.MigrateLockTimeout)
+	assert.Empty(t, config.Database.MigrationsDir)
+
+	setEnvVars(map[string]string{
+		"MIGRATE_ON_STARTUP":   "verify-only",
+		"MIGRATE_LOCK_TIMEOUT": "30s",
+		"DB_MIGRATIONS_DIR":    "/etc/todo/migrations",
+	})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, MigrateVerifyOnly, config.Database.MigrateOnStartup)
+	assert.Equal(t, 30*time.Second, config.Database.MigrateLockTimeout)
+	assert.Equal(t, "/etc/todo/migrations", config.Database.MigrationsDir)
+
+	os.Setenv("MIGRATE_ON_STARTUP", "always")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migrate on startup mode")
+
+	os.Setenv("MIGRATE_ON_STARTUP", "off")
+	os.Setenv("MIGRATE_LOCK_TIMEOUT", "-1s")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migrate lock timeout")
+}
+
+func TestLoad_RequestTimeouts(t *testing.T) {
+	clearEnvVars()
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, config.Server.RequestTimeouts.Default)
+	assert.Empty(t, config.Server.RequestTimeouts.Procedures)
+
+	setEnvVars(map[string]string{
+		"REQUEST_TIMEOUT":            "5s",
+		"REQUEST_TIMEOUT_PROCEDURES": "GetAllTasks=10s,/task.v1.TaskService/CreateTask=1s",
+	})
+	defer clearEnvVars()
+
+	config, err = Load()
+	require.NoError(t, err)
+	timeouts := config.Server.RequestTimeouts
+	assert.Equal(t, 10*time.Second, timeouts.ForProcedure("/task.v1.TaskService/GetAllTasks"))
+	assert.Equal(t, time.Second, timeouts.ForProcedure("/task.v1.TaskService/CreateTask"))
+	assert.Equal(t, 5*time.Second, timeouts.ForProcedure("/task.v1.TaskService/GetTask"))
+
+	os.Setenv("REQUEST_TIMEOUT_PROCEDURES", "GetAllTasks=soon")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid REQUEST_TIMEOUT_PROCEDURES")
+
+	os.Setenv("REQUEST_TIMEOUT_PROCEDURES", "GetAllTasks=0s")
+	_, err = Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid request timeout for GetAllTasks")
+}
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected map[string]RateLimit
+		wantErr  bool
+	}{
+		{name: "empty", value: "", expected: map[string]RateLimit{}},
+		{
+			name:  "multiple",
+			value: "CreateTask=2:5,GetTask=100:200",
+			expected: map[string]RateLimit{
+				"CreateTask": {RequestsPerSecond: 2, Burst: 5},
+				"GetTask":    {RequestsPerSecond: 100, Burst: 200},
+			},
+		},
+		{name: "missing_burst", value: "CreateTask=2", wantErr: true},
+		{name: "missing_procedure", value: "=2:5", wantErr: true},
+		{name: "bad_rate", value: "CreateTask=fast:5", wantErr: true},
+		{name: "bad_burst", value: "CreateTask=2:lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits, err := ParseRateLimits(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limits)
+		})
+	}
+}
+
+func TestParseReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []ReplicaConfig
+		wantErr  bool
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: nil,
+		},
+		{
+			name:     "host_only",
+			value:    "replica",
+			expected: []ReplicaConfig{{Host: "replica", Port: 3306}},
+		},
+		{
+			name:  "host_and_port",
+			value: "replica-a:3310,replica-b:3311",
+			expected: []ReplicaConfig{
+				{Host: "replica-a", Port: 3310},
+				{Host: "replica-b", Port: 3311},
+			},
+		},
+		{
+			name:     "skips_blank_entries",
+			value:    " replica-a , ,",
+			expected: []ReplicaConfig{{Host: "replica-a", Port: 3306}},
+		},
+		{
+			name:    "invalid_port",
+			value:   "replica:abc",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replicas, err := ParseReplicas(tt.value, 3306)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, replicas)
+		})
+	}
+}
+
+func TestDatabaseConfig_ForReplica(t *testing.T) {
+	primary := DatabaseConfig{
+		Host:         "primary",
+		Port:         3306,
+		User:         "user",
+		Password:     "pass",
+		Database:     "db",
+		ReadReplicas: []ReplicaConfig{{Host: "replica", Port: 3307}},
+	}
+
+	replica := primary.ForReplica(primary.ReadReplicas[0])
+
+	assert.Equal(t, "replica", replica.Host)
+	assert.Equal(t, 3307, replica.Port)
+	assert.Equal(t, "user", replica.User)
+	assert.Empty(t, replica.ReadReplicas)
+	assert.Contains(t, replica.DSN(), "@tcp(replica:3307)/db")
+	assert.Equal(t, "primary", primary.Host, "primary config must not be modified")
+}
+
 // Helper functions
 
 func setEnvVars(vars map[string]string) {
@@ -302,6 +697,36 @@ func clearEnvVars() {
 		"LOG_LEVEL",
 		"LOG_FORMAT",
 		"ENVIRONMENT",
+		"DB_PORT",
+		"DB_READ_REPLICAS",
+		"DB_REPLICA_HEALTH_INTERVAL",
+		"DB_REPLICA_HEALTH_TIMEOUT",
+		"MIGRATE_ON_STARTUP",
+		"MIGRATE_LOCK_TIMEOUT",
+		"DB_MIGRATIONS_DIR",
+		"CACHE_ENABLED",
+		"CACHE_CAPACITY",
+		"CACHE_TTL",
+		"TRACING_EXPORTER",
+		"TRACING_SAMPLE_RATIO",
+		"OTEL_EXPORTER_OTLP_ENDPOINT",
+		"OTEL_SERVICE_NAME",
+		"HEALTH_CHECK_TIMEOUT",
+		"HEALTH_POOL_SATURATION_THRESHOLD",
+		"HEALTH_SHUTDOWN_DELAY",
+		"LEGACY_DELETE_RESPONSES",
+		"CALENDAR_FEED_TOKEN",
+		"RATE_LIMIT_ENABLED",
+		"RATE_LIMIT_RPS",
+		"RATE_LIMIT_BURST",
+		"RATE_LIMIT_PROCEDURES",
+		"RATE_LIMIT_TRUSTED_PROXIES",
+		"IDEMPOTENCY_TTL",
+		"IDEMPOTENCY_PURGE_INTERVAL",
+		"IDEMPOTENCY_LEASE",
+		"REQUEST_TIMEOUT",
+		"REQUEST_TIMEOUT_PROCEDURES",
+		"CONFIG_FILE",
 	}
 	
 	for _, key := range envVars {
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AllOrigins is the CORSConfig.AllowedOrigins entry that allows every
+// origin
+const AllOrigins = "*"
+
+// validate checks the origin patterns and the credentials setting
+func (c *CORSConfig) validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == AllOrigins {
+			if c.AllowCredentials {
+				return fmt.Errorf("CORS credentials cannot be allowed for every origin (%s); list the origins instead", AllOrigins)
+			}
+			continue
+		}
+		if err := validateOrigin(origin); err != nil {
+			return fmt.Errorf("invalid CORS origin %q: %w", origin, err)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("invalid CORS max age: %v (must not be negative)", c.MaxAge)
+	}
+	return nil
+}
+
+// validateOrigin checks that origin is scheme://host[:port], where the host
+// may start with a "*." wildcard label
+func validateOrigin(origin string) error {
+	host := origin
+	if i := strings.Index(origin, "://"); i >= 0 {
+		host = origin[i+3:]
+	}
+	if strings.HasPrefix(host, "*.") {
+		host = host[2:]
+	}
+	if strings.Contains(host, "*") {
+		return fmt.Errorf("a wildcard may only replace the leftmost host label, as in https://*.example.com")
+	}
+
+	u, err := url.Parse(strings.Replace(origin, "*.", "wildcard.", 1))
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("must be scheme://host[:port]")
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("must not have a path, query or user info")
+	}
+	return nil
+}
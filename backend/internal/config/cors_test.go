@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve_CORS(t *testing.T) {
+	r, err := resolve("", envMap(nil))
+	require.NoError(t, err)
+	assert.Contains(t, r.Config.Server.CORS.AllowedHeaders, "X-Grpc-Web")
+	assert.Contains(t, r.Config.Server.CORS.ExposedHeaders, "Grpc-Status")
+	assert.Contains(t, r.Config.Server.CORS.ExposedHeaders, "Retry-After")
+	assert.False(t, r.Config.Server.CORS.AllowCredentials)
+	assert.Equal(t, "2h0m0s", r.Config.Server.CORS.MaxAge.String())
+
+	r, err = resolve("", envMap(map[string]string{
+		"CORS_ALLOWED_ORIGINS":   "https://app.example.com,https://*.example.net",
+		"CORS_ALLOW_CREDENTIALS": "true",
+		"CORS_MAX_AGE":           "10m",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com", "https://*.example.net"}, r.Config.Server.CORS.AllowedOrigins)
+	assert.True(t, r.Config.Server.CORS.AllowCredentials)
+}
+
+func TestValidate_CORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"credentials for every origin", map[string]string{"CORS_ALLOW_CREDENTIALS": "true"}, "CORS credentials cannot be allowed for every origin"},
+		{"no scheme", map[string]string{"CORS_ALLOWED_ORIGINS": "app.example.com"}, `invalid CORS origin "app.example.com"`},
+		{"path", map[string]string{"CORS_ALLOWED_ORIGINS": "https://app.example.com/app"}, "must not have a path"},
+		{"inner wildcard", map[string]string{"CORS_ALLOWED_ORIGINS": "https://app.*.example.com"}, "leftmost host label"},
+		{"negative max age", map[string]string{"CORS_MAX_AGE": "-1s"}, "invalid CORS max age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolve("", envMap(tt.env))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	_, err := resolve("", envMap(map[string]string{"CORS_ALLOWED_ORIGINS": "http://localhost:3000,https://*.example.com/"}))
+	assert.NoError(t, err)
+}
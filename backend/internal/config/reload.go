@@ -0,0 +1,199 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reloadableKeys lists the settings, or prefixes of settings ending in ".",
+// that can change while the server runs. Everything else is read once at
+// startup, such as listener ports, database connections and which
+// middleware is installed.
+var reloadableKeys = []string{
+	"logger.level",
+	"database.password",
+	"server.cors.",
+	"server.rate_limit.default.",
+	"server.rate_limit.procedures",
+	"server.request_timeouts.",
+}
+
+// reloadable reports whether the setting key can change without a restart
+func reloadable(key string) bool {
+	for _, k := range reloadableKeys {
+		if key == k || strings.HasSuffix(k, ".") && strings.HasPrefix(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Change is one setting that differs between two configurations
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// String returns the change as key: "old" -> "new"
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Key, c.Old, c.New)
+}
+
+// UnsafeChangeError rejects a reload that changes settings which need a
+// restart. Changes lists every difference, reloadable or not, with secrets
+// redacted.
+type UnsafeChangeError struct {
+	Unsafe  []string
+	Changes []Change
+}
+
+func (e *UnsafeChangeError) Error() string {
+	return fmt.Sprintf("configuration not reloaded: %s cannot change without a restart", strings.Join(e.Unsafe, ", "))
+}
+
+// ReloadResult describes a successful reload
+type ReloadResult struct {
+	// Changes lists the settings that changed; empty when nothing did
+	Changes []Change
+}
+
+// Store holds the current configuration and swaps in a new one on Reload.
+// Readers always see a complete configuration: Current returns either the
+// old or the new one, never a mix.
+type Store struct {
+	path      string
+	lookupEnv func(string) (string, bool)
+	// digest is the hash of the watched files when the Store was created
+	digest []byte
+
+	// mu serializes reloads and subscriber calls
+	mu          sync.Mutex
+	current     atomic.Pointer[Resolved]
+	subscribers []func(cfg *Config)
+}
+
+// NewStore returns a Store holding resolved, which Reload re-reads from the
+// same file and environment
+func NewStore(resolved *Resolved) *Store {
+	s := &Store{path: resolved.File, lookupEnv: os.LookupEnv}
+	s.digest = filesDigest(resolved.Files)
+	s.current.Store(resolved)
+	return s
+}
+
+// Current returns the configuration in effect. Callers must not modify it.
+func (s *Store) Current() *Config {
+	return s.current.Load().Config
+}
+
+// Subscribe registers fn to be called with the new configuration after each
+// reload that changes a setting
+func (s *Store) Subscribe(fn func(cfg *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Reload loads the configuration again and, if only reloadable settings
+// changed, makes it current and notifies subscribers. The current
+// configuration is kept when loading fails or when a setting that needs a
+// restart changed, in which case the error is an *UnsafeChangeError.
+func (s *Store) Reload() (*ReloadResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, err := resolve(s.path, s.lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diff(s.current.Load(), next)
+	var unsafe []string
+	for _, c := range changes {
+		if !reloadable(c.Key) {
+			unsafe = append(unsafe, c.Key)
+		}
+	}
+	if len(unsafe) > 0 {
+		return nil, &UnsafeChangeError{Unsafe: unsafe, Changes: changes}
+	}
+	if len(changes) == 0 {
+		return &ReloadResult{}, nil
+	}
+
+	s.current.Store(next)
+	for _, fn := range s.subscribers {
+		fn(next.Config)
+	}
+	return &ReloadResult{Changes: changes}, nil
+}
+
+// Watch reloads whenever the contents of the configuration file or a secret
+// file change, checking every interval until ctx is done. onReload receives
+// the outcome of each reload. Watch returns at once when there is no file to
+// watch.
+func (s *Store) Watch(ctx context.Context, interval time.Duration, onReload func(*ReloadResult, error)) {
+	if len(s.current.Load().Files) == 0 {
+		return
+	}
+
+	last := s.digest
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			digest := filesDigest(s.current.Load().Files)
+			if bytes.Equal(digest, last) {
+				continue
+			}
+			last = digest
+			onReload(s.Reload())
+		}
+	}
+}
+
+// filesDigest returns a hash of the paths and contents of files. Files that
+// cannot be read hash as empty.
+func filesDigest(files []string) []byte {
+	h := sha256.New()
+	for _, path := range files {
+		data, _ := os.ReadFile(path)
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+		h.Write(data)
+	}
+	return h.Sum(nil)
+}
+
+// diff lists the settings whose values differ between old and next, with
+// secrets redacted
+func diff(old, next *Resolved) []Change {
+	previous := make(map[string]string, len(old.Values))
+	for _, v := range old.Values {
+		previous[v.Key] = v.Value
+	}
+
+	var changes []Change
+	for _, v := range next.Values {
+		before := previous[v.Key]
+		if before == v.Value {
+			continue
+		}
+		c := Change{Key: v.Key, Old: before, New: v.Value}
+		if v.Secret {
+			c.Old, c.New = redactedValue, redactedValue
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore returns a Store over a configuration file with content and
+// the given environment
+func newTestStore(t *testing.T, content string, env map[string]string) (*Store, string) {
+	t.Helper()
+	path := writeConfigFile(t, "config.yaml", content)
+	resolved, err := resolve(path, envMap(env))
+	require.NoError(t, err)
+	s := NewStore(resolved)
+	s.lookupEnv = envMap(env)
+	return s, path
+}
+
+func TestStore_ReloadAppliesSafeChanges(t *testing.T) {
+	s, path := newTestStore(t, "logger:\n  level: info\n", nil)
+	before := s.Current()
+
+	var notified []*Config
+	s.Subscribe(func(cfg *Config) { notified = append(notified, cfg) })
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+logger:
+  level: debug
+server:
+  cors:
+    allowed_origins: [https://app.example.com]
+  request_timeouts:
+    default: 10s
+  rate_limit:
+    default:
+      burst: 5
+`), 0o600))
+
+	result, err := s.Reload()
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []Change{
+		{Key: "logger.level", Old: "info", New: "debug"},
+		{Key: "server.cors.allowed_origins", Old: "*", New: "https://app.example.com"},
+		{Key: "server.rate_limit.default.burst", Old: "20", New: "5"},
+		{Key: "server.request_timeouts.default", Old: "30s", New: "10s"},
+	}, result.Changes)
+
+	current := s.Current()
+	assert.Equal(t, "debug", current.Logger.Level)
+	assert.Equal(t, []string{"https://app.example.com"}, current.Server.CORS.AllowedOrigins)
+	assert.Equal(t, 10*time.Second, current.Server.RequestTimeouts.Default)
+	assert.Equal(t, 5, current.Server.RateLimit.Default.Burst)
+	require.Len(t, notified, 1)
+	assert.Same(t, current, notified[0])
+
+	// The previous configuration is replaced, not modified
+	assert.Equal(t, "info", before.Logger.Level)
+}
+
+func TestStore_ReloadRejectsUnsafeChanges(t *testing.T) {
+	s, path := newTestStore(t, "server:\n  port: 8080\n", map[string]string{"CALENDAR_FEED_TOKEN": "old-calendar-token"})
+	s.Subscribe(func(*Config) { t.Fatal("subscriber called for a rejected reload") })
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  port: 9090\nlogger:\n  level: debug\n"), 0o600))
+	s.lookupEnv = envMap(map[string]string{"CALENDAR_FEED_TOKEN": "new-calendar-token"})
+
+	_, err := s.Reload()
+	var unsafe *UnsafeChangeError
+	require.ErrorAs(t, err, &unsafe)
+	assert.Equal(t, []string{"server.port", "calendar.feed_token"}, unsafe.Unsafe)
+	assert.Contains(t, unsafe.Changes, Change{Key: "server.port", Old: "8080", New: "9090"})
+	assert.Contains(t, unsafe.Changes, Change{Key: "logger.level", Old: "info", New: "debug"})
+	assert.Contains(t, unsafe.Changes, Change{Key: "calendar.feed_token", Old: redactedValue, New: redactedValue})
+	assert.Contains(t, err.Error(), "server.port, calendar.feed_token cannot change without a restart")
+
+	assert.Equal(t, 8080, s.Current().Server.Port)
+	assert.Equal(t, "info", s.Current().Logger.Level)
+}
+
+func TestStore_ReloadKeepsConfigOnError(t *testing.T) {
+	s, path := newTestStore(t, "logger:\n  level: warn\n", nil)
+
+	require.NoError(t, os.WriteFile(path, []byte("logger:\n  levle: debug\n"), 0o600))
+	_, err := s.Reload()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown key "logger.levle"`)
+	assert.Equal(t, "warn", s.Current().Logger.Level)
+}
+
+func TestStore_ReloadWithoutChanges(t *testing.T) {
+	s, _ := newTestStore(t, "logger:\n  level: warn\n", nil)
+	before := s.Current()
+
+	result, err := s.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, result.Changes)
+	assert.Same(t, before, s.Current())
+}
+
+func TestStore_WatchReloadsOnFileChange(t *testing.T) {
+	s, path := newTestStore(t, "logger:\n  level: info\n", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan error, 1)
+	go s.Watch(ctx, 10*time.Millisecond, func(_ *ReloadResult, err error) {
+		results <- err
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte("logger:\n  level: error\n"), 0o600))
+	select {
+	case err := <-results:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration file change was not detected")
+	}
+	assert.Equal(t, "error", s.Current().Logger.Level)
+}
+
+func TestReloadable(t *testing.T) {
+	for _, key := range []string{
+		"logger.level",
+		"database.password",
+		"server.cors.allowed_origins",
+		"server.rate_limit.default.requests_per_second",
+		"server.rate_limit.procedures",
+		"server.request_timeouts.procedures",
+	} {
+		assert.True(t, reloadable(key), key)
+	}
+	for _, key := range []string{
+		"logger.format",
+		"server.port",
+		"server.rate_limit.enabled",
+		"server.read_timeout",
+		"database.host",
+		"calendar.feed_token",
+	} {
+		assert.False(t, reloadable(key), key)
+	}
+}
+
+func TestStore_WatchRotatesSecretFile(t *testing.T) {
+	passwordFile := writeConfigFile(t, "db-password", "first-password")
+	env := map[string]string{"DB_PASSWORD_FILE": passwordFile}
+	resolved, err := resolve("", envMap(env))
+	require.NoError(t, err)
+	s := NewStore(resolved)
+	s.lookupEnv = envMap(env)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan *ReloadResult, 1)
+	go s.Watch(ctx, 10*time.Millisecond, func(result *ReloadResult, err error) {
+		assert.NoError(t, err)
+		results <- result
+	})
+
+	require.NoError(t, os.WriteFile(passwordFile, []byte("second-password\n"), 0o600))
+	select {
+	case result := <-results:
+		require.NotNil(t, result)
+		assert.Equal(t, []Change{{Key: "database.password", Old: redactedValue, New: redactedValue}}, result.Changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("secret file change was not detected")
+	}
+	assert.Equal(t, Secret("second-password"), s.Current().Database.Password)
+}
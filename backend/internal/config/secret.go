@@ -0,0 +1,45 @@
+package config
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// Secret is a configuration value such as a password that must not appear
+// in logs or marshaled configuration. Printing, logging or marshaling it
+// yields "[redacted]"; use Reveal to get the value itself.
+type Secret string
+
+// Reveal returns the secret value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer, redacting the value
+func (s Secret) String() string {
+	return s.redacted()
+}
+
+// GoString implements fmt.GoStringer, redacting the value for %#v
+func (s Secret) GoString() string {
+	return "config.Secret(" + `"` + s.redacted() + `"` + ")"
+}
+
+// MarshalJSON implements json.Marshaler, redacting the value
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.redacted())
+}
+
+// LogValue implements slog.LogValuer, redacting the value
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.redacted())
+}
+
+// redacted returns the placeholder for a set secret, or an empty string so
+// that unset secrets stay recognizable
+func (s Secret) redacted() string {
+	if s == "" {
+		return ""
+	}
+	return redactedValue
+}
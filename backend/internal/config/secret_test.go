@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret_Redacted(t *testing.T) {
+	cfg := &Config{
+		Database: DatabaseConfig{User: "todoapp", Password: "hunter2"},
+		Calendar: CalendarConfig{FeedToken: "0123456789abcdef"},
+	}
+
+	for name, out := range map[string]string{
+		"%v":  fmt.Sprintf("%v", cfg),
+		"%+v": fmt.Sprintf("%+v", cfg),
+		"%#v": fmt.Sprintf("%#v", cfg),
+		"%s":  fmt.Sprintf("%s", cfg.Database.Password),
+		"%q":  fmt.Sprintf("%q", cfg.Database.Password),
+	} {
+		assert.NotContains(t, out, "hunter2", name)
+		assert.NotContains(t, out, "0123456789abcdef", name)
+	}
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	assert.Contains(t, string(data), `"password":"[redacted]"`)
+	assert.Contains(t, string(data), `"feed_token":"[redacted]"`)
+
+	for name, handler := range map[string]func(*bytes.Buffer) slog.Handler{
+		"json": func(b *bytes.Buffer) slog.Handler { return slog.NewJSONHandler(b, nil) },
+		"text": func(b *bytes.Buffer) slog.Handler { return slog.NewTextHandler(b, nil) },
+	} {
+		var buf bytes.Buffer
+		slog.New(handler(&buf)).Info("config", "config", cfg, "password", cfg.Database.Password)
+		assert.NotContains(t, buf.String(), "hunter2", name)
+		assert.Contains(t, buf.String(), "[redacted]", name)
+	}
+
+	assert.Equal(t, "hunter2", cfg.Database.Password.Reveal())
+	assert.Contains(t, cfg.Database.DSN(), ":hunter2@")
+	assert.Empty(t, Secret("").String())
+}
@@ -0,0 +1,474 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv names the environment variable that points Load at a
+// configuration file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// redactedValue replaces secrets in printed configuration
+const redactedValue = "[redacted]"
+
+// Suffixes that name a file holding a secret instead of the secret itself
+const (
+	fileEnvSuffix = "_FILE"
+	fileKeySuffix = "_file"
+)
+
+// SourceKind says which layer a configuration value came from
+type SourceKind string
+
+const (
+	// SourceDefault is the built-in default
+	SourceDefault SourceKind = "default"
+	// SourceFile is the configuration file
+	SourceFile SourceKind = "file"
+	// SourceEnv is an environment variable
+	SourceEnv SourceKind = "env"
+)
+
+// Source locates where a configuration value was set
+type Source struct {
+	Kind SourceKind
+	// Name is the file path or environment variable; empty for defaults
+	Name string
+	// SecretFile is the file a secret was read from when it was set with a
+	// _FILE variable or a _file key
+	SecretFile string
+}
+
+// String returns the source in the form printed by config print
+func (s Source) String() string {
+	out := string(s.Kind)
+	if s.Name != "" {
+		out += " " + s.Name
+	}
+	if s.SecretFile != "" {
+		out += " (" + s.SecretFile + ")"
+	}
+	return out
+}
+
+// Value is one resolved configuration setting
+type Value struct {
+	// Key is the dotted path of the setting in configuration files, such as
+	// "server.port"
+	Key string
+	// Env is the environment variable that overrides the setting
+	Env    string
+	Value  string
+	Secret bool
+	Source Source
+}
+
+// Resolved is a loaded configuration together with where each value came
+// from
+type Resolved struct {
+	Config *Config
+	// File is the configuration file that was read, if any
+	File   string
+	Values []Value
+	// Files lists every file the configuration was read from: the
+	// configuration file and any secret files
+	Files []string
+}
+
+// Write prints every setting with its value and source. Secrets are
+// replaced when redacted is set.
+func (r *Resolved) Write(w io.Writer, redacted bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, v := range r.Values {
+		value := v.Value
+		if redacted && v.Secret && value != "" {
+			value = redactedValue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Key, strconv.Quote(value), v.Source)
+	}
+	return tw.Flush()
+}
+
+// setting describes one configuration value: its file key, environment
+// variable, default and how to store a parsed value. Secrets can also be
+// read from a file named by the environment variable with a _FILE suffix or
+// the file key with a _file suffix, as Kubernetes secret mounts provide.
+type setting struct {
+	key    string
+	env    string
+	def    string
+	secret bool
+	set    func(c *Config, value string) error
+}
+
+// settings lists every configuration value. Keys follow the JSON field
+// names of Config. Order matters where one setting's parser reads another:
+// database.read_replicas defaults to database.port.
+var settings = []setting{
+	{key: "server.port", env: "SERVER_PORT", def: "8080", set: intField(func(c *Config) *int { return &c.Server.Port })},
+	{key: "server.read_timeout", env: "SERVER_READ_TIMEOUT", def: "30s", set: durationField(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{key: "server.write_timeout", env: "SERVER_WRITE_TIMEOUT", def: "30s", set: durationField(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{key: "server.idle_timeout", env: "SERVER_IDLE_TIMEOUT", def: "60s", set: durationField(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{key: "server.shutdown_timeout", env: "SERVER_SHUTDOWN_TIMEOUT", def: "15s", set: durationField(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{key: "server.cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS", def: "*", set: listField(func(c *Config) *[]string { return &c.Server.CORS.AllowedOrigins })},
+	{key: "server.cors.allowed_methods", env: "CORS_ALLOWED_METHODS", def: "GET,POST,PUT,PATCH,DELETE,OPTIONS", set: listField(func(c *Config) *[]string { return &c.Server.CORS.AllowedMethods })},
+	{key: "server.cors.allowed_headers", env: "CORS_ALLOWED_HEADERS", def: "Content-Type,Connect-Protocol-Version,Connect-Timeout-Ms,Grpc-Timeout,X-Grpc-Web,X-User-Agent,Idempotency-Key", set: listField(func(c *Config) *[]string { return &c.Server.CORS.AllowedHeaders })},
+	{key: "server.cors.exposed_headers", env: "CORS_EXPOSED_HEADERS", def: "Grpc-Status,Grpc-Message,Grpc-Status-Details-Bin,Retry-After,X-Request-ID,Location", set: listField(func(c *Config) *[]string { return &c.Server.CORS.ExposedHeaders })},
+	{key: "server.cors.allow_credentials", env: "CORS_ALLOW_CREDENTIALS", def: "false", set: boolField(func(c *Config) *bool { return &c.Server.CORS.AllowCredentials })},
+	{key: "server.cors.max_age", env: "CORS_MAX_AGE", def: "2h", set: durationField(func(c *Config) *time.Duration { return &c.Server.CORS.MaxAge })},
+	{key: "server.rate_limit.enabled", env: "RATE_LIMIT_ENABLED", def: "false", set: boolField(func(c *Config) *bool { return &c.Server.RateLimit.Enabled })},
+	{key: "server.rate_limit.default.requests_per_second", env: "RATE_LIMIT_RPS", def: "10", set: floatField(func(c *Config) *float64 { return &c.Server.RateLimit.Default.RequestsPerSecond })},
+	{key: "server.rate_limit.default.burst", env: "RATE_LIMIT_BURST", def: "20", set: intField(func(c *Config) *int { return &c.Server.RateLimit.Default.Burst })},
+	{key: "server.rate_limit.trusted_proxies", env: "RATE_LIMIT_TRUSTED_PROXIES", set: listField(func(c *Config) *[]string { return &c.Server.RateLimit.TrustedProxies })},
+	{key: "server.rate_limit.procedures", env: "RATE_LIMIT_PROCEDURES", set: func(c *Config, value string) (err error) {
+		c.Server.RateLimit.Procedures, err = ParseRateLimits(value)
+		return err
+	}},
+	{key: "server.request_timeouts.default", env: "REQUEST_TIMEOUT", def: "30s", set: durationField(func(c *Config) *time.Duration { return &c.Server.RequestTimeouts.Default })},
+	{key: "server.request_timeouts.procedures", env: "REQUEST_TIMEOUT_PROCEDURES", set: func(c *Config, value string) (err error) {
+		c.Server.RequestTimeouts.Procedures, err = ParseProcedureTimeouts(value)
+		return err
+	}},
+	{key: "server.tls.cert_file", env: "TLS_CERT_FILE", set: stringField(func(c *Config) *string { return &c.Server.TLS.CertFile })},
+	{key: "server.tls.key_file", env: "TLS_KEY_FILE", set: stringField(func(c *Config) *string { return &c.Server.TLS.KeyFile })},
+	{key: "server.tls.client_ca_file", env: "TLS_CLIENT_CA_FILE", set: stringField(func(c *Config) *string { return &c.Server.TLS.ClientCAFile })},
+	{key: "server.tls.client_auth", env: "TLS_CLIENT_AUTH", set: stringField(func(c *Config) *string { return &c.Server.TLS.ClientAuth })},
+	{key: "server.legacy_delete_responses", env: "LEGACY_DELETE_RESPONSES", def: "false", set: boolField(func(c *Config) *bool { return &c.Server.LegacyDeleteResponses })},
+
+	{key: "logger.level", env: "LOG_LEVEL", def: "info", set: stringField(func(c *Config) *string { return &c.Logger.Level })},
+	{key: "logger.format", env: "LOG_FORMAT", def: "json", set: stringField(func(c *Config) *string { return &c.Logger.Format })},
+
+	{key: "database.host", env: "DB_HOST", def: "todo-mariadb.todo-app.svc.cluster.local", set: stringField(func(c *Config) *string { return &c.Database.Host })},
+	{key: "database.port", env: "DB_PORT", def: "3306", set: intField(func(c *Config) *int { return &c.Database.Port })},
+	{key: "database.user", env: "DB_USER", def: "todoapp", set: stringField(func(c *Config) *string { return &c.Database.User })},
+	{key: "database.password", env: "DB_PASSWORD", def: DefaultDatabasePassword, secret: true, set: secretField(func(c *Config) *Secret { return &c.Database.Password })},
+	{key: "database.database", env: "DB_NAME", def: "todoapp", set: stringField(func(c *Config) *string { return &c.Database.Database })},
+	{key: "database.max_open_conns", env: "DB_MAX_OPEN_CONNS", def: "25", set: intField(func(c *Config) *int { return &c.Database.MaxOpenConns })},
+	{key: "database.max_idle_conns", env: "DB_MAX_IDLE_CONNS", def: "10", set: intField(func(c *Config) *int { return &c.Database.MaxIdleConns })},
+	{key: "database.conn_max_lifetime", env: "DB_CONN_MAX_LIFETIME", def: "5m", set: durationField(func(c *Config) *time.Duration { return &c.Database.ConnMaxLifetime })},
+	{key: "database.conn_max_idle_time", env: "DB_CONN_MAX_IDLE_TIME", def: "5m", set: durationField(func(c *Config) *time.Duration { return &c.Database.ConnMaxIdleTime })},
+	{key: "database.ssl_mode", env: "DB_SSL_MODE", def: "false", set: stringField(func(c *Config) *string { return &c.Database.SSLMode })},
+	{key: "database.ssl_ca", env: "DB_SSL_CA", set: stringField(func(c *Config) *string { return &c.Database.SSLCA })},
+	{key: "database.ssl_cert", env: "DB_SSL_CERT", set: stringField(func(c *Config) *string { return &c.Database.SSLCert })},
+	{key: "database.ssl_key", env: "DB_SSL_KEY", set: stringField(func(c *Config) *string { return &c.Database.SSLKey })},
+	{key: "database.read_replicas", env: "DB_READ_REPLICAS", set: func(c *Config, value string) (err error) {
+		c.Database.ReadReplicas, err = ParseReplicas(value, c.Database.Port)
+		return err
+	}},
+	{key: "database.replica_health_interval", env: "DB_REPLICA_HEALTH_INTERVAL", def: "10s", set: durationField(func(c *Config) *time.Duration { return &c.Database.ReplicaHealthInterval })},
+	{key: "database.replica_health_timeout", env: "DB_REPLICA_HEALTH_TIMEOUT", def: "2s", set: durationField(func(c *Config) *time.Duration { return &c.Database.ReplicaHealthTimeout })},
+	{key: "database.migrate_on_startup", env: "MIGRATE_ON_STARTUP", def: MigrateAuto, set: stringField(func(c *Config) *string { return &c.Database.MigrateOnStartup })},
+	{key: "database.migrate_lock_timeout", env: "MIGRATE_LOCK_TIMEOUT", def: "5m", set: durationField(func(c *Config) *time.Duration { return &c.Database.MigrateLockTimeout })},
+	{key: "database.migrations_dir", env: "DB_MIGRATIONS_DIR", set: stringField(func(c *Config) *string { return &c.Database.MigrationsDir })},
+
+	{key: "cache.enabled", env: "CACHE_ENABLED", def: "false", set: boolField(func(c *Config) *bool { return &c.Cache.Enabled })},
+	{key: "cache.capacity", env: "CACHE_CAPACITY", def: "1000", set: intField(func(c *Config) *int { return &c.Cache.Capacity })},
+	{key: "cache.ttl", env: "CACHE_TTL", def: "30s", set: durationField(func(c *Config) *time.Duration { return &c.Cache.TTL })},
+
+	{key: "tracing.exporter", env: "TRACING_EXPORTER", def: "none", set: stringField(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{key: "tracing.otlp_endpoint", env: "OTEL_EXPORTER_OTLP_ENDPOINT", set: stringField(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+	{key: "tracing.service_name", env: "OTEL_SERVICE_NAME", def: "todo-backend", set: stringField(func(c *Config) *string { return &c.Tracing.ServiceName })},
+	{key: "tracing.sample_ratio", env: "TRACING_SAMPLE_RATIO", def: "1.0", set: floatField(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+
+	{key: "health.check_timeout", env: "HEALTH_CHECK_TIMEOUT", def: "2s", set: durationField(func(c *Config) *time.Duration { return &c.Health.CheckTimeout })},
+	{key: "health.pool_saturation_threshold", env: "HEALTH_POOL_SATURATION_THRESHOLD", def: "0.9", set: floatField(func(c *Config) *float64 { return &c.Health.PoolSaturationThreshold })},
+	{key: "health.shutdown_delay", env: "HEALTH_SHUTDOWN_DELAY", def: "5s", set: durationField(func(c *Config) *time.Duration { return &c.Health.ShutdownDelay })},
+
+	{key: "calendar.feed_token", env: "CALENDAR_FEED_TOKEN", secret: true, set: secretField(func(c *Config) *Secret { return &c.Calendar.FeedToken })},
+
+	{key: "idempotency.ttl", env: "IDEMPOTENCY_TTL", def: "24h", set: durationField(func(c *Config) *time.Duration { return &c.Idempotency.TTL })},
+	{key: "idempotency.purge_interval", env: "IDEMPOTENCY_PURGE_INTERVAL", def: "1h", set: durationField(func(c *Config) *time.Duration { return &c.Idempotency.PurgeInterval })},
+	{key: "idempotency.lease", env: "IDEMPOTENCY_LEASE", def: "1m", set: durationField(func(c *Config) *time.Duration { return &c.Idempotency.Lease })},
+}
+
+// Resolve loads the configuration from defaults, then the file at path when
+// it is not empty, then environment variables, and validates the result
+func Resolve(path string) (*Resolved, error) {
+	return resolve(path, os.LookupEnv)
+}
+
+// resolve implements Resolve with lookupEnv standing in for the environment
+func resolve(path string, lookupEnv func(string) (string, bool)) (*Resolved, error) {
+	var fileValues map[string]string
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if fileValues, err = parseFile(data); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	resolved := &Resolved{Config: &Config{}, File: path}
+	if path != "" {
+		resolved.Files = append(resolved.Files, path)
+	}
+	for _, s := range settings {
+		value, source := s.def, Source{Kind: SourceDefault}
+		if v, ok := fileValues[s.key]; ok {
+			value, source = v, Source{Kind: SourceFile, Name: path}
+		}
+		if secretPath, ok := fileValues[s.key+fileKeySuffix]; ok && s.secret {
+			if _, ok := fileValues[s.key]; ok {
+				return nil, fmt.Errorf("invalid config file %s: set only one of %s and %s", path, s.key, s.key+fileKeySuffix)
+			}
+			v, err := readSecretFile(secretPath)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s in %s: %w", s.key+fileKeySuffix, path, err)
+			}
+			value, source = v, Source{Kind: SourceFile, Name: path, SecretFile: secretPath}
+			resolved.Files = append(resolved.Files, secretPath)
+		}
+
+		envValue, hasEnv := lookupEnv(s.env)
+		if hasEnv {
+			value, source = envValue, Source{Kind: SourceEnv, Name: s.env}
+		}
+		if secretPath, ok := lookupEnv(s.env + fileEnvSuffix); ok && s.secret {
+			if hasEnv {
+				return nil, fmt.Errorf("set only one of %s and %s", s.env, s.env+fileEnvSuffix)
+			}
+			v, err := readSecretFile(secretPath)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", s.env+fileEnvSuffix, err)
+			}
+			value, source = v, Source{Kind: SourceEnv, Name: s.env + fileEnvSuffix, SecretFile: secretPath}
+			resolved.Files = append(resolved.Files, secretPath)
+		}
+
+		if err := s.set(resolved.Config, value); err != nil {
+			if source.Kind == SourceFile {
+				return nil, fmt.Errorf("invalid %s in %s: %w", s.key, path, err)
+			}
+			return nil, fmt.Errorf("invalid %s: %w", s.env, err)
+		}
+		resolved.Values = append(resolved.Values, Value{
+			Key:    s.key,
+			Env:    s.env,
+			Value:  value,
+			Secret: s.secret,
+			Source: source,
+		})
+	}
+
+	if err := resolved.Config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return resolved, nil
+}
+
+// parseFile reads a YAML or JSON configuration file into setting values
+// keyed like settings. Unknown keys are rejected so that typos do not go
+// unnoticed.
+func parseFile(data []byte) (map[string]string, error) {
+	var doc yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&doc); err != nil {
+		if err == io.EOF {
+			// An empty file sets nothing
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return map[string]string{}, nil
+	}
+
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.key] = true
+		if s.secret {
+			known[s.key+fileKeySuffix] = true
+		}
+	}
+
+	values := make(map[string]string)
+	if err := collectValues(doc.Content[0], "", known, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// collectValues walks a mapping node, storing the value of every known
+// setting under its dotted key
+func collectValues(node *yaml.Node, prefix string, known map[string]bool, values map[string]string) error {
+	if node.Kind != yaml.MappingNode {
+		if prefix == "" {
+			return fmt.Errorf("line %d: expected a mapping at the top level", node.Line)
+		}
+		return fmt.Errorf("line %d: %s must be a mapping", node.Line, prefix)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if known[key] {
+			if _, ok := values[key]; ok {
+				return fmt.Errorf("line %d: %s is set more than once", keyNode.Line, key)
+			}
+			value, err := nodeValue(valueNode)
+			if err != nil {
+				return fmt.Errorf("line %d: %s: %w", valueNode.Line, key, err)
+			}
+			values[key] = value
+			continue
+		}
+		if !hasSettingUnder(known, key) {
+			return fmt.Errorf("line %d: unknown key %q", keyNode.Line, key)
+		}
+		if err := collectValues(valueNode, key, known, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasSettingUnder reports whether any known setting is nested below prefix
+func hasSettingUnder(known map[string]bool, prefix string) bool {
+	for key := range known {
+		if strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeValue converts a setting's node to the string form its parser takes.
+// Lists become comma-separated values and mappings comma-separated
+// key=value entries, matching the environment variable syntax.
+func nodeValue(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "", fmt.Errorf("missing value")
+		}
+		return node.Value, nil
+	case yaml.SequenceNode:
+		items := make([]string, len(node.Content))
+		for i, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return "", fmt.Errorf("list items must be plain values")
+			}
+			items[i] = item.Value
+		}
+		return strings.Join(items, ","), nil
+	case yaml.MappingNode:
+		entries := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind != yaml.ScalarNode {
+				return "", fmt.Errorf("%s must be a plain value", key.Value)
+			}
+			entries = append(entries, key.Value+"="+value.Value)
+		}
+		return strings.Join(entries, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value")
+	}
+}
+
+// readSecretFile reads a secret from path, dropping the trailing newline
+// that editors and "echo" add
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return secret, nil
+}
+
+// field returns a setter that parses a value with parse and stores it in
+// the field selected by ptr
+func field[T any](parse func(string) (T, error), ptr func(c *Config) *T) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		parsed, err := parse(value)
+		if err != nil {
+			return err
+		}
+		*ptr(c) = parsed
+		return nil
+	}
+}
+
+func stringField(ptr func(c *Config) *string) func(c *Config, value string) error {
+	return field(func(s string) (string, error) { return s, nil }, ptr)
+}
+
+func intField(ptr func(c *Config) *int) func(c *Config, value string) error {
+	return field(func(s string) (int, error) {
+		value, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an integer", s)
+		}
+		return value, nil
+	}, ptr)
+}
+
+func boolField(ptr func(c *Config) *bool) func(c *Config, value string) error {
+	return field(func(s string) (bool, error) {
+		value, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return false, fmt.Errorf("%q is not a boolean", s)
+		}
+		return value, nil
+	}, ptr)
+}
+
+func floatField(ptr func(c *Config) *float64) func(c *Config, value string) error {
+	return field(func(s string) (float64, error) {
+		value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", s)
+		}
+		return value, nil
+	}, ptr)
+}
+
+func durationField(ptr func(c *Config) *time.Duration) func(c *Config, value string) error {
+	return field(func(s string) (time.Duration, error) {
+		value, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a duration such as 30s or 5m", s)
+		}
+		return value, nil
+	}, ptr)
+}
+
+func secretField(ptr func(c *Config) *Secret) func(c *Config, value string) error {
+	return field(func(s string) (Secret, error) { return Secret(s), nil }, ptr)
+}
+
+func listField(ptr func(c *Config) *[]string) func(c *Config, value string) error {
+	return field(func(s string) ([]string, error) { return splitList(s), nil }, ptr)
+}
+
+// splitList splits a comma-separated list, trimming whitespace and dropping
+// empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
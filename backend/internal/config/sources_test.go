@@ -0,0 +1,289 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFile writes content to a file named name in a temporary
+// directory and returns its path
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// envMap returns a lookupEnv function backed by env
+func envMap(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+// valueFor returns the resolved value with the given key
+func valueFor(t *testing.T, r *Resolved, key string) Value {
+	t.Helper()
+	for _, v := range r.Values {
+		if v.Key == key {
+			return v
+		}
+	}
+	t.Fatalf("no value for %s", key)
+	return Value{}
+}
+
+func TestResolve_YAMLFile(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  port: 9090
+  read_timeout: 45s
+  cors:
+    allowed_origins:
+      - https://app.example.com
+      - https://admin.example.com
+  rate_limit:
+    enabled: true
+    procedures:
+      /task.v1.TaskService/CreateTask: 5:10
+logger:
+  level: debug
+database:
+  port: 3307
+  read_replicas: replica-1, replica-2:3308
+cache:
+  enabled: true
+  ttl: 1m
+`)
+
+	r, err := resolve(path, envMap(nil))
+	require.NoError(t, err)
+
+	cfg := r.Config
+	assert.Equal(t, 9090, cfg.Server.Port)
+	assert.Equal(t, 45*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 30*time.Second, cfg.Server.WriteTimeout)
+	assert.Equal(t, []string{"https://app.example.com", "https://admin.example.com"}, cfg.Server.CORS.AllowedOrigins)
+	assert.True(t, cfg.Server.RateLimit.Enabled)
+	assert.Equal(t, RateLimit{RequestsPerSecond: 5, Burst: 10}, cfg.Server.RateLimit.Procedures["/task.v1.TaskService/CreateTask"])
+	assert.Equal(t, "debug", cfg.Logger.Level)
+	assert.Equal(t, []ReplicaConfig{{Host: "replica-1", Port: 3307}, {Host: "replica-2", Port: 3308}}, cfg.Database.ReadReplicas)
+	assert.True(t, cfg.Cache.Enabled)
+	assert.Equal(t, time.Minute, cfg.Cache.TTL)
+
+	assert.Equal(t, Source{Kind: SourceFile, Name: path}, valueFor(t, r, "server.port").Source)
+	assert.Equal(t, Source{Kind: SourceDefault}, valueFor(t, r, "server.write_timeout").Source)
+}
+
+func TestResolve_JSONFile(t *testing.T) {
+	path := writeConfigFile(t, "config.json", `{
+  "server": {"port": 9191, "cors": {"allowed_methods": ["GET", "POST"]}},
+  "tracing": {"sample_ratio": 0.25}
+}`)
+
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9191, cfg.Server.Port)
+	assert.Equal(t, []string{"GET", "POST"}, cfg.Server.CORS.AllowedMethods)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+}
+
+func TestResolve_EnvOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "server:\n  port: 9090\nlogger:\n  level: debug\n")
+
+	r, err := resolve(path, envMap(map[string]string{"SERVER_PORT": "7070"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, 7070, r.Config.Server.Port)
+	assert.Equal(t, "debug", r.Config.Logger.Level)
+	assert.Equal(t, Source{Kind: SourceEnv, Name: "SERVER_PORT"}, valueFor(t, r, "server.port").Source)
+	assert.Equal(t, Source{Kind: SourceFile, Name: path}, valueFor(t, r, "logger.level").Source)
+}
+
+func TestLoad_ConfigFileEnv(t *testing.T) {
+	clearEnvVars()
+	defer clearEnvVars()
+	path := writeConfigFile(t, "config.yaml", "server:\n  port: 9292\n")
+	setEnvVars(map[string]string{"CONFIG_FILE": path})
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 9292, cfg.Server.Port)
+}
+
+func TestResolve_MalformedEnvValues(t *testing.T) {
+	tests := []struct {
+		env   string
+		value string
+	}{
+		{"SERVER_PORT", "80a"},
+		{"SERVER_READ_TIMEOUT", "30"},
+		{"RATE_LIMIT_ENABLED", "yes please"},
+		{"RATE_LIMIT_RPS", "ten"},
+		{"CACHE_CAPACITY", "1k"},
+		{"HEALTH_POOL_SATURATION_THRESHOLD", "90%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			_, err := resolve("", envMap(map[string]string{tt.env: tt.value}))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid "+tt.env)
+			assert.Contains(t, err.Error(), tt.value)
+		})
+	}
+}
+
+func TestResolve_MalformedFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown top-level key", "sever:\n  port: 80\n", `unknown key "sever"`},
+		{"unknown nested key", "server:\n  prot: 80\n", `unknown key "server.prot"`},
+		{"bad integer", "server:\n  port: eighty\n", "invalid server.port"},
+		{"bad duration", "cache:\n  ttl: 30\n", "invalid cache.ttl"},
+		{"missing value", "logger:\n  level:\n", "missing value"},
+		{"section is not a mapping", "server: 80\n", "server must be a mapping"},
+		{"not a mapping", "- port\n", "expected a mapping"},
+		{"syntax error", "server: [\n", "invalid config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, "config.yaml", tt.content)
+			_, err := resolve(path, envMap(nil))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestResolve_MissingFile(t *testing.T) {
+	_, err := resolve(filepath.Join(t.TempDir(), "missing.yaml"), envMap(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read config file")
+}
+
+func TestResolve_EmptyFile(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "")
+
+	r, err := resolve(path, envMap(nil))
+	require.NoError(t, err)
+	assert.Equal(t, 8080, r.Config.Server.Port)
+}
+
+func TestResolve_SplitsLists(t *testing.T) {
+	r, err := resolve("", envMap(map[string]string{
+		"CORS_ALLOWED_ORIGINS": "https://a.example.com, https://b.example.com,,",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, r.Config.Server.CORS.AllowedOrigins)
+}
+
+func TestResolved_Write(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "calendar:\n  feed_token: s3cret-calendar-token\n")
+	r, err := resolve(path, envMap(map[string]string{"DB_PASSWORD": "hunter2", "LOG_LEVEL": "warn"}))
+	require.NoError(t, err)
+
+	var redacted strings.Builder
+	require.NoError(t, r.Write(&redacted, true))
+	out := redacted.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "s3cret-calendar-token")
+	assert.Regexp(t, `database\.password\s+"\[redacted\]"\s+env DB_PASSWORD`, out)
+	assert.Regexp(t, `calendar\.feed_token\s+"\[redacted\]"\s+file `+regexp.QuoteMeta(path), out)
+	assert.Regexp(t, `logger\.level\s+"warn"\s+env LOG_LEVEL`, out)
+	assert.Regexp(t, `server\.port\s+"8080"\s+default`, out)
+
+	var plain strings.Builder
+	require.NoError(t, r.Write(&plain, false))
+	assert.Contains(t, plain.String(), `"hunter2"`)
+}
+
+func TestLoadFile_ShippedConfigs(t *testing.T) {
+	paths, err := filepath.Glob("../../configs/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			_, err := resolve(path, envMap(nil))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestResolve_SecretFiles(t *testing.T) {
+	passwordFile := writeConfigFile(t, "db-password", "from-env-file\n")
+	tokenFile := writeConfigFile(t, "feed-token", "0123456789abcdef")
+	path := writeConfigFile(t, "config.yaml", "calendar:\n  feed_token_file: "+tokenFile+"\n")
+
+	r, err := resolve(path, envMap(map[string]string{"DB_PASSWORD_FILE": passwordFile}))
+	require.NoError(t, err)
+
+	assert.Equal(t, Secret("from-env-file"), r.Config.Database.Password)
+	assert.Equal(t, Secret("0123456789abcdef"), r.Config.Calendar.FeedToken)
+	assert.Equal(t, Source{Kind: SourceEnv, Name: "DB_PASSWORD_FILE", SecretFile: passwordFile}, valueFor(t, r, "database.password").Source)
+	assert.Equal(t, Source{Kind: SourceFile, Name: path, SecretFile: tokenFile}, valueFor(t, r, "calendar.feed_token").Source)
+	assert.ElementsMatch(t, []string{path, passwordFile, tokenFile}, r.Files)
+
+	var out strings.Builder
+	require.NoError(t, r.Write(&out, true))
+	assert.Regexp(t, `database\.password\s+"\[redacted\]"\s+env DB_PASSWORD_FILE \(`+regexp.QuoteMeta(passwordFile)+`\)`, out.String())
+}
+
+func TestResolve_SecretFileErrors(t *testing.T) {
+	passwordFile := writeConfigFile(t, "db-password", "secret")
+	emptyFile := writeConfigFile(t, "empty", "\n")
+
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		wantErr string
+	}{
+		{"env and env file", "", map[string]string{"DB_PASSWORD": "a", "DB_PASSWORD_FILE": passwordFile}, "set only one of DB_PASSWORD and DB_PASSWORD_FILE"},
+		{"missing env file", "", map[string]string{"DB_PASSWORD_FILE": passwordFile + ".missing"}, "invalid DB_PASSWORD_FILE"},
+		{"empty env file", "", map[string]string{"DB_PASSWORD_FILE": emptyFile}, "is empty"},
+		{"key and key file", "database:\n  password: a\n  password_file: " + passwordFile + "\n", nil, "set only one of database.password and database.password_file"},
+		{"file key on a non-secret", "database:\n  host_file: " + passwordFile + "\n", nil, `unknown key "database.host_file"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			if tt.content != "" {
+				path = writeConfigFile(t, "config.yaml", tt.content)
+			}
+			_, err := resolve(path, envMap(tt.env))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate_DefaultPasswordInProduction(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+
+	_, err := resolve("", envMap(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "default database password is not allowed in production")
+
+	_, err = resolve("", envMap(map[string]string{"DB_PASSWORD": "a-real-password"}))
+	assert.NoError(t, err)
+
+	t.Setenv("ENVIRONMENT", "development")
+	_, err = resolve("", envMap(nil))
+	assert.NoError(t, err)
+}
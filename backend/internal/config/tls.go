@@ -0,0 +1,130 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// Client certificate modes for TLSConfig.ClientAuth
+const (
+	// ClientAuthNone does not ask clients for a certificate
+	ClientAuthNone = "none"
+	// ClientAuthOptional verifies a client certificate when one is sent
+	ClientAuthOptional = "optional"
+	// ClientAuthRequire rejects clients without a valid certificate
+	ClientAuthRequire = "require"
+)
+
+// Database TLS modes for DatabaseConfig.SSLMode. Except for SSLModeCustom
+// they map directly to the tls parameter of the MySQL driver.
+const (
+	// SSLModeDisabled connects without TLS
+	SSLModeDisabled = "false"
+	// SSLModeVerify requires TLS verified against the system roots
+	SSLModeVerify = "true"
+	// SSLModeSkipVerify requires TLS without verifying the server
+	SSLModeSkipVerify = "skip-verify"
+	// SSLModePreferred uses TLS when the server offers it, unverified
+	SSLModePreferred = "preferred"
+	// SSLModeCustom requires TLS verified against SSLCA, optionally with
+	// the client certificate in SSLCert and SSLKey
+	SSLModeCustom = "custom"
+)
+
+// TLSConfig enables TLS on the server listener. TLS is off when CertFile is
+// empty. Certificate files are re-read when they change.
+type TLSConfig struct {
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// ClientCAFile holds the CAs that sign client certificates for mTLS
+	ClientCAFile string `json:"client_ca_file"`
+	// ClientAuth is one of the ClientAuth* modes; empty means
+	// ClientAuthRequire when ClientCAFile is set and ClientAuthNone otherwise
+	ClientAuth string `json:"client_auth"`
+}
+
+// Enabled reports whether the server serves TLS
+func (t *TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// ClientAuthMode returns ClientAuth with the default applied
+func (t *TLSConfig) ClientAuthMode() string {
+	if t.ClientAuth != "" {
+		return t.ClientAuth
+	}
+	if t.ClientCAFile != "" {
+		return ClientAuthRequire
+	}
+	return ClientAuthNone
+}
+
+// validate checks that the TLS settings are complete and consistent
+func (t *TLSConfig) validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("TLS needs both a certificate and a key file")
+	}
+	switch t.ClientAuth {
+	case "", ClientAuthNone, ClientAuthOptional, ClientAuthRequire:
+	default:
+		return fmt.Errorf("invalid TLS client auth %q (must be %s, %s or %s)", t.ClientAuth, ClientAuthNone, ClientAuthOptional, ClientAuthRequire)
+	}
+	if t.ClientCAFile != "" && !t.Enabled() {
+		return fmt.Errorf("a TLS client CA file needs TLS to be enabled with a certificate and key file")
+	}
+	if t.ClientAuthMode() != ClientAuthNone && t.ClientCAFile == "" {
+		return fmt.Errorf("TLS client auth %q needs a client CA file", t.ClientAuth)
+	}
+	return nil
+}
+
+// validateTLS checks the database TLS settings
+func (d *DatabaseConfig) validateTLS() error {
+	switch d.SSLMode {
+	case "", SSLModeDisabled, SSLModeVerify, SSLModeSkipVerify, SSLModePreferred:
+		if d.SSLCA != "" || d.SSLCert != "" || d.SSLKey != "" {
+			return fmt.Errorf("database CA and client certificates need SSL mode %q", SSLModeCustom)
+		}
+	case SSLModeCustom:
+		if d.SSLCA == "" {
+			return fmt.Errorf("SSL mode %q needs a CA bundle", SSLModeCustom)
+		}
+		if (d.SSLCert == "") != (d.SSLKey == "") {
+			return fmt.Errorf("a database client certificate needs both a certificate and a key file")
+		}
+	default:
+		return fmt.Errorf("invalid SSL mode %q (must be %s, %s, %s, %s or %s)", d.SSLMode,
+			SSLModeDisabled, SSLModeVerify, SSLModeSkipVerify, SSLModePreferred, SSLModeCustom)
+	}
+	return nil
+}
+
+// TLSClientConfig returns the TLS configuration for SSLModeCustom, reading
+// the CA bundle and client certificate. It returns nil for other modes,
+// which the MySQL driver configures itself.
+func (d *DatabaseConfig) TLSClientConfig() (*tls.Config, error) {
+	if d.SSLMode != SSLModeCustom {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(d.SSLCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database CA bundle: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("database CA bundle %s contains no certificates", d.SSLCA)
+	}
+
+	tlsCfg := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	if d.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(d.SSLCert, d.SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load database client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
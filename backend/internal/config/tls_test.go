@@ -0,0 +1,118 @@
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/certs/certstest"
+)
+
+func TestResolve_TLS(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  tls:
+    cert_file: /etc/todo/tls.crt
+    key_file: /etc/todo/tls.key
+    client_ca_file: /etc/todo/clients.crt
+database:
+  ssl_mode: custom
+  ssl_ca: /etc/todo/db-ca.crt
+`)
+	r, err := resolve(path, envMap(map[string]string{"TLS_CLIENT_AUTH": "optional"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, TLSConfig{
+		CertFile:     "/etc/todo/tls.crt",
+		KeyFile:      "/etc/todo/tls.key",
+		ClientCAFile: "/etc/todo/clients.crt",
+		ClientAuth:   ClientAuthOptional,
+	}, r.Config.Server.TLS)
+	assert.True(t, r.Config.Server.TLS.Enabled())
+	assert.Equal(t, SSLModeCustom, r.Config.Database.SSLMode)
+	assert.Equal(t, "/etc/todo/db-ca.crt", r.Config.Database.SSLCA)
+}
+
+func TestTLSConfig_ClientAuthMode(t *testing.T) {
+	assert.Equal(t, ClientAuthNone, (&TLSConfig{}).ClientAuthMode())
+	assert.Equal(t, ClientAuthRequire, (&TLSConfig{ClientCAFile: "ca.crt"}).ClientAuthMode())
+	assert.Equal(t, ClientAuthOptional, (&TLSConfig{ClientCAFile: "ca.crt", ClientAuth: ClientAuthOptional}).ClientAuthMode())
+}
+
+func TestValidate_TLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"cert without key", map[string]string{"TLS_CERT_FILE": "tls.crt"}, "TLS needs both a certificate and a key file"},
+		{"key without cert", map[string]string{"TLS_KEY_FILE": "tls.key"}, "TLS needs both a certificate and a key file"},
+		{"client CA without TLS", map[string]string{"TLS_CLIENT_CA_FILE": "ca.crt"}, "needs TLS to be enabled"},
+		{"client auth without CA", map[string]string{"TLS_CERT_FILE": "tls.crt", "TLS_KEY_FILE": "tls.key", "TLS_CLIENT_AUTH": "require"}, `TLS client auth "require" needs a client CA file`},
+		{"unknown client auth", map[string]string{"TLS_CERT_FILE": "tls.crt", "TLS_KEY_FILE": "tls.key", "TLS_CLIENT_AUTH": "sometimes"}, `invalid TLS client auth "sometimes"`},
+		{"unknown SSL mode", map[string]string{"DB_SSL_MODE": "verify-full"}, `invalid SSL mode "verify-full"`},
+		{"custom without CA", map[string]string{"DB_SSL_MODE": "custom"}, `SSL mode "custom" needs a CA bundle`},
+		{"CA without custom", map[string]string{"DB_SSL_MODE": "true", "DB_SSL_CA": "ca.crt"}, `need SSL mode "custom"`},
+		{"client cert without key", map[string]string{"DB_SSL_MODE": "custom", "DB_SSL_CA": "ca.crt", "DB_SSL_CERT": "client.crt"}, "needs both a certificate and a key file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolve("", envMap(tt.env))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	for _, env := range []map[string]string{
+		{"TLS_CERT_FILE": "tls.crt", "TLS_KEY_FILE": "tls.key"},
+		{"TLS_CERT_FILE": "tls.crt", "TLS_KEY_FILE": "tls.key", "TLS_CLIENT_CA_FILE": "ca.crt"},
+		{"DB_SSL_MODE": "skip-verify"},
+		{"DB_SSL_MODE": "custom", "DB_SSL_CA": "ca.crt", "DB_SSL_CERT": "client.crt", "DB_SSL_KEY": "client.key"},
+	} {
+		_, err := resolve("", envMap(env))
+		assert.NoError(t, err, env)
+	}
+}
+
+func TestDatabaseConfig_DSNTLS(t *testing.T) {
+	d := DatabaseConfig{Host: "db", Port: 3306, User: "u", Password: "p", Database: "todo"}
+	for mode, want := range map[string]string{
+		"":                "",
+		SSLModeDisabled:   "",
+		SSLModeVerify:     "&tls=true",
+		SSLModeSkipVerify: "&tls=skip-verify",
+		SSLModePreferred:  "&tls=preferred",
+		SSLModeCustom:     "&tls=custom",
+	} {
+		d.SSLMode = mode
+		assert.Equal(t, "u:p@tcp(db:3306)/todo?charset=utf8mb4&parseTime=True&loc=Local"+want, d.DSN(), mode)
+	}
+}
+
+func TestDatabaseConfig_TLSClientConfig(t *testing.T) {
+	ca := certstest.NewCA(t, "database")
+	client := ca.Issue(t, certstest.Options{CommonName: "todoapp", Client: true})
+
+	tlsCfg, err := (&DatabaseConfig{SSLMode: SSLModeVerify}).TLSClientConfig()
+	require.NoError(t, err)
+	assert.Nil(t, tlsCfg, "built-in modes are configured by the driver")
+
+	tlsCfg, err = (&DatabaseConfig{SSLMode: SSLModeCustom, SSLCA: ca.CertFile}).TLSClientConfig()
+	require.NoError(t, err)
+	assert.True(t, tlsCfg.RootCAs.Equal(ca.Pool()))
+	assert.Empty(t, tlsCfg.Certificates)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsCfg.MinVersion)
+
+	tlsCfg, err = (&DatabaseConfig{SSLMode: SSLModeCustom, SSLCA: ca.CertFile, SSLCert: client.CertFile, SSLKey: client.KeyFile}).TLSClientConfig()
+	require.NoError(t, err)
+	assert.Len(t, tlsCfg.Certificates, 1)
+
+	_, err = (&DatabaseConfig{SSLMode: SSLModeCustom, SSLCA: client.KeyFile}).TLSClientConfig()
+	assert.ErrorContains(t, err, "contains no certificates")
+
+	_, err = (&DatabaseConfig{SSLMode: SSLModeCustom, SSLCA: ca.CertFile, SSLCert: client.CertFile, SSLKey: ca.CertFile}).TLSClientConfig()
+	assert.ErrorContains(t, err, "failed to load database client certificate")
+}
@@ -0,0 +1,201 @@
+// Package cors implements Cross-Origin Resource Sharing for the Connect,
+// gRPC-Web and REST endpoints, so browser clients on other origins can call
+// them
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+// policy is a CORSConfig prepared for matching requests
+type policy struct {
+	allowAll bool
+	// origins holds exact origins; wildcards holds "scheme://" and ".domain"
+	// pairs for patterns like https://*.example.com
+	origins   map[string]bool
+	wildcards []wildcard
+
+	allowMethods string
+	methods      map[string]bool
+	allowHeaders string
+	headers      map[string]bool
+	anyHeader    bool
+	exposeHeader string
+	credentials  bool
+	maxAge       string
+}
+
+// wildcard matches origins with any subdomain of a domain
+type wildcard struct {
+	prefix string
+	suffix string
+}
+
+// CORS answers preflight requests and adds CORS headers to responses for
+// allowed origins. Its configuration can be replaced while it serves.
+type CORS struct {
+	policy atomic.Pointer[policy]
+}
+
+// New returns a CORS component enforcing cfg
+func New(cfg *config.CORSConfig) *CORS {
+	c := &CORS{}
+	c.SetConfig(cfg)
+	return c
+}
+
+// SetConfig replaces the configuration for requests that start after it
+// returns
+func (c *CORS) SetConfig(cfg *config.CORSConfig) {
+	p := &policy{
+		origins:      make(map[string]bool),
+		allowMethods: strings.Join(cfg.AllowedMethods, ", "),
+		methods:      make(map[string]bool),
+		allowHeaders: strings.Join(cfg.AllowedHeaders, ", "),
+		headers:      make(map[string]bool),
+		exposeHeader: strings.Join(cfg.ExposedHeaders, ", "),
+		credentials:  cfg.AllowCredentials,
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimSuffix(strings.ToLower(origin), "/")
+		switch {
+		case origin == config.AllOrigins:
+			p.allowAll = true
+		case strings.Contains(origin, "://*."):
+			scheme, domain, _ := strings.Cut(origin, "*")
+			p.wildcards = append(p.wildcards, wildcard{prefix: scheme, suffix: domain})
+		default:
+			p.origins[origin] = true
+		}
+	}
+	for _, method := range cfg.AllowedMethods {
+		p.methods[strings.ToUpper(method)] = true
+	}
+	for _, header := range cfg.AllowedHeaders {
+		if header == "*" {
+			p.anyHeader = true
+		}
+		p.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+	c.policy.Store(p)
+}
+
+// Handler wraps next with CORS handling. Preflight requests are answered
+// here; other requests reach next, with CORS headers added when their
+// origin is allowed.
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := c.policy.Load()
+		origin := r.Header.Get("Origin")
+
+		// Responses differ by origin, so caches must key on it
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if origin != "" && p.allowsOrigin(origin) && p.allowsPreflight(r) {
+				p.writePreflight(w, r, origin)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if origin != "" && p.allowsOrigin(origin) {
+			p.writeOrigin(w, origin)
+			if p.exposeHeader != "" {
+				w.Header().Set("Access-Control-Expose-Headers", p.exposeHeader)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowsOrigin reports whether origin matches the allowlist
+func (p *policy) allowsOrigin(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if len(origin) <= len(w.prefix)+len(w.suffix) ||
+			!strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+			continue
+		}
+		// The wildcard stands for one or more host labels, nothing else
+		if sub := origin[len(w.prefix) : len(origin)-len(w.suffix)]; !strings.ContainsAny(sub, "/:@") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsPreflight reports whether the method and headers a preflight
+// request asks for are allowed
+func (p *policy) allowsPreflight(r *http.Request) bool {
+	if !p.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+		return false
+	}
+	if p.anyHeader {
+		return true
+	}
+	for _, header := range requestedHeaders(r) {
+		if !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+// writePreflight answers an allowed preflight request
+func (p *policy) writePreflight(w http.ResponseWriter, r *http.Request, origin string) {
+	p.writeOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", p.allowMethods)
+	allowHeaders := p.allowHeaders
+	if p.anyHeader {
+		// Echo the request since "*" is not honored with credentials
+		allowHeaders = strings.Join(requestedHeaders(r), ", ")
+	}
+	if allowHeaders != "" {
+		w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+	}
+	if p.maxAge != "" {
+		w.Header().Set("Access-Control-Max-Age", p.maxAge)
+	}
+}
+
+// writeOrigin sets the allowed origin and credentials headers
+func (p *policy) writeOrigin(w http.ResponseWriter, origin string) {
+	if p.allowAll && !p.credentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// requestedHeaders returns the headers listed in a preflight request
+func requestedHeaders(r *http.Request) []string {
+	var headers []string
+	for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				headers = append(headers, header)
+			}
+		}
+	}
+	return headers
+}
@@ -0,0 +1,161 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+// testConfig allows one origin and the subdomains of example.com
+func testConfig() *config.CORSConfig {
+	return &config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.org", "https://*.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "Connect-Protocol-Version", "X-Grpc-Web"},
+		ExposedHeaders:   []string{"Grpc-Status", "Grpc-Message", "Retry-After"},
+		AllowCredentials: true,
+		MaxAge:           2 * time.Hour,
+	}
+}
+
+// serve sends req through c and reports whether the wrapped handler ran
+func serve(c *CORS, req *http.Request) (*httptest.ResponseRecorder, bool) {
+	reached := false
+	handler := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, reached
+}
+
+// preflight returns an OPTIONS request from origin asking for method and
+// headers
+func preflight(origin, method, headers string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/task.v1.TaskService/CreateTask", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return req
+}
+
+func TestCORS_AllowedOrigins(t *testing.T) {
+	c := New(testConfig())
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.org", true},
+		{"HTTPS://APP.EXAMPLE.ORG", true},
+		{"https://web.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://example.com", false},
+		{"http://web.example.com", false},
+		{"https://evil-example.com", false},
+		{"https://web.example.com.evil.org", false},
+		{"https://web.example.com:8443", false},
+		{"https://other.example.org", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/task.v1.TaskService/GetAllTasks", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec, reached := serve(c, req)
+
+			assert.True(t, reached, "actual requests always reach the handler")
+			assert.Equal(t, []string{"Origin"}, rec.Header().Values("Vary"))
+			if !tt.allowed {
+				assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+				assert.Empty(t, rec.Header().Get("Access-Control-Expose-Headers"))
+				return
+			}
+			assert.Equal(t, tt.origin, rec.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, "Grpc-Status, Grpc-Message, Retry-After", rec.Header().Get("Access-Control-Expose-Headers"))
+		})
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	c := New(testConfig())
+	rec, reached := serve(c, preflight("https://web.example.com", "POST", "content-type, connect-protocol-version"))
+
+	assert.False(t, reached, "preflight requests are answered by the CORS handler")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://web.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, POST", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Connect-Protocol-Version, X-Grpc-Web", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "7200", rec.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, rec.Header().Values("Vary"))
+}
+
+func TestCORS_PreflightRejected(t *testing.T) {
+	c := New(testConfig())
+	for name, req := range map[string]*http.Request{
+		"origin":  preflight("https://evil.example.org", "POST", ""),
+		"method":  preflight("https://app.example.org", "DELETE", ""),
+		"headers": preflight("https://app.example.org", "POST", "Content-Type, X-Secret"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec, reached := serve(c, req)
+			assert.False(t, reached)
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+			assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+}
+
+func TestCORS_AllOrigins(t *testing.T) {
+	c := New(&config.CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"POST"},
+		AllowedHeaders: []string{"*"},
+	})
+
+	rec, _ := serve(c, preflight("https://anywhere.test", "POST", "Content-Type, X-Custom"))
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Content-Type, X-Custom", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORS_PassesThroughNonCORSRequests(t *testing.T) {
+	c := New(testConfig())
+
+	rec, reached := serve(c, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.True(t, reached)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	// OPTIONS without Access-Control-Request-Method is not a preflight
+	req := httptest.NewRequest(http.MethodOptions, "/calendar/", nil)
+	req.Header.Set("Origin", "https://app.example.org")
+	_, reached = serve(c, req)
+	assert.True(t, reached)
+}
+
+func TestCORS_SetConfig(t *testing.T) {
+	c := New(testConfig())
+	cfg := testConfig()
+	cfg.AllowedOrigins = []string{"https://new.example.net"}
+	c.SetConfig(cfg)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Origin", "https://app.example.org")
+	rec, _ := serve(c, req)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+
+	req.Header.Set("Origin", "https://new.example.net")
+	rec, _ = serve(c, req)
+	assert.Equal(t, "https://new.example.net", rec.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -1,11 +1,22 @@
 package errors
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
 
 	"connectrpc.com/connect"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
+// ErrorDomain identifies this service in google.rpc.ErrorInfo details
+const ErrorDomain = "todo.wcygan.github.com"
+
 // ToConnectError converts an application error to a ConnectRPC error
 func ToConnectError(err error) error {
 	if err == nil {
@@ -24,18 +35,151 @@ func ToConnectError(err error) error {
 		return connect.NewError(connect.CodeInternal, err)
 	}
 
+	var connectErr *connect.Error
 	switch appErr.Code {
 	case CodeNotFound:
-		return connect.NewError(connect.CodeNotFound, appErr)
+		connectErr = connect.NewError(connect.CodeNotFound, appErr)
 	case CodeValidation:
-		return connect.NewError(connect.CodeInvalidArgument, appErr)
+		connectErr = connect.NewError(connect.CodeInvalidArgument, appErr)
 	case CodeTimeout:
-		return connect.NewError(connect.CodeDeadlineExceeded, appErr)
-	case CodeInternal:
-		return connect.NewError(connect.CodeInternal, appErr)
+		connectErr = connect.NewError(connect.CodeDeadlineExceeded, appErr)
+	case CodeRateLimited:
+		connectErr = connect.NewError(connect.CodeResourceExhausted, appErr)
+		if retryAfter, ok := appErr.Details["retry_after"].(time.Duration); ok {
+			connectErr.Meta().Set("Retry-After", retryAfterSeconds(retryAfter))
+		}
+	case CodeConflict:
+		connectErr = connect.NewError(connect.CodeAborted, appErr)
 	default:
-		return connect.NewError(connect.CodeInternal, appErr)
+		connectErr = connect.NewError(connect.CodeInternal, appErr)
+	}
+
+	for _, detail := range errorDetails(appErr) {
+		if d, err := connect.NewErrorDetail(detail); err == nil {
+			connectErr.AddDetail(d)
+		}
+	}
+	return connectErr
+}
+
+// errorDetails builds the typed google.rpc details for appErr. Every error
+// carries an ErrorInfo with its ErrorCode; validation, not-found and rate
+// limit errors add BadRequest, ResourceInfo and RetryInfo respectively.
+func errorDetails(appErr *Error) []proto.Message {
+	info := &errdetails.ErrorInfo{
+		Reason: string(appErr.Code),
+		Domain: ErrorDomain,
+	}
+	if appErr.Code != CodeInternal {
+		for key, value := range appErr.Details {
+			if info.Metadata == nil {
+				info.Metadata = make(map[string]string, len(appErr.Details))
+			}
+			info.Metadata[key] = fmt.Sprint(value)
+		}
+	}
+	details := []proto.Message{info}
+
+	switch appErr.Code {
+	case CodeValidation:
+		field, _ := appErr.Details["field"].(string)
+		reason, _ := appErr.Details["reason"].(string)
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: field, Description: reason},
+			},
+		})
+	case CodeNotFound:
+		resource, _ := appErr.Details["resource"].(string)
+		id, _ := appErr.Details["id"].(string)
+		details = append(details, &errdetails.ResourceInfo{
+			ResourceType: resource,
+			ResourceName: id,
+			Description:  appErr.Message,
+		})
+	case CodeRateLimited:
+		if retryAfter, ok := appErr.Details["retry_after"].(time.Duration); ok {
+			details = append(details, &errdetails.RetryInfo{
+				RetryDelay: durationpb.New(retryAfter),
+			})
+		}
+	}
+
+	return details
+}
+
+// scrubbedMessage replaces internal error messages sent to clients
+const scrubbedMessage = "internal error"
+
+// ScrubInternalErrors returns a Connect interceptor that replaces the message
+// of internal and unknown errors, which may include database errors and other
+// causes, with a generic one. Typed details such as ErrorInfo are kept.
+// Install it in production.
+func ScrubInternalErrors() connect.Interceptor {
+	return scrubInterceptor{}
+}
+
+// scrubInterceptor implements ScrubInternalErrors
+type scrubInterceptor struct{}
+
+// WrapUnary implements connect.Interceptor
+func (scrubInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if req.Spec().IsClient {
+			return resp, err
+		}
+		return resp, ScrubInternal(err)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (scrubInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (scrubInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return ScrubInternal(next(ctx, conn))
+	}
+}
+
+// ScrubInternal hides the message of internal and unknown errors, keeping
+// their code, details and metadata
+func ScrubInternal(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	code := connect.CodeOf(err)
+	if code != connect.CodeInternal && code != connect.CodeUnknown {
+		return err
+	}
+
+	scrubbed := connect.NewError(code, errors.New(scrubbedMessage))
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		for _, detail := range connectErr.Details() {
+			scrubbed.AddDetail(detail)
+		}
+		for key, values := range connectErr.Meta() {
+			for _, value := range values {
+				scrubbed.Meta().Add(key, value)
+			}
+		}
+	}
+	return scrubbed
+}
+
+// retryAfterSeconds formats d as a Retry-After value in whole seconds,
+// rounding up so clients never retry early
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int64(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
 	}
+	return strconv.FormatInt(seconds, 10)
 }
 
 // As is a convenience wrapper around errors.As for our Error type
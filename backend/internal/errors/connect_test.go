@@ -1,11 +1,16 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestToConnectError(t *testing.T) {
@@ -34,6 +39,16 @@ func TestToConnectError(t *testing.T) {
 			err:          Timeout("operation"),
 			expectedCode: connect.CodeDeadlineExceeded,
 		},
+		{
+			name:         "rate_limited_error",
+			err:          RateLimited(time.Second),
+			expectedCode: connect.CodeResourceExhausted,
+		},
+		{
+			name:         "conflict_error",
+			err:          Conflict("request in progress"),
+			expectedCode: connect.CodeAborted,
+		},
 		{
 			name:         "internal_error",
 			err:          Internal("internal error"),
@@ -65,6 +80,23 @@ func TestToConnectError(t *testing.T) {
 	}
 }
 
+func TestToConnectError_RetryAfter(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		expected   string
+	}{
+		{retryAfter: 2 * time.Second, expected: "2"},
+		{retryAfter: 1500 * time.Millisecond, expected: "2"},
+		{retryAfter: 10 * time.Millisecond, expected: "1"},
+	}
+
+	for _, tt := range tests {
+		var connectErr *connect.Error
+		assert.True(t, errors.As(ToConnectError(RateLimited(tt.retryAfter)), &connectErr))
+		assert.Equal(t, tt.expected, connectErr.Meta().Get("Retry-After"), tt.retryAfter.String())
+	}
+}
+
 func TestAs(t *testing.T) {
 	appErr := NotFound("task", "123")
 	regularErr := errors.New("regular error")
@@ -79,4 +111,107 @@ func TestAs(t *testing.T) {
 	target = nil
 	assert.False(t, As(regularErr, &target))
 	assert.Nil(t, target)
-}
\ No newline at end of file
+}
+// connectDetails decodes every typed detail attached to err
+func connectDetails(t *testing.T, err error) []proto.Message {
+	var connectErr *connect.Error
+	require.True(t, errors.As(err, &connectErr))
+
+	var details []proto.Message
+	for _, detail := range connectErr.Details() {
+		value, err := detail.Value()
+		require.NoError(t, err)
+		details = append(details, value)
+	}
+	return details
+}
+
+func TestToConnectError_Details(t *testing.T) {
+	t.Run("validation", func(t *testing.T) {
+		details := connectDetails(t, ToConnectError(Validation("description", "cannot be empty")))
+		require.Len(t, details, 2)
+
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, string(CodeValidation), info.Reason)
+		assert.Equal(t, ErrorDomain, info.Domain)
+		assert.Equal(t, "description", info.Metadata["field"])
+
+		badRequest, ok := details[1].(*errdetails.BadRequest)
+		require.True(t, ok)
+		require.Len(t, badRequest.FieldViolations, 1)
+		assert.Equal(t, "description", badRequest.FieldViolations[0].Field)
+		assert.Equal(t, "cannot be empty", badRequest.FieldViolations[0].Description)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		details := connectDetails(t, ToConnectError(NotFound("task", "123")))
+		require.Len(t, details, 2)
+
+		resource, ok := details[1].(*errdetails.ResourceInfo)
+		require.True(t, ok)
+		assert.Equal(t, "task", resource.ResourceType)
+		assert.Equal(t, "123", resource.ResourceName)
+	})
+
+	t.Run("rate_limited", func(t *testing.T) {
+		details := connectDetails(t, ToConnectError(RateLimited(1500*time.Millisecond)))
+		require.Len(t, details, 2)
+
+		retry, ok := details[1].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		assert.Equal(t, 1500*time.Millisecond, retry.RetryDelay.AsDuration())
+	})
+
+	t.Run("internal_has_no_metadata", func(t *testing.T) {
+		err := InternalWrap(errors.New("dial tcp 10.0.0.1:3306: connection refused"), "failed to create task").
+			WithDetail("dsn", "todouser@tcp(10.0.0.1:3306)/todo")
+		details := connectDetails(t, ToConnectError(err))
+		require.Len(t, details, 1)
+
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, string(CodeInternal), info.Reason)
+		assert.Empty(t, info.Metadata)
+	})
+}
+
+func TestScrubInternalErrors(t *testing.T) {
+	interceptor := ScrubInternalErrors()
+	req := connect.NewRequest(&errdetails.ErrorInfo{})
+
+	call := func(err error) error {
+		next := func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			return nil, err
+		}
+		_, err = interceptor.WrapUnary(next)(context.Background(), req)
+		return err
+	}
+
+	t.Run("internal", func(t *testing.T) {
+		err := call(ToConnectError(InternalWrap(errors.New("connection refused"), "failed to create task")))
+		require.Error(t, err)
+		assert.Equal(t, connect.CodeInternal, connect.CodeOf(err))
+
+		var connectErr *connect.Error
+		require.True(t, errors.As(err, &connectErr))
+		assert.Equal(t, scrubbedMessage, connectErr.Message())
+		assert.NotContains(t, err.Error(), "connection refused")
+		assert.Len(t, connectErr.Details(), 1, "typed details are kept")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		err := call(errors.New("sql: database is closed"))
+		assert.Equal(t, connect.CodeUnknown, connect.CodeOf(err))
+		assert.NotContains(t, err.Error(), "database is closed")
+	})
+
+	t.Run("client_errors_untouched", func(t *testing.T) {
+		original := ToConnectError(Validation("description", "cannot be empty"))
+		assert.Same(t, original, call(original))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		assert.NoError(t, call(nil))
+	})
+}
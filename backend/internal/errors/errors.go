@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 // ErrorCode represents the type of error
@@ -17,6 +18,10 @@ const (
 	CodeInternal ErrorCode = "INTERNAL_ERROR"
 	// CodeTimeout indicates a request timeout
 	CodeTimeout ErrorCode = "TIMEOUT"
+	// CodeRateLimited indicates the caller exceeded its request quota
+	CodeRateLimited ErrorCode = "RATE_LIMITED"
+	// CodeConflict indicates the request conflicts with one in progress
+	CodeConflict ErrorCode = "CONFLICT"
 )
 
 // Error represents a structured application error
@@ -107,6 +112,17 @@ func Timeout(operation string) *Error {
 		WithDetail("operation", operation)
 }
 
+// RateLimited creates a rate limit error telling the caller when to retry
+func RateLimited(retryAfter time.Duration) *Error {
+	return New(CodeRateLimited, "rate limit exceeded").
+		WithDetail("retry_after", retryAfter)
+}
+
+// Conflict creates a conflict error
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
 // IsNotFound checks if an error is a not found error
 func IsNotFound(err error) bool {
 	var appErr *Error
@@ -129,4 +145,16 @@ func IsInternal(err error) bool {
 func IsTimeout(err error) bool {
 	var appErr *Error
 	return errors.As(err, &appErr) && appErr.Code == CodeTimeout
-}
\ No newline at end of file
+}
+
+// IsRateLimited checks if an error is a rate limit error
+func IsRateLimited(err error) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Code == CodeRateLimited
+}
+
+// IsConflict checks if an error is a conflict error
+func IsConflict(err error) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Code == CodeConflict
+}
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -100,6 +101,24 @@ func TestTimeout(t *testing.T) {
 	assert.Equal(t, "create_task", err.Details["operation"])
 }
 
+func TestRateLimited(t *testing.T) {
+	err := RateLimited(3 * time.Second)
+
+	assert.Equal(t, CodeRateLimited, err.Code)
+	assert.Equal(t, 3*time.Second, err.Details["retry_after"])
+	assert.True(t, IsRateLimited(err))
+	assert.False(t, IsRateLimited(Timeout("create_task")))
+}
+
+func TestConflict(t *testing.T) {
+	err := Conflict("request in progress")
+
+	assert.Equal(t, CodeConflict, err.Code)
+	assert.Equal(t, "request in progress", err.Message)
+	assert.True(t, IsConflict(err))
+	assert.False(t, IsConflict(Internal("internal error")))
+}
+
 func TestIsNotFound(t *testing.T) {
 	tests := []struct {
 		name     string
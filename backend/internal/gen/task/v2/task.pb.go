@@ -0,0 +1,963 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: task/v2/task.proto
+
+package taskv2
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// File formats for ExportTasks and ImportTasks
+type TaskFormat int32
+
+const (
+	TaskFormat_TASK_FORMAT_UNSPECIFIED TaskFormat = 0
+	// One task.v1.Task per line in protobuf JSON
+	TaskFormat_TASK_FORMAT_NDJSON TaskFormat = 1
+	// CSV with a header row; imports need a description column and may have
+	// completed and created_at columns
+	TaskFormat_TASK_FORMAT_CSV TaskFormat = 2
+	// GitHub-style checklist items such as "- [ ] Buy milk" or "- [x] Done"
+	TaskFormat_TASK_FORMAT_MARKDOWN TaskFormat = 3
+)
+
+// Enum value maps for TaskFormat.
+var (
+	TaskFormat_name = map[int32]string{
+		0: "TASK_FORMAT_UNSPECIFIED",
+		1: "TASK_FORMAT_NDJSON",
+		2: "TASK_FORMAT_CSV",
+		3: "TASK_FORMAT_MARKDOWN",
+	}
+	TaskFormat_value = map[string]int32{
+		"TASK_FORMAT_UNSPECIFIED": 0,
+		"TASK_FORMAT_NDJSON":      1,
+		"TASK_FORMAT_CSV":         2,
+		"TASK_FORMAT_MARKDOWN":    3,
+	}
+)
+
+func (x TaskFormat) Enum() *TaskFormat {
+	p := new(TaskFormat)
+	*p = x
+	return p
+}
+
+func (x TaskFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TaskFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_task_v2_task_proto_enumTypes[0].Descriptor()
+}
+
+func (TaskFormat) Type() protoreflect.EnumType {
+	return &file_task_v2_task_proto_enumTypes[0]
+}
+
+func (x TaskFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TaskFormat.Descriptor instead.
+func (TaskFormat) EnumDescriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{0}
+}
+
+// A task on the todo list
+type Task struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Resource name of the task, in the form tasks/{task}
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Completed     bool                   `protobuf:"varint,3,opt,name=completed,proto3" json:"completed,omitempty"`
+	CreateTime    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	UpdateTime    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Task) Reset() {
+	*x = Task{}
+	mi := &file_task_v2_task_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Task) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Task) ProtoMessage() {}
+
+func (x *Task) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Task.ProtoReflect.Descriptor instead.
+func (*Task) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Task) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Task) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Task) GetCompleted() bool {
+	if x != nil {
+		return x.Completed
+	}
+	return false
+}
+
+func (x *Task) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *Task) GetUpdateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return nil
+}
+
+// Request to create a new task
+type CreateTaskRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The task to create; only description is used
+	Task          *Task `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTaskRequest) Reset() {
+	*x = CreateTaskRequest{}
+	mi := &file_task_v2_task_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTaskRequest) ProtoMessage() {}
+
+func (x *CreateTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTaskRequest.ProtoReflect.Descriptor instead.
+func (*CreateTaskRequest) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateTaskRequest) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+// Request to get a task by resource name
+type GetTaskRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Resource name of the task, in the form tasks/{task}
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTaskRequest) Reset() {
+	*x = GetTaskRequest{}
+	mi := &file_task_v2_task_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTaskRequest) ProtoMessage() {}
+
+func (x *GetTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTaskRequest.ProtoReflect.Descriptor instead.
+func (*GetTaskRequest) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetTaskRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Request to list tasks, newest first
+type ListTasksRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Maximum number of tasks to return; defaults to 50 and is capped at 1000
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from a previous ListTasks call
+	PageToken     string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksRequest) Reset() {
+	*x = ListTasksRequest{}
+	mi := &file_task_v2_task_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksRequest) ProtoMessage() {}
+
+func (x *ListTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksRequest.ProtoReflect.Descriptor instead.
+func (*ListTasksRequest) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListTasksRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListTasksRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// Response containing a page of tasks
+type ListTasksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Tasks []*Task                `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	// Token for the next page; empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksResponse) Reset() {
+	*x = ListTasksResponse{}
+	mi := &file_task_v2_task_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksResponse) ProtoMessage() {}
+
+func (x *ListTasksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksResponse.ProtoReflect.Descriptor instead.
+func (*ListTasksResponse) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListTasksResponse) GetTasks() []*Task {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+func (x *ListTasksResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// Request to update a task
+type UpdateTaskRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The task to update, identified by name
+	Task *Task `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	// Fields to update: description, completed, or * for both. When empty,
+	// fields set to a non-default value are updated.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTaskRequest) Reset() {
+	*x = UpdateTaskRequest{}
+	mi := &file_task_v2_task_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTaskRequest) ProtoMessage() {}
+
+func (x *UpdateTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTaskRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTaskRequest) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateTaskRequest) GetTask() *Task {
+	if x != nil {
+		return x.Task
+	}
+	return nil
+}
+
+func (x *UpdateTaskRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+// Request to delete a task by resource name
+type DeleteTaskRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Resource name of the task, in the form tasks/{task}
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTaskRequest) Reset() {
+	*x = DeleteTaskRequest{}
+	mi := &file_task_v2_task_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTaskRequest) ProtoMessage() {}
+
+func (x *DeleteTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTaskRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTaskRequest) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteTaskRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Request to export every task
+type ExportTasksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Format        TaskFormat             `protobuf:"varint,1,opt,name=format,proto3,enum=task.v2.TaskFormat" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportTasksRequest) Reset() {
+	*x = ExportTasksRequest{}
+	mi := &file_task_v2_task_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportTasksRequest) ProtoMessage() {}
+
+func (x *ExportTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportTasksRequest.ProtoReflect.Descriptor instead.
+func (*ExportTasksRequest) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ExportTasksRequest) GetFormat() TaskFormat {
+	if x != nil {
+		return x.Format
+	}
+	return TaskFormat_TASK_FORMAT_UNSPECIFIED
+}
+
+// A chunk of the exported file; concatenate the chunks in order
+type ExportTasksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportTasksResponse) Reset() {
+	*x = ExportTasksResponse{}
+	mi := &file_task_v2_task_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportTasksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportTasksResponse) ProtoMessage() {}
+
+func (x *ExportTasksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportTasksResponse.ProtoReflect.Descriptor instead.
+func (*ExportTasksResponse) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExportTasksResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Request to import tasks from a file
+type ImportTasksRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Format TaskFormat             `protobuf:"varint,1,opt,name=format,proto3,enum=task.v2.TaskFormat" json:"format,omitempty"`
+	Data   []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Validate and report without writing anything
+	DryRun        bool `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportTasksRequest) Reset() {
+	*x = ImportTasksRequest{}
+	mi := &file_task_v2_task_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportTasksRequest) ProtoMessage() {}
+
+func (x *ImportTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportTasksRequest.ProtoReflect.Descriptor instead.
+func (*ImportTasksRequest) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ImportTasksRequest) GetFormat() TaskFormat {
+	if x != nil {
+		return x.Format
+	}
+	return TaskFormat_TASK_FORMAT_UNSPECIFIED
+}
+
+func (x *ImportTasksRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ImportTasksRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// A row that could not be imported
+type ImportRowError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 1-based line number in the file
+	Line          int32  `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	Field         string `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	Message       string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportRowError) Reset() {
+	*x = ImportRowError{}
+	mi := &file_task_v2_task_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportRowError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRowError) ProtoMessage() {}
+
+func (x *ImportRowError) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRowError.ProtoReflect.Descriptor instead.
+func (*ImportRowError) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ImportRowError) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *ImportRowError) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *ImportRowError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// A row skipped because a task with the same description already exists or
+// appears earlier in the file
+type ImportDuplicate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 1-based line number in the file
+	Line          int32  `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	Description   string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportDuplicate) Reset() {
+	*x = ImportDuplicate{}
+	mi := &file_task_v2_task_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportDuplicate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportDuplicate) ProtoMessage() {}
+
+func (x *ImportDuplicate) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportDuplicate.ProtoReflect.Descriptor instead.
+func (*ImportDuplicate) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ImportDuplicate) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *ImportDuplicate) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// Result of an import. Nothing is written when errors is not empty.
+type ImportTasksResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tasks that were created, or would be created on a dry run
+	Tasks         []*Task            `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	Errors        []*ImportRowError  `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	Duplicates    []*ImportDuplicate `protobuf:"bytes,3,rep,name=duplicates,proto3" json:"duplicates,omitempty"`
+	DryRun        bool               `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportTasksResponse) Reset() {
+	*x = ImportTasksResponse{}
+	mi := &file_task_v2_task_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportTasksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportTasksResponse) ProtoMessage() {}
+
+func (x *ImportTasksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_task_v2_task_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportTasksResponse.ProtoReflect.Descriptor instead.
+func (*ImportTasksResponse) Descriptor() ([]byte, []int) {
+	return file_task_v2_task_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ImportTasksResponse) GetTasks() []*Task {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+func (x *ImportTasksResponse) GetErrors() []*ImportRowError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ImportTasksResponse) GetDuplicates() []*ImportDuplicate {
+	if x != nil {
+		return x.Duplicates
+	}
+	return nil
+}
+
+func (x *ImportTasksResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+var File_task_v2_task_proto protoreflect.FileDescriptor
+
+const file_task_v2_task_proto_rawDesc = "" +
+	"\n" +
+	"\x12task/v2/task.proto\x12\atask.v2\x1a\x1bgoogle/protobuf/empty.proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd4\x01\n" +
+	"\x04Task\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1c\n" +
+	"\tcompleted\x18\x03 \x01(\bR\tcompleted\x12;\n" +
+	"\vcreate_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12;\n" +
+	"\vupdate_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"updateTime\"6\n" +
+	"\x11CreateTaskRequest\x12!\n" +
+	"\x04task\x18\x01 \x01(\v2\r.task.v2.TaskR\x04task\"$\n" +
+	"\x0eGetTaskRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"N\n" +
+	"\x10ListTasksRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"`\n" +
+	"\x11ListTasksResponse\x12#\n" +
+	"\x05tasks\x18\x01 \x03(\v2\r.task.v2.TaskR\x05tasks\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"s\n" +
+	"\x11UpdateTaskRequest\x12!\n" +
+	"\x04task\x18\x01 \x01(\v2\r.task.v2.TaskR\x04task\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"'\n" +
+	"\x11DeleteTaskRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"A\n" +
+	"\x12ExportTasksRequest\x12+\n" +
+	"\x06format\x18\x01 \x01(\x0e2\x13.task.v2.TaskFormatR\x06format\")\n" +
+	"\x13ExportTasksResponse\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"n\n" +
+	"\x12ImportTasksRequest\x12+\n" +
+	"\x06format\x18\x01 \x01(\x0e2\x13.task.v2.TaskFormatR\x06format\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"T\n" +
+	"\x0eImportRowError\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x05R\x04line\x12\x14\n" +
+	"\x05field\x18\x02 \x01(\tR\x05field\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"G\n" +
+	"\x0fImportDuplicate\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x05R\x04line\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"\xbe\x01\n" +
+	"\x13ImportTasksResponse\x12#\n" +
+	"\x05tasks\x18\x01 \x03(\v2\r.task.v2.TaskR\x05tasks\x12/\n" +
+	"\x06errors\x18\x02 \x03(\v2\x17.task.v2.ImportRowErrorR\x06errors\x128\n" +
+	"\n" +
+	"duplicates\x18\x03 \x03(\v2\x18.task.v2.ImportDuplicateR\n" +
+	"duplicates\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun*p\n" +
+	"\n" +
+	"TaskFormat\x12\x1b\n" +
+	"\x17TASK_FORMAT_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12TASK_FORMAT_NDJSON\x10\x01\x12\x13\n" +
+	"\x0fTASK_FORMAT_CSV\x10\x02\x12\x18\n" +
+	"\x14TASK_FORMAT_MARKDOWN\x10\x032\xce\x03\n" +
+	"\vTaskService\x127\n" +
+	"\n" +
+	"CreateTask\x12\x1a.task.v2.CreateTaskRequest\x1a\r.task.v2.Task\x121\n" +
+	"\aGetTask\x12\x17.task.v2.GetTaskRequest\x1a\r.task.v2.Task\x12B\n" +
+	"\tListTasks\x12\x19.task.v2.ListTasksRequest\x1a\x1a.task.v2.ListTasksResponse\x127\n" +
+	"\n" +
+	"UpdateTask\x12\x1a.task.v2.UpdateTaskRequest\x1a\r.task.v2.Task\x12@\n" +
+	"\n" +
+	"DeleteTask\x12\x1a.task.v2.DeleteTaskRequest\x1a\x16.google.protobuf.Empty\x12J\n" +
+	"\vExportTasks\x12\x1b.task.v2.ExportTasksRequest\x1a\x1c.task.v2.ExportTasksResponse0\x01\x12H\n" +
+	"\vImportTasks\x12\x1b.task.v2.ImportTasksRequest\x1a\x1c.task.v2.ImportTasksResponseb\x06proto3"
+
+var (
+	file_task_v2_task_proto_rawDescOnce sync.Once
+	file_task_v2_task_proto_rawDescData []byte
+)
+
+func file_task_v2_task_proto_rawDescGZIP() []byte {
+	file_task_v2_task_proto_rawDescOnce.Do(func() {
+		file_task_v2_task_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_task_v2_task_proto_rawDesc), len(file_task_v2_task_proto_rawDesc)))
+	})
+	return file_task_v2_task_proto_rawDescData
+}
+
+var file_task_v2_task_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_task_v2_task_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_task_v2_task_proto_goTypes = []any{
+	(TaskFormat)(0),               // 0: task.v2.TaskFormat
+	(*Task)(nil),                  // 1: task.v2.Task
+	(*CreateTaskRequest)(nil),     // 2: task.v2.CreateTaskRequest
+	(*GetTaskRequest)(nil),        // 3: task.v2.GetTaskRequest
+	(*ListTasksRequest)(nil),      // 4: task.v2.ListTasksRequest
+	(*ListTasksResponse)(nil),     // 5: task.v2.ListTasksResponse
+	(*UpdateTaskRequest)(nil),     // 6: task.v2.UpdateTaskRequest
+	(*DeleteTaskRequest)(nil),     // 7: task.v2.DeleteTaskRequest
+	(*ExportTasksRequest)(nil),    // 8: task.v2.ExportTasksRequest
+	(*ExportTasksResponse)(nil),   // 9: task.v2.ExportTasksResponse
+	(*ImportTasksRequest)(nil),    // 10: task.v2.ImportTasksRequest
+	(*ImportRowError)(nil),        // 11: task.v2.ImportRowError
+	(*ImportDuplicate)(nil),       // 12: task.v2.ImportDuplicate
+	(*ImportTasksResponse)(nil),   // 13: task.v2.ImportTasksResponse
+	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil), // 15: google.protobuf.FieldMask
+	(*emptypb.Empty)(nil),         // 16: google.protobuf.Empty
+}
+var file_task_v2_task_proto_depIdxs = []int32{
+	14, // 0: task.v2.Task.create_time:type_name -> google.protobuf.Timestamp
+	14, // 1: task.v2.Task.update_time:type_name -> google.protobuf.Timestamp
+	1,  // 2: task.v2.CreateTaskRequest.task:type_name -> task.v2.Task
+	1,  // 3: task.v2.ListTasksResponse.tasks:type_name -> task.v2.Task
+	1,  // 4: task.v2.UpdateTaskRequest.task:type_name -> task.v2.Task
+	15, // 5: task.v2.UpdateTaskRequest.update_mask:type_name -> google.protobuf.FieldMask
+	0,  // 6: task.v2.ExportTasksRequest.format:type_name -> task.v2.TaskFormat
+	0,  // 7: task.v2.ImportTasksRequest.format:type_name -> task.v2.TaskFormat
+	1,  // 8: task.v2.ImportTasksResponse.tasks:type_name -> task.v2.Task
+	11, // 9: task.v2.ImportTasksResponse.errors:type_name -> task.v2.ImportRowError
+	12, // 10: task.v2.ImportTasksResponse.duplicates:type_name -> task.v2.ImportDuplicate
+	2,  // 11: task.v2.TaskService.CreateTask:input_type -> task.v2.CreateTaskRequest
+	3,  // 12: task.v2.TaskService.GetTask:input_type -> task.v2.GetTaskRequest
+	4,  // 13: task.v2.TaskService.ListTasks:input_type -> task.v2.ListTasksRequest
+	6,  // 14: task.v2.TaskService.UpdateTask:input_type -> task.v2.UpdateTaskRequest
+	7,  // 15: task.v2.TaskService.DeleteTask:input_type -> task.v2.DeleteTaskRequest
+	8,  // 16: task.v2.TaskService.ExportTasks:input_type -> task.v2.ExportTasksRequest
+	10, // 17: task.v2.TaskService.ImportTasks:input_type -> task.v2.ImportTasksRequest
+	1,  // 18: task.v2.TaskService.CreateTask:output_type -> task.v2.Task
+	1,  // 19: task.v2.TaskService.GetTask:output_type -> task.v2.Task
+	5,  // 20: task.v2.TaskService.ListTasks:output_type -> task.v2.ListTasksResponse
+	1,  // 21: task.v2.TaskService.UpdateTask:output_type -> task.v2.Task
+	16, // 22: task.v2.TaskService.DeleteTask:output_type -> google.protobuf.Empty
+	9,  // 23: task.v2.TaskService.ExportTasks:output_type -> task.v2.ExportTasksResponse
+	13, // 24: task.v2.TaskService.ImportTasks:output_type -> task.v2.ImportTasksResponse
+	18, // [18:25] is the sub-list for method output_type
+	11, // [11:18] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_task_v2_task_proto_init() }
+func file_task_v2_task_proto_init() {
+	if File_task_v2_task_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_task_v2_task_proto_rawDesc), len(file_task_v2_task_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_task_v2_task_proto_goTypes,
+		DependencyIndexes: file_task_v2_task_proto_depIdxs,
+		EnumInfos:         file_task_v2_task_proto_enumTypes,
+		MessageInfos:      file_task_v2_task_proto_msgTypes,
+	}.Build()
+	File_task_v2_task_proto = out.File
+	file_task_v2_task_proto_goTypes = nil
+	file_task_v2_task_proto_depIdxs = nil
+}
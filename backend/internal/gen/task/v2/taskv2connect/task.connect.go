@@ -0,0 +1,277 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: task/v2/task.proto
+
+package taskv2connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v2 "github.com/wcygan/todo/backend/internal/gen/task/v2"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// TaskServiceName is the fully-qualified name of the TaskService service.
+	TaskServiceName = "task.v2.TaskService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// TaskServiceCreateTaskProcedure is the fully-qualified name of the TaskService's CreateTask RPC.
+	TaskServiceCreateTaskProcedure = "/task.v2.TaskService/CreateTask"
+	// TaskServiceGetTaskProcedure is the fully-qualified name of the TaskService's GetTask RPC.
+	TaskServiceGetTaskProcedure = "/task.v2.TaskService/GetTask"
+	// TaskServiceListTasksProcedure is the fully-qualified name of the TaskService's ListTasks RPC.
+	TaskServiceListTasksProcedure = "/task.v2.TaskService/ListTasks"
+	// TaskServiceUpdateTaskProcedure is the fully-qualified name of the TaskService's UpdateTask RPC.
+	TaskServiceUpdateTaskProcedure = "/task.v2.TaskService/UpdateTask"
+	// TaskServiceDeleteTaskProcedure is the fully-qualified name of the TaskService's DeleteTask RPC.
+	TaskServiceDeleteTaskProcedure = "/task.v2.TaskService/DeleteTask"
+	// TaskServiceExportTasksProcedure is the fully-qualified name of the TaskService's ExportTasks RPC.
+	TaskServiceExportTasksProcedure = "/task.v2.TaskService/ExportTasks"
+	// TaskServiceImportTasksProcedure is the fully-qualified name of the TaskService's ImportTasks RPC.
+	TaskServiceImportTasksProcedure = "/task.v2.TaskService/ImportTasks"
+)
+
+// TaskServiceClient is a client for the task.v2.TaskService service.
+type TaskServiceClient interface {
+	CreateTask(context.Context, *connect.Request[v2.CreateTaskRequest]) (*connect.Response[v2.Task], error)
+	GetTask(context.Context, *connect.Request[v2.GetTaskRequest]) (*connect.Response[v2.Task], error)
+	ListTasks(context.Context, *connect.Request[v2.ListTasksRequest]) (*connect.Response[v2.ListTasksResponse], error)
+	UpdateTask(context.Context, *connect.Request[v2.UpdateTaskRequest]) (*connect.Response[v2.Task], error)
+	DeleteTask(context.Context, *connect.Request[v2.DeleteTaskRequest]) (*connect.Response[emptypb.Empty], error)
+	ExportTasks(context.Context, *connect.Request[v2.ExportTasksRequest]) (*connect.ServerStreamForClient[v2.ExportTasksResponse], error)
+	ImportTasks(context.Context, *connect.Request[v2.ImportTasksRequest]) (*connect.Response[v2.ImportTasksResponse], error)
+}
+
+// NewTaskServiceClient constructs a client for the task.v2.TaskService service. By default, it uses
+// the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewTaskServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) TaskServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	taskServiceMethods := v2.File_task_v2_task_proto.Services().ByName("TaskService").Methods()
+	return &taskServiceClient{
+		createTask: connect.NewClient[v2.CreateTaskRequest, v2.Task](
+			httpClient,
+			baseURL+TaskServiceCreateTaskProcedure,
+			connect.WithSchema(taskServiceMethods.ByName("CreateTask")),
+			connect.WithClientOptions(opts...),
+		),
+		getTask: connect.NewClient[v2.GetTaskRequest, v2.Task](
+			httpClient,
+			baseURL+TaskServiceGetTaskProcedure,
+			connect.WithSchema(taskServiceMethods.ByName("GetTask")),
+			connect.WithClientOptions(opts...),
+		),
+		listTasks: connect.NewClient[v2.ListTasksRequest, v2.ListTasksResponse](
+			httpClient,
+			baseURL+TaskServiceListTasksProcedure,
+			connect.WithSchema(taskServiceMethods.ByName("ListTasks")),
+			connect.WithClientOptions(opts...),
+		),
+		updateTask: connect.NewClient[v2.UpdateTaskRequest, v2.Task](
+			httpClient,
+			baseURL+TaskServiceUpdateTaskProcedure,
+			connect.WithSchema(taskServiceMethods.ByName("UpdateTask")),
+			connect.WithClientOptions(opts...),
+		),
+		deleteTask: connect.NewClient[v2.DeleteTaskRequest, emptypb.Empty](
+			httpClient,
+			baseURL+TaskServiceDeleteTaskProcedure,
+			connect.WithSchema(taskServiceMethods.ByName("DeleteTask")),
+			connect.WithClientOptions(opts...),
+		),
+		exportTasks: connect.NewClient[v2.ExportTasksRequest, v2.ExportTasksResponse](
+			httpClient,
+			baseURL+TaskServiceExportTasksProcedure,
+			connect.WithSchema(taskServiceMethods.ByName("ExportTasks")),
+			connect.WithClientOptions(opts...),
+		),
+		importTasks: connect.NewClient[v2.ImportTasksRequest, v2.ImportTasksResponse](
+			httpClient,
+			baseURL+TaskServiceImportTasksProcedure,
+			connect.WithSchema(taskServiceMethods.ByName("ImportTasks")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// taskServiceClient implements TaskServiceClient.
+type taskServiceClient struct {
+	createTask  *connect.Client[v2.CreateTaskRequest, v2.Task]
+	getTask     *connect.Client[v2.GetTaskRequest, v2.Task]
+	listTasks   *connect.Client[v2.ListTasksRequest, v2.ListTasksResponse]
+	updateTask  *connect.Client[v2.UpdateTaskRequest, v2.Task]
+	deleteTask  *connect.Client[v2.DeleteTaskRequest, emptypb.Empty]
+	exportTasks *connect.Client[v2.ExportTasksRequest, v2.ExportTasksResponse]
+	importTasks *connect.Client[v2.ImportTasksRequest, v2.ImportTasksResponse]
+}
+
+// CreateTask calls task.v2.TaskService.CreateTask.
+func (c *taskServiceClient) CreateTask(ctx context.Context, req *connect.Request[v2.CreateTaskRequest]) (*connect.Response[v2.Task], error) {
+	return c.createTask.CallUnary(ctx, req)
+}
+
+// GetTask calls task.v2.TaskService.GetTask.
+func (c *taskServiceClient) GetTask(ctx context.Context, req *connect.Request[v2.GetTaskRequest]) (*connect.Response[v2.Task], error) {
+	return c.getTask.CallUnary(ctx, req)
+}
+
+// ListTasks calls task.v2.TaskService.ListTasks.
+func (c *taskServiceClient) ListTasks(ctx context.Context, req *connect.Request[v2.ListTasksRequest]) (*connect.Response[v2.ListTasksResponse], error) {
+	return c.listTasks.CallUnary(ctx, req)
+}
+
+// UpdateTask calls task.v2.TaskService.UpdateTask.
+func (c *taskServiceClient) UpdateTask(ctx context.Context, req *connect.Request[v2.UpdateTaskRequest]) (*connect.Response[v2.Task], error) {
+	return c.updateTask.CallUnary(ctx, req)
+}
+
+// DeleteTask calls task.v2.TaskService.DeleteTask.
+func (c *taskServiceClient) DeleteTask(ctx context.Context, req *connect.Request[v2.DeleteTaskRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.deleteTask.CallUnary(ctx, req)
+}
+
+// ExportTasks calls task.v2.TaskService.ExportTasks.
+func (c *taskServiceClient) ExportTasks(ctx context.Context, req *connect.Request[v2.ExportTasksRequest]) (*connect.ServerStreamForClient[v2.ExportTasksResponse], error) {
+	return c.exportTasks.CallServerStream(ctx, req)
+}
+
+// ImportTasks calls task.v2.TaskService.ImportTasks.
+func (c *taskServiceClient) ImportTasks(ctx context.Context, req *connect.Request[v2.ImportTasksRequest]) (*connect.Response[v2.ImportTasksResponse], error) {
+	return c.importTasks.CallUnary(ctx, req)
+}
+
+// TaskServiceHandler is an implementation of the task.v2.TaskService service.
+type TaskServiceHandler interface {
+	CreateTask(context.Context, *connect.Request[v2.CreateTaskRequest]) (*connect.Response[v2.Task], error)
+	GetTask(context.Context, *connect.Request[v2.GetTaskRequest]) (*connect.Response[v2.Task], error)
+	ListTasks(context.Context, *connect.Request[v2.ListTasksRequest]) (*connect.Response[v2.ListTasksResponse], error)
+	UpdateTask(context.Context, *connect.Request[v2.UpdateTaskRequest]) (*connect.Response[v2.Task], error)
+	DeleteTask(context.Context, *connect.Request[v2.DeleteTaskRequest]) (*connect.Response[emptypb.Empty], error)
+	ExportTasks(context.Context, *connect.Request[v2.ExportTasksRequest], *connect.ServerStream[v2.ExportTasksResponse]) error
+	ImportTasks(context.Context, *connect.Request[v2.ImportTasksRequest]) (*connect.Response[v2.ImportTasksResponse], error)
+}
+
+// NewTaskServiceHandler builds an HTTP handler from the service implementation. It returns the path
+// on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewTaskServiceHandler(svc TaskServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	taskServiceMethods := v2.File_task_v2_task_proto.Services().ByName("TaskService").Methods()
+	taskServiceCreateTaskHandler := connect.NewUnaryHandler(
+		TaskServiceCreateTaskProcedure,
+		svc.CreateTask,
+		connect.WithSchema(taskServiceMethods.ByName("CreateTask")),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskServiceGetTaskHandler := connect.NewUnaryHandler(
+		TaskServiceGetTaskProcedure,
+		svc.GetTask,
+		connect.WithSchema(taskServiceMethods.ByName("GetTask")),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskServiceListTasksHandler := connect.NewUnaryHandler(
+		TaskServiceListTasksProcedure,
+		svc.ListTasks,
+		connect.WithSchema(taskServiceMethods.ByName("ListTasks")),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskServiceUpdateTaskHandler := connect.NewUnaryHandler(
+		TaskServiceUpdateTaskProcedure,
+		svc.UpdateTask,
+		connect.WithSchema(taskServiceMethods.ByName("UpdateTask")),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskServiceDeleteTaskHandler := connect.NewUnaryHandler(
+		TaskServiceDeleteTaskProcedure,
+		svc.DeleteTask,
+		connect.WithSchema(taskServiceMethods.ByName("DeleteTask")),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskServiceExportTasksHandler := connect.NewServerStreamHandler(
+		TaskServiceExportTasksProcedure,
+		svc.ExportTasks,
+		connect.WithSchema(taskServiceMethods.ByName("ExportTasks")),
+		connect.WithHandlerOptions(opts...),
+	)
+	taskServiceImportTasksHandler := connect.NewUnaryHandler(
+		TaskServiceImportTasksProcedure,
+		svc.ImportTasks,
+		connect.WithSchema(taskServiceMethods.ByName("ImportTasks")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/task.v2.TaskService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case TaskServiceCreateTaskProcedure:
+			taskServiceCreateTaskHandler.ServeHTTP(w, r)
+		case TaskServiceGetTaskProcedure:
+			taskServiceGetTaskHandler.ServeHTTP(w, r)
+		case TaskServiceListTasksProcedure:
+			taskServiceListTasksHandler.ServeHTTP(w, r)
+		case TaskServiceUpdateTaskProcedure:
+			taskServiceUpdateTaskHandler.ServeHTTP(w, r)
+		case TaskServiceDeleteTaskProcedure:
+			taskServiceDeleteTaskHandler.ServeHTTP(w, r)
+		case TaskServiceExportTasksProcedure:
+			taskServiceExportTasksHandler.ServeHTTP(w, r)
+		case TaskServiceImportTasksProcedure:
+			taskServiceImportTasksHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedTaskServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedTaskServiceHandler struct{}
+
+func (UnimplementedTaskServiceHandler) CreateTask(context.Context, *connect.Request[v2.CreateTaskRequest]) (*connect.Response[v2.Task], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("task.v2.TaskService.CreateTask is not implemented"))
+}
+
+func (UnimplementedTaskServiceHandler) GetTask(context.Context, *connect.Request[v2.GetTaskRequest]) (*connect.Response[v2.Task], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("task.v2.TaskService.GetTask is not implemented"))
+}
+
+func (UnimplementedTaskServiceHandler) ListTasks(context.Context, *connect.Request[v2.ListTasksRequest]) (*connect.Response[v2.ListTasksResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("task.v2.TaskService.ListTasks is not implemented"))
+}
+
+func (UnimplementedTaskServiceHandler) UpdateTask(context.Context, *connect.Request[v2.UpdateTaskRequest]) (*connect.Response[v2.Task], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("task.v2.TaskService.UpdateTask is not implemented"))
+}
+
+func (UnimplementedTaskServiceHandler) DeleteTask(context.Context, *connect.Request[v2.DeleteTaskRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("task.v2.TaskService.DeleteTask is not implemented"))
+}
+
+func (UnimplementedTaskServiceHandler) ExportTasks(context.Context, *connect.Request[v2.ExportTasksRequest], *connect.ServerStream[v2.ExportTasksResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("task.v2.TaskService.ExportTasks is not implemented"))
+}
+
+func (UnimplementedTaskServiceHandler) ImportTasks(context.Context, *connect.Request[v2.ImportTasksRequest]) (*connect.Response[v2.ImportTasksResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("task.v2.TaskService.ImportTasks is not implemented"))
+}
@@ -8,19 +8,41 @@ import (
 	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
 
 	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/middleware"
 	"github.com/wcygan/todo/backend/internal/service"
 )
 
+// IdempotencyKeyHeader carries the client-chosen key that makes a retried
+// CreateTask, UpdateTask or DeleteTask return the original response
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // TaskHandler implements the TaskService ConnectRPC interface
 type TaskHandler struct {
-	service *service.TaskService
+	service               *service.TaskService
+	legacyDeleteResponses bool
+}
+
+// Option configures a TaskHandler
+type Option func(*TaskHandler)
+
+// WithLegacyDeleteResponses makes DeleteTask answer failures with
+// success=false instead of a Connect error, for clients written against the
+// original contract
+func WithLegacyDeleteResponses(enabled bool) Option {
+	return func(h *TaskHandler) {
+		h.legacyDeleteResponses = enabled
+	}
 }
 
 // NewTaskHandler creates a new TaskHandler instance
-func NewTaskHandler(service *service.TaskService) *TaskHandler {
-	return &TaskHandler{
+func NewTaskHandler(service *service.TaskService, opts ...Option) *TaskHandler {
+	h := &TaskHandler{
 		service: service,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // CreateTask handles task creation requests
@@ -28,6 +50,7 @@ func (h *TaskHandler) CreateTask(
 	ctx context.Context,
 	req *connect.Request[taskv1.CreateTaskRequest],
 ) (*connect.Response[taskv1.CreateTaskResponse], error) {
+	ctx = service.WithIdempotencyKey(ctx, middleware.ClientName(ctx), req.Header().Get(IdempotencyKeyHeader))
 	task, err := h.service.CreateTask(ctx, req.Msg.Description)
 	if err != nil {
 		return nil, errors.ToConnectError(err)
@@ -73,6 +96,7 @@ func (h *TaskHandler) UpdateTask(
 	ctx context.Context,
 	req *connect.Request[taskv1.UpdateTaskRequest],
 ) (*connect.Response[taskv1.UpdateTaskResponse], error) {
+	ctx = service.WithIdempotencyKey(ctx, middleware.ClientName(ctx), req.Header().Get(IdempotencyKeyHeader))
 	task, err := h.service.UpdateTask(ctx, req.Msg.Id, req.Msg.Description, req.Msg.Completed)
 	if err != nil {
 		return nil, errors.ToConnectError(err)
@@ -88,12 +112,16 @@ func (h *TaskHandler) DeleteTask(
 	ctx context.Context,
 	req *connect.Request[taskv1.DeleteTaskRequest],
 ) (*connect.Response[taskv1.DeleteTaskResponse], error) {
+	ctx = service.WithIdempotencyKey(ctx, middleware.ClientName(ctx), req.Header().Get(IdempotencyKeyHeader))
 	err := h.service.DeleteTask(ctx, req.Msg.Id)
 	if err != nil {
-		return connect.NewResponse(&taskv1.DeleteTaskResponse{
-			Success: false,
-			Message: err.Error(),
-		}), nil
+		if h.legacyDeleteResponses {
+			return connect.NewResponse(&taskv1.DeleteTaskResponse{
+				Success: false,
+				Message: legacyDeleteMessage(err),
+			}), nil
+		}
+		return nil, errors.ToConnectError(err)
 	}
 
 	return connect.NewResponse(&taskv1.DeleteTaskResponse{
@@ -103,4 +131,14 @@ func (h *TaskHandler) DeleteTask(
 }
 
 // Verify that TaskHandler implements the interface
-var _ taskconnect.TaskServiceHandler = (*TaskHandler)(nil)
\ No newline at end of file
+var _ taskconnect.TaskServiceHandler = (*TaskHandler)(nil)
+
+// legacyDeleteMessage describes a DeleteTask failure for legacy clients
+// without exposing the cause of internal errors
+func legacyDeleteMessage(err error) string {
+	var appErr *errors.Error
+	if !errors.As(err, &appErr) || appErr.Code == errors.CodeInternal {
+		return "failed to delete task"
+	}
+	return appErr.Message
+}
@@ -2,14 +2,19 @@ package handler
 
 import (
 	"context"
+	stderrors "errors"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
 	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 
+	"github.com/wcygan/todo/backend/internal/errors"
 	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/internal/store"
 	"github.com/wcygan/todo/backend/test/testutil"
 )
 
@@ -207,11 +212,11 @@ func TestTaskHandler_GetAllTasks(t *testing.T) {
 			require.NotNil(t, resp.Msg)
 			assert.Len(t, resp.Msg.Tasks, tt.expectedCount)
 			
-			// Verify task content
+			// Verify task content, newest first
 			if tt.expectedCount > 0 {
 				for i, task := range resp.Msg.Tasks {
 					assert.NotEmpty(t, task.Id)
-					assert.Equal(t, tt.setupTasks[i], task.Description)
+					assert.Equal(t, tt.setupTasks[len(tt.setupTasks)-1-i], task.Description)
 					assert.False(t, task.Completed)
 					assert.NotNil(t, task.CreatedAt)
 					assert.NotNil(t, task.UpdatedAt)
@@ -223,28 +228,33 @@ func TestTaskHandler_GetAllTasks(t *testing.T) {
 
 func TestTaskHandler_DeleteTask(t *testing.T) {
 	tests := []struct {
-		name        string
-		setupTask   bool
-		taskID      string
-		expectError bool
+		name         string
+		setupTask    bool
+		taskID       string
+		expectedCode connect.Code
 	}{
 		{
-			name:        "delete_existing_task",
-			setupTask:   true,
-			taskID:      "1",
-			expectError: false,
+			name:      "delete_existing_task",
+			setupTask: true,
+			taskID:    "1",
 		},
 		{
-			name:        "delete_nonexistent_task",
-			setupTask:   false,
-			taskID:      "999",
-			expectError: false, // Handler returns success=false, not an error
+			name:         "delete_nonexistent_task",
+			setupTask:    false,
+			taskID:       "999",
+			expectedCode: connect.CodeNotFound,
 		},
 		{
-			name:        "delete_empty_id",
-			setupTask:   false,
-			taskID:      "",
-			expectError: false, // Service validation will handle this
+			name:         "delete_empty_id",
+			setupTask:    false,
+			taskID:       "",
+			expectedCode: connect.CodeInvalidArgument,
+		},
+		{
+			name:         "delete_malformed_id",
+			setupTask:    false,
+			taskID:       "abc",
+			expectedCode: connect.CodeInvalidArgument,
 		},
 	}
 
@@ -255,35 +265,120 @@ func TestTaskHandler_DeleteTask(t *testing.T) {
 			taskService := service.NewTaskService(taskStore)
 			handler := NewTaskHandler(taskService)
 			ctx := context.Background()
-			
+
 			if tt.setupTask {
 				_, err := taskStore.CreateTask(ctx, "Test task")
 				require.NoError(t, err)
 			}
-			
+
 			req := connect.NewRequest(&taskv1.DeleteTaskRequest{
 				Id: tt.taskID,
 			})
-			
+
 			// Execute
 			resp, err := handler.DeleteTask(ctx, req)
-			
+
 			// Assert
+			if tt.expectedCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, resp)
+				assert.Equal(t, tt.expectedCode, connect.CodeOf(err))
+				return
+			}
+
 			require.NoError(t, err)
 			require.NotNil(t, resp)
-			require.NotNil(t, resp.Msg)
-			
-			if tt.setupTask && tt.taskID == "1" {
-				assert.True(t, resp.Msg.Success)
-				assert.Contains(t, resp.Msg.Message, "successfully")
-			} else {
-				assert.False(t, resp.Msg.Success)
-				assert.NotEmpty(t, resp.Msg.Message)
-			}
+			assert.True(t, resp.Msg.Success)
+			assert.Contains(t, resp.Msg.Message, "successfully")
+		})
+	}
+}
+
+// deleteErrorStore fails DeleteTask with err when set
+type deleteErrorStore struct {
+	*testutil.MockStore
+	err error
+}
+
+func (s *deleteErrorStore) DeleteTask(ctx context.Context, id string) error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.MockStore.DeleteTask(ctx, id)
+}
+
+func TestTaskHandler_DeleteTask_LegacyResponses(t *testing.T) {
+	tests := []struct {
+		name            string
+		taskID          string
+		storeErr        error
+		expectedMessage string
+	}{
+		{
+			name:            "not_found",
+			taskID:          "999",
+			expectedMessage: "task not found",
+		},
+		{
+			name:            "validation",
+			taskID:          "",
+			expectedMessage: "validation failed for field 'id': task ID cannot be empty",
+		},
+		{
+			name:            "internal_cause_hidden",
+			taskID:          "1",
+			storeErr:        errors.InternalWrap(stderrors.New("dial tcp 10.0.0.1:3306: connection refused"), "failed to delete task"),
+			expectedMessage: "failed to delete task",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &deleteErrorStore{MockStore: testutil.NewMockStore(), err: tt.storeErr}
+			handler := NewTaskHandler(service.NewTaskService(repo), WithLegacyDeleteResponses(true))
+
+			resp, err := handler.DeleteTask(context.Background(), connect.NewRequest(&taskv1.DeleteTaskRequest{
+				Id: tt.taskID,
+			}))
+
+			require.NoError(t, err)
+			require.NotNil(t, resp)
+			assert.False(t, resp.Msg.Success)
+			assert.Equal(t, tt.expectedMessage, resp.Msg.Message)
+			assert.NotContains(t, resp.Msg.Message, "10.0.0.1")
 		})
 	}
 }
 
+func TestTaskHandler_MalformedID(t *testing.T) {
+	handler := NewTaskHandler(service.NewTaskService(testutil.NewMockStore()))
+	ctx := context.Background()
+
+	_, getErr := handler.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: "abc"}))
+	_, updateErr := handler.UpdateTask(ctx, connect.NewRequest(&taskv1.UpdateTaskRequest{
+		Id:          "abc",
+		Description: "Renamed",
+	}))
+
+	for _, err := range []error{getErr, updateErr} {
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+
+		var violations []*errdetails.BadRequest_FieldViolation
+		for _, detail := range connectErr.Details() {
+			value, err := detail.Value()
+			require.NoError(t, err)
+			if badRequest, ok := value.(*errdetails.BadRequest); ok {
+				violations = append(violations, badRequest.FieldViolations...)
+			}
+		}
+		require.Len(t, violations, 1)
+		assert.Equal(t, "id", violations[0].Field)
+		assert.Contains(t, violations[0].Description, "invalid task ID format")
+	}
+}
+
 func TestTaskHandler_CreateTask_ValidationErrors(t *testing.T) {
 	taskStore := testutil.NewMockStore()
 	taskService := service.NewTaskService(taskStore)
@@ -306,23 +401,53 @@ func TestTaskHandler_CreateTask_ValidationErrors(t *testing.T) {
 }
 
 func TestTaskHandler_DeleteTask_WithStoreError(t *testing.T) {
-	taskStore := testutil.NewMockStore()
+	taskStore := &deleteErrorStore{
+		MockStore: testutil.NewMockStore(),
+		err:       errors.InternalWrap(stderrors.New("connection refused"), "failed to delete task"),
+	}
 	taskService := service.NewTaskService(taskStore)
 	handler := NewTaskHandler(taskService)
 	ctx := context.Background()
-	
-	// Try to delete a non-existent task
+
 	req := connect.NewRequest(&taskv1.DeleteTaskRequest{
-		Id: "nonexistent",
+		Id: "1",
 	})
-	
+
 	resp, err := handler.DeleteTask(ctx, req)
-	
-	// The handler should not return an error, but success should be false
+
+	require.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, connect.CodeInternal, connect.CodeOf(err))
+}
+
+func TestTaskHandler_IdempotencyKeyHeader(t *testing.T) {
+	taskStore := testutil.NewMockStore()
+	taskService := service.NewTaskService(taskStore,
+		service.WithIdempotency(store.NewMemoryIdempotencyStore(), time.Hour),
+	)
+	handler := NewTaskHandler(taskService)
+	ctx := context.Background()
+
+	newRequest := func(key string) *connect.Request[taskv1.CreateTaskRequest] {
+		req := connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Retried task"})
+		req.Header().Set(IdempotencyKeyHeader, key)
+		return req
+	}
+
+	first, err := handler.CreateTask(ctx, newRequest("retry-1"))
 	require.NoError(t, err)
-	require.NotNil(t, resp)
-	assert.False(t, resp.Msg.Success)
-	assert.Contains(t, resp.Msg.Message, "not found")
+	replayed, err := handler.CreateTask(ctx, newRequest("retry-1"))
+	require.NoError(t, err)
+	assert.Equal(t, first.Msg.Task.Id, replayed.Msg.Task.Id)
+
+	fresh, err := handler.CreateTask(ctx, newRequest("retry-2"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Msg.Task.Id, fresh.Msg.Task.Id)
+
+	conflicting := connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Different task"})
+	conflicting.Header().Set(IdempotencyKeyHeader, "retry-1")
+	_, err = handler.CreateTask(ctx, conflicting)
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
 }
 
 func TestTaskHandler_IntegrationTest(t *testing.T) {
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	taskv2 "github.com/wcygan/todo/backend/internal/gen/task/v2"
+	"github.com/wcygan/todo/backend/internal/gen/task/v2/taskv2connect"
+	"github.com/wcygan/todo/backend/internal/middleware"
+	"github.com/wcygan/todo/backend/internal/service"
+)
+
+// taskNamePrefix is the collection part of a task resource name
+const taskNamePrefix = "tasks/"
+
+// TaskV2Handler implements the task.v2 TaskService ConnectRPC interface on
+// the same TaskService as TaskHandler
+type TaskV2Handler struct {
+	service *service.TaskService
+}
+
+// NewTaskV2Handler creates a new TaskV2Handler instance
+func NewTaskV2Handler(service *service.TaskService) *TaskV2Handler {
+	return &TaskV2Handler{
+		service: service,
+	}
+}
+
+// CreateTask handles task creation requests
+func (h *TaskV2Handler) CreateTask(
+	ctx context.Context,
+	req *connect.Request[taskv2.CreateTaskRequest],
+) (*connect.Response[taskv2.Task], error) {
+	ctx = service.WithIdempotencyKey(ctx, middleware.ClientName(ctx), req.Header().Get(IdempotencyKeyHeader))
+	task, err := h.service.CreateTask(ctx, req.Msg.GetTask().GetDescription())
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	return connect.NewResponse(toV2Task(task)), nil
+}
+
+// GetTask handles requests to retrieve a single task by name
+func (h *TaskV2Handler) GetTask(
+	ctx context.Context,
+	req *connect.Request[taskv2.GetTaskRequest],
+) (*connect.Response[taskv2.Task], error) {
+	id, err := parseTaskName(req.Msg.Name)
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	task, err := h.service.GetTask(ctx, id)
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	return connect.NewResponse(toV2Task(task)), nil
+}
+
+// ListTasks handles requests to list tasks a page at a time
+func (h *TaskV2Handler) ListTasks(
+	ctx context.Context,
+	req *connect.Request[taskv2.ListTasksRequest],
+) (*connect.Response[taskv2.ListTasksResponse], error) {
+	page, err := h.service.ListTasksPage(ctx, int(req.Msg.PageSize), req.Msg.PageToken)
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	tasks := make([]*taskv2.Task, len(page.Tasks))
+	for i, task := range page.Tasks {
+		tasks[i] = toV2Task(task)
+	}
+	return connect.NewResponse(&taskv2.ListTasksResponse{
+		Tasks:         tasks,
+		NextPageToken: page.NextPageToken,
+	}), nil
+}
+
+// UpdateTask handles task update requests, changing only the fields named
+// in update_mask
+func (h *TaskV2Handler) UpdateTask(
+	ctx context.Context,
+	req *connect.Request[taskv2.UpdateTaskRequest],
+) (*connect.Response[taskv2.Task], error) {
+	id, err := parseTaskName(req.Msg.GetTask().GetName())
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+	patch, err := taskPatch(req.Msg.GetTask(), req.Msg.UpdateMask)
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	ctx = service.WithIdempotencyKey(ctx, middleware.ClientName(ctx), req.Header().Get(IdempotencyKeyHeader))
+	task, err := h.service.PatchTask(ctx, id, patch)
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	return connect.NewResponse(toV2Task(task)), nil
+}
+
+// DeleteTask handles task deletion requests
+func (h *TaskV2Handler) DeleteTask(
+	ctx context.Context,
+	req *connect.Request[taskv2.DeleteTaskRequest],
+) (*connect.Response[emptypb.Empty], error) {
+	id, err := parseTaskName(req.Msg.Name)
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	ctx = service.WithIdempotencyKey(ctx, middleware.ClientName(ctx), req.Header().Get(IdempotencyKeyHeader))
+	if err := h.service.DeleteTask(ctx, id); err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+// exportChunkSize is the size of the data chunks sent by ExportTasks
+const exportChunkSize = 32 * 1024
+
+// ExportTasks streams every task in the requested format
+func (h *TaskV2Handler) ExportTasks(
+	ctx context.Context,
+	req *connect.Request[taskv2.ExportTasksRequest],
+	stream *connect.ServerStream[taskv2.ExportTasksResponse],
+) error {
+	format, err := taskFormat(req.Msg.Format)
+	if err != nil {
+		return errors.ToConnectError(err)
+	}
+
+	w := bufio.NewWriterSize(exportWriter{stream}, exportChunkSize)
+	if err := h.service.ExportTasks(ctx, format, w); err != nil {
+		return errors.ToConnectError(err)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.ToConnectError(err)
+	}
+	return nil
+}
+
+// ImportTasks handles requests to create tasks from a file
+func (h *TaskV2Handler) ImportTasks(
+	ctx context.Context,
+	req *connect.Request[taskv2.ImportTasksRequest],
+) (*connect.Response[taskv2.ImportTasksResponse], error) {
+	format, err := taskFormat(req.Msg.Format)
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	result, err := h.service.ImportTasks(ctx, format, bytes.NewReader(req.Msg.Data), service.ImportOptions{
+		DryRun: req.Msg.DryRun,
+	})
+	if err != nil {
+		return nil, errors.ToConnectError(err)
+	}
+
+	resp := &taskv2.ImportTasksResponse{DryRun: result.DryRun}
+	for _, task := range result.Tasks {
+		if task.Id == "" {
+			// Dry runs report tasks that have not been given an ID yet
+			resp.Tasks = append(resp.Tasks, &taskv2.Task{
+				Description: task.Description,
+				Completed:   task.Completed,
+			})
+			continue
+		}
+		resp.Tasks = append(resp.Tasks, toV2Task(task))
+	}
+	for _, rowErr := range result.Errors {
+		resp.Errors = append(resp.Errors, &taskv2.ImportRowError{
+			Line:    int32(rowErr.Line),
+			Field:   rowErr.Field,
+			Message: rowErr.Message,
+		})
+	}
+	for _, duplicate := range result.Duplicates {
+		resp.Duplicates = append(resp.Duplicates, &taskv2.ImportDuplicate{
+			Line:        int32(duplicate.Line),
+			Description: duplicate.Description,
+		})
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// Verify that TaskV2Handler implements the interface
+var _ taskv2connect.TaskServiceHandler = (*TaskV2Handler)(nil)
+
+// taskName returns the resource name of the task with the given ID
+func taskName(id string) string {
+	return taskNamePrefix + id
+}
+
+// parseTaskName returns the task ID from a tasks/{task} resource name
+func parseTaskName(name string) (string, error) {
+	id, ok := strings.CutPrefix(name, taskNamePrefix)
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", errors.Validation("name", fmt.Sprintf("resource name %q must have the form tasks/{task}", name))
+	}
+	return id, nil
+}
+
+// toV2Task converts a stored task to its task.v2 representation
+func toV2Task(task *taskv1.Task) *taskv2.Task {
+	return &taskv2.Task{
+		Name:        taskName(task.Id),
+		Description: task.Description,
+		Completed:   task.Completed,
+		CreateTime:  task.CreatedAt,
+		UpdateTime:  task.UpdatedAt,
+	}
+}
+
+// taskPatch selects the fields of task named by mask. An empty mask selects
+// the fields that are set to a non-default value, and "*" selects all of
+// them.
+func taskPatch(task *taskv2.Task, mask *fieldmaskpb.FieldMask) (service.TaskPatch, error) {
+	var patch service.TaskPatch
+	if task == nil {
+		return patch, errors.Validation("task", "task is required")
+	}
+
+	paths := mask.GetPaths()
+	if len(paths) == 0 {
+		if task.Description != "" {
+			patch.Description = &task.Description
+		}
+		if task.Completed {
+			patch.Completed = &task.Completed
+		}
+		return patch, nil
+	}
+
+	for _, path := range paths {
+		switch path {
+		case "*":
+			patch.Description = &task.Description
+			patch.Completed = &task.Completed
+		case "description":
+			patch.Description = &task.Description
+		case "completed":
+			patch.Completed = &task.Completed
+		default:
+			return patch, errors.Validation("update_mask", fmt.Sprintf("field %q cannot be updated", path))
+		}
+	}
+	return patch, nil
+}
+
+// taskFormat converts a task.v2 TaskFormat to the service format
+func taskFormat(format taskv2.TaskFormat) (service.TaskFormat, error) {
+	switch format {
+	case taskv2.TaskFormat_TASK_FORMAT_NDJSON:
+		return service.FormatNDJSON, nil
+	case taskv2.TaskFormat_TASK_FORMAT_CSV:
+		return service.FormatCSV, nil
+	case taskv2.TaskFormat_TASK_FORMAT_MARKDOWN:
+		return service.FormatMarkdown, nil
+	default:
+		return 0, errors.Validation("format", "format must be TASK_FORMAT_NDJSON, TASK_FORMAT_CSV or TASK_FORMAT_MARKDOWN")
+	}
+}
+
+// exportWriter sends each write as one ExportTasksResponse. It sits behind a
+// bufio.Writer, which turns the encoder's small writes into full chunks.
+type exportWriter struct {
+	stream *connect.ServerStream[taskv2.ExportTasksResponse]
+}
+
+func (w exportWriter) Write(p []byte) (int, error) {
+	data := p
+	for len(data) > 0 {
+		n := min(len(data), exportChunkSize)
+		if err := w.stream.Send(&taskv2.ExportTasksResponse{Data: data[:n]}); err != nil {
+			return 0, err
+		}
+		data = data[n:]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	taskv2 "github.com/wcygan/todo/backend/internal/gen/task/v2"
+	"github.com/wcygan/todo/backend/internal/gen/task/v2/taskv2connect"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// newHandlers returns v1 and v2 handlers sharing one TaskService
+func newHandlers() (*TaskHandler, *TaskV2Handler) {
+	taskService := service.NewTaskService(testutil.NewMockStore())
+	return NewTaskHandler(taskService), NewTaskV2Handler(taskService)
+}
+
+// assertEquivalent checks that a v2 task represents the v1 task
+func assertEquivalent(t *testing.T, v1 *taskv1.Task, v2 *taskv2.Task) {
+	t.Helper()
+	assert.Equal(t, "tasks/"+v1.Id, v2.Name)
+	assert.Equal(t, v1.Description, v2.Description)
+	assert.Equal(t, v1.Completed, v2.Completed)
+	assert.True(t, v1.CreatedAt.AsTime().Equal(v2.CreateTime.AsTime()))
+	assert.True(t, v1.UpdatedAt.AsTime().Equal(v2.UpdateTime.AsTime()))
+}
+
+func TestToV2Task(t *testing.T) {
+	now := time.Now()
+	v1 := &taskv1.Task{
+		Id:          "42",
+		Description: "Buy milk",
+		Completed:   true,
+		CreatedAt:   timestamppb.New(now.Add(-time.Hour)),
+		UpdatedAt:   timestamppb.New(now),
+	}
+
+	assertEquivalent(t, v1, toV2Task(v1))
+}
+
+func TestParseTaskName(t *testing.T) {
+	id, err := parseTaskName("tasks/42")
+	require.NoError(t, err)
+	assert.Equal(t, "42", id)
+	assert.Equal(t, "tasks/42", taskName(id))
+
+	for _, name := range []string{"", "42", "tasks/", "projects/1/tasks/42", "tasks/42/extra", "task/42"} {
+		_, err := parseTaskName(name)
+		assert.Error(t, err, "name %q", name)
+	}
+}
+
+func TestTaskPatch(t *testing.T) {
+	task := &taskv2.Task{Name: "tasks/1", Description: "Buy milk", Completed: false}
+
+	tests := []struct {
+		name              string
+		task              *taskv2.Task
+		paths             []string
+		expectDescription bool
+		expectCompleted   bool
+		expectError       bool
+	}{
+		{name: "description", task: task, paths: []string{"description"}, expectDescription: true},
+		{name: "completed", task: task, paths: []string{"completed"}, expectCompleted: true},
+		{name: "wildcard", task: task, paths: []string{"*"}, expectDescription: true, expectCompleted: true},
+		{name: "empty_mask_uses_set_fields", task: task, expectDescription: true},
+		{name: "output_only_field", task: task, paths: []string{"create_time"}, expectError: true},
+		{name: "unknown_field", task: task, paths: []string{"title"}, expectError: true},
+		{name: "missing_task", paths: []string{"description"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mask *fieldmaskpb.FieldMask
+			if tt.paths != nil {
+				mask = &fieldmaskpb.FieldMask{Paths: tt.paths}
+			}
+
+			patch, err := taskPatch(tt.task, mask)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectDescription, patch.Description != nil)
+			assert.Equal(t, tt.expectCompleted, patch.Completed != nil)
+		})
+	}
+}
+
+func TestTaskV2Handler_EquivalentToV1(t *testing.T) {
+	v1Handler, v2Handler := newHandlers()
+	ctx := context.Background()
+
+	// Tasks created through either version are visible through the other
+	created, err := v2Handler.CreateTask(ctx, connect.NewRequest(&taskv2.CreateTaskRequest{
+		Task: &taskv2.Task{Description: "Buy milk"},
+	}))
+	require.NoError(t, err)
+
+	id, err := parseTaskName(created.Msg.Name)
+	require.NoError(t, err)
+	v1Task, err := v1Handler.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: id}))
+	require.NoError(t, err)
+	assertEquivalent(t, v1Task.Msg.Task, created.Msg)
+
+	_, err = v1Handler.CreateTask(ctx, connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Walk dog"}))
+	require.NoError(t, err)
+
+	// ListTasks pages through what GetAllTasks returns at once
+	all, err := v1Handler.GetAllTasks(ctx, connect.NewRequest(&taskv1.GetAllTasksRequest{}))
+	require.NoError(t, err)
+
+	var listed []*taskv2.Task
+	token := ""
+	for {
+		page, err := v2Handler.ListTasks(ctx, connect.NewRequest(&taskv2.ListTasksRequest{PageSize: 1, PageToken: token}))
+		require.NoError(t, err)
+		listed = append(listed, page.Msg.Tasks...)
+		if page.Msg.NextPageToken == "" {
+			break
+		}
+		token = page.Msg.NextPageToken
+	}
+	require.Len(t, listed, len(all.Msg.Tasks))
+	for i := range listed {
+		assertEquivalent(t, all.Msg.Tasks[i], listed[i])
+	}
+
+	// A masked update changes only the named fields
+	updated, err := v2Handler.UpdateTask(ctx, connect.NewRequest(&taskv2.UpdateTaskRequest{
+		Task:       &taskv2.Task{Name: created.Msg.Name, Completed: true},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"completed"}},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "Buy milk", updated.Msg.Description)
+	assert.True(t, updated.Msg.Completed)
+
+	v1Task, err = v1Handler.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: id}))
+	require.NoError(t, err)
+	assertEquivalent(t, v1Task.Msg.Task, updated.Msg)
+
+	// Deletes are visible to both versions with the same error codes
+	_, err = v2Handler.DeleteTask(ctx, connect.NewRequest(&taskv2.DeleteTaskRequest{Name: created.Msg.Name}))
+	require.NoError(t, err)
+
+	_, v1Err := v1Handler.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: id}))
+	_, v2Err := v2Handler.GetTask(ctx, connect.NewRequest(&taskv2.GetTaskRequest{Name: created.Msg.Name}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(v1Err))
+	assert.Equal(t, connect.CodeOf(v1Err), connect.CodeOf(v2Err))
+
+	_, v1Err = v1Handler.DeleteTask(ctx, connect.NewRequest(&taskv1.DeleteTaskRequest{Id: id}))
+	_, v2Err = v2Handler.DeleteTask(ctx, connect.NewRequest(&taskv2.DeleteTaskRequest{Name: created.Msg.Name}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(v1Err))
+	assert.Equal(t, connect.CodeOf(v1Err), connect.CodeOf(v2Err))
+}
+
+func TestTaskV2Handler_InvalidArguments(t *testing.T) {
+	_, v2Handler := newHandlers()
+	ctx := context.Background()
+
+	_, err := v2Handler.CreateTask(ctx, connect.NewRequest(&taskv2.CreateTaskRequest{}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	_, err = v2Handler.GetTask(ctx, connect.NewRequest(&taskv2.GetTaskRequest{Name: "1"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	_, err = v2Handler.GetTask(ctx, connect.NewRequest(&taskv2.GetTaskRequest{Name: "tasks/abc"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	_, err = v2Handler.UpdateTask(ctx, connect.NewRequest(&taskv2.UpdateTaskRequest{
+		Task:       &taskv2.Task{Name: "tasks/1"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	_, err = v2Handler.ListTasks(ctx, connect.NewRequest(&taskv2.ListTasksRequest{PageToken: "garbage!"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+// importStore adds store.TaskImporter to the mock store
+type importStore struct {
+	*testutil.MockStore
+}
+
+func (s *importStore) ImportTasks(ctx context.Context, tasks []store.NewTask) ([]*taskv1.Task, error) {
+	imported := make([]*taskv1.Task, len(tasks))
+	for i, task := range tasks {
+		created, err := s.CreateTask(ctx, task.Description)
+		if err != nil {
+			return nil, err
+		}
+		if imported[i], err = s.UpdateTask(ctx, created.Id, task.Description, task.Completed); err != nil {
+			return nil, err
+		}
+	}
+	return imported, nil
+}
+
+// newTransferClient serves a TaskV2Handler over HTTP, since ExportTasks
+// streams
+func newTransferClient(t *testing.T, repo store.TaskRepository) taskv2connect.TaskServiceClient {
+	mux := http.NewServeMux()
+	mux.Handle(taskv2connect.NewTaskServiceHandler(NewTaskV2Handler(service.NewTaskService(repo))))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return taskv2connect.NewTaskServiceClient(server.Client(), server.URL)
+}
+
+func TestTaskV2Handler_ImportExport(t *testing.T) {
+	repo := &importStore{MockStore: testutil.NewMockStore()}
+	client := newTransferClient(t, repo)
+	ctx := context.Background()
+
+	data := []byte("- [ ] Buy milk\n- [x] Walk dog\n- [ ] buy milk\n")
+	dryRun, err := client.ImportTasks(ctx, connect.NewRequest(&taskv2.ImportTasksRequest{
+		Format: taskv2.TaskFormat_TASK_FORMAT_MARKDOWN,
+		Data:   data,
+		DryRun: true,
+	}))
+	require.NoError(t, err)
+	assert.True(t, dryRun.Msg.DryRun)
+	assert.Len(t, dryRun.Msg.Tasks, 2)
+	assert.Empty(t, dryRun.Msg.Tasks[0].Name)
+	assert.Equal(t, 0, repo.TaskCount())
+
+	imported, err := client.ImportTasks(ctx, connect.NewRequest(&taskv2.ImportTasksRequest{
+		Format: taskv2.TaskFormat_TASK_FORMAT_MARKDOWN,
+		Data:   data,
+	}))
+	require.NoError(t, err)
+	require.Len(t, imported.Msg.Tasks, 2)
+	assert.NotEmpty(t, imported.Msg.Tasks[0].Name)
+	assert.True(t, imported.Msg.Tasks[1].Completed)
+	require.Len(t, imported.Msg.Duplicates, 1)
+	assert.Equal(t, int32(3), imported.Msg.Duplicates[0].Line)
+	assert.Equal(t, 2, repo.TaskCount())
+
+	stream, err := client.ExportTasks(ctx, connect.NewRequest(&taskv2.ExportTasksRequest{
+		Format: taskv2.TaskFormat_TASK_FORMAT_MARKDOWN,
+	}))
+	require.NoError(t, err)
+	var exported []byte
+	for stream.Receive() {
+		exported = append(exported, stream.Msg().Data...)
+	}
+	require.NoError(t, stream.Err())
+	lines := strings.Split(strings.TrimSuffix(string(exported), "\n"), "\n")
+	assert.ElementsMatch(t, []string{"- [ ] Buy milk", "- [x] Walk dog"}, lines)
+}
+
+func TestTaskV2Handler_ImportErrors(t *testing.T) {
+	repo := &importStore{MockStore: testutil.NewMockStore()}
+	client := newTransferClient(t, repo)
+	ctx := context.Background()
+
+	resp, err := client.ImportTasks(ctx, connect.NewRequest(&taskv2.ImportTasksRequest{
+		Format: taskv2.TaskFormat_TASK_FORMAT_CSV,
+		Data:   []byte("description,completed\nBuy milk,true\nWalk dog,maybe\n"),
+	}))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Errors, 1)
+	assert.Equal(t, int32(3), resp.Msg.Errors[0].Line)
+	assert.Equal(t, "completed", resp.Msg.Errors[0].Field)
+	assert.Empty(t, resp.Msg.Tasks)
+	assert.Equal(t, 0, repo.TaskCount())
+
+	_, err = client.ImportTasks(ctx, connect.NewRequest(&taskv2.ImportTasksRequest{Data: []byte("- [ ] Buy milk\n")}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	stream, err := client.ExportTasks(ctx, connect.NewRequest(&taskv2.ExportTasksRequest{}))
+	require.NoError(t, err)
+	assert.False(t, stream.Receive())
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(stream.Err()))
+}
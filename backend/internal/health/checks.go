@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	errNotStarted   = errors.New("server has not finished starting")
+	errShuttingDown = errors.New("server is shutting down")
+)
+
+// Pinger is implemented by anything that can cheaply verify connectivity,
+// such as *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck returns a check that pings p
+func PingCheck(p Pinger) CheckFunc {
+	return func(ctx context.Context) error {
+		return p.PingContext(ctx)
+	}
+}
+
+// PoolSaturationCheck returns a check that fails when any pool has more than
+// threshold of its MaxOpenConnections in use. Pools without a connection
+// limit, and a threshold of zero, never fail.
+func PoolSaturationCheck(pools map[string]*sql.DB, threshold float64) CheckFunc {
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(context.Context) error {
+		if threshold <= 0 {
+			return nil
+		}
+
+		var saturated []string
+		for _, name := range names {
+			stats := pools[name].Stats()
+			if stats.MaxOpenConnections <= 0 {
+				continue
+			}
+			if float64(stats.InUse)/float64(stats.MaxOpenConnections) > threshold {
+				saturated = append(saturated, fmt.Sprintf("%s (%d/%d in use)", name, stats.InUse, stats.MaxOpenConnections))
+			}
+		}
+
+		if len(saturated) > 0 {
+			return fmt.Errorf("connection pool saturated: %s", strings.Join(saturated, ", "))
+		}
+		return nil
+	}
+}
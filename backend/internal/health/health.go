@@ -0,0 +1,233 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status values reported by probes and individual checks
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// defaultCheckTimeout bounds every check when the registry has no timeout set
+const defaultCheckTimeout = 2 * time.Second
+
+// CheckFunc reports the health of a single dependency. A nil error means the
+// dependency is healthy.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the JSON body returned by every probe endpoint
+type Report struct {
+	Status  string        `json:"status"`
+	Service string        `json:"service"`
+	Probe   string        `json:"probe"`
+	Checks  []CheckResult `json:"checks"`
+}
+
+// check is a named CheckFunc
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Registry holds the checks behind the /livez, /readyz and /startupz probes.
+// Liveness checks should only cover the process itself so that a dependency
+// outage never restarts the container; dependencies belong in readiness.
+type Registry struct {
+	service string
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []check
+	readiness []check
+	startup   []check
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry creates a registry whose checks are each bounded by timeout
+func NewRegistry(service string, timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	r := &Registry{service: service, timeout: timeout}
+	r.startup = []check{{name: "startup", fn: r.checkStarted}}
+	r.readiness = []check{{name: "shutdown", fn: r.checkNotShuttingDown}}
+	return r
+}
+
+// AddLivenessCheck registers a check served by /livez
+func (r *Registry) AddLivenessCheck(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, check{name: name, fn: fn})
+}
+
+// AddReadinessCheck registers a check served by /readyz
+func (r *Registry) AddReadinessCheck(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, check{name: name, fn: fn})
+}
+
+// AddStartupCheck registers a check served by /startupz
+func (r *Registry) AddStartupCheck(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.startup = append(r.startup, check{name: name, fn: fn})
+}
+
+// MarkStarted records that initialization finished and the server is
+// accepting connections
+func (r *Registry) MarkStarted() {
+	r.started.Store(true)
+}
+
+// MarkShuttingDown makes /readyz fail so that load balancers stop routing
+// new requests while in-flight ones drain
+func (r *Registry) MarkShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Liveness runs the liveness checks
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, "liveness", r.checks(&r.liveness))
+}
+
+// Readiness runs the readiness checks
+func (r *Registry) Readiness(ctx context.Context) Report {
+	return r.run(ctx, "readiness", r.checks(&r.readiness))
+}
+
+// Startup runs the startup checks
+func (r *Registry) Startup(ctx context.Context) Report {
+	return r.run(ctx, "startup", r.checks(&r.startup))
+}
+
+// LivezHandler serves the liveness probe
+func (r *Registry) LivezHandler() http.Handler {
+	return reportHandler(r.Liveness)
+}
+
+// ReadyzHandler serves the readiness probe
+func (r *Registry) ReadyzHandler() http.Handler {
+	return reportHandler(r.Readiness)
+}
+
+// StartupzHandler serves the startup probe
+func (r *Registry) StartupzHandler() http.Handler {
+	return reportHandler(r.Startup)
+}
+
+// Register mounts every probe endpoint on mux
+func (r *Registry) Register(mux *http.ServeMux) {
+	mux.Handle("/livez", r.LivezHandler())
+	mux.Handle("/readyz", r.ReadyzHandler())
+	mux.Handle("/startupz", r.StartupzHandler())
+}
+
+// checks returns a snapshot of the given check list
+func (r *Registry) checks(list *[]check) []check {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]check(nil), (*list)...)
+}
+
+// run executes checks concurrently and aggregates the results in
+// registration order
+func (r *Registry) run(ctx context.Context, probe string, checks []check) Report {
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = r.runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:  StatusOK,
+		Service: r.service,
+		Probe:   probe,
+		Checks:  results,
+	}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusUnavailable
+			break
+		}
+	}
+	return report
+}
+
+// runCheck executes a single check under the registry timeout
+func (r *Registry) runCheck(ctx context.Context, c check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(ctx)
+	elapsed := time.Since(start)
+
+	result := CheckResult{
+		Name:      c.name,
+		Status:    StatusOK,
+		LatencyMs: float64(elapsed.Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkStarted fails until MarkStarted is called
+func (r *Registry) checkStarted(context.Context) error {
+	if !r.started.Load() {
+		return errNotStarted
+	}
+	return nil
+}
+
+// checkNotShuttingDown fails once MarkShuttingDown is called
+func (r *Registry) checkNotShuttingDown(context.Context) error {
+	if r.shuttingDown.Load() {
+		return errShuttingDown
+	}
+	return nil
+}
+
+// reportHandler writes the report as JSON with 200 or 503
+func reportHandler(probe func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := probe(req.Context())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status == StatusOK {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,226 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, handler http.Handler) (int, Report) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func findCheck(t *testing.T, report Report, name string) CheckResult {
+	t.Helper()
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("check %q not found in %+v", name, report.Checks)
+	return CheckResult{}
+}
+
+func TestRegistry_Liveness(t *testing.T) {
+	registry := NewRegistry("todo-backend", time.Second)
+
+	code, report := serve(t, registry.LivezHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, "todo-backend", report.Service)
+	assert.Equal(t, "liveness", report.Probe)
+	assert.Empty(t, report.Checks)
+}
+
+func TestRegistry_Readiness(t *testing.T) {
+	registry := NewRegistry("todo-backend", time.Second)
+	registry.AddReadinessCheck("database", func(context.Context) error { return nil })
+
+	code, report := serve(t, registry.ReadyzHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, StatusOK, findCheck(t, report, "database").Status)
+	assert.Equal(t, StatusOK, findCheck(t, report, "shutdown").Status)
+
+	t.Run("FailingCheck", func(t *testing.T) {
+		registry.AddReadinessCheck("cache", func(context.Context) error { return errors.New("cache down") })
+
+		code, report := serve(t, registry.ReadyzHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusUnavailable, report.Status)
+
+		result := findCheck(t, report, "cache")
+		assert.Equal(t, StatusUnavailable, result.Status)
+		assert.Equal(t, "cache down", result.Error)
+		assert.Equal(t, StatusOK, findCheck(t, report, "database").Status)
+	})
+}
+
+func TestRegistry_ReadinessFlipsOnShutdown(t *testing.T) {
+	registry := NewRegistry("todo-backend", time.Second)
+	registry.MarkStarted()
+
+	code, _ := serve(t, registry.ReadyzHandler())
+	assert.Equal(t, http.StatusOK, code)
+
+	registry.MarkShuttingDown()
+
+	code, report := serve(t, registry.ReadyzHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, errShuttingDown.Error(), findCheck(t, report, "shutdown").Error)
+
+	// Liveness must not be affected, or the container would be killed mid-drain
+	code, _ = serve(t, registry.LivezHandler())
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestRegistry_Startup(t *testing.T) {
+	registry := NewRegistry("todo-backend", time.Second)
+	registry.AddStartupCheck("migrations", func(context.Context) error { return nil })
+
+	code, report := serve(t, registry.StartupzHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "startup", report.Probe)
+	assert.Equal(t, StatusUnavailable, findCheck(t, report, "startup").Status)
+
+	registry.MarkStarted()
+
+	code, report = serve(t, registry.StartupzHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, report.Checks, 2)
+}
+
+func TestRegistry_CheckTimeout(t *testing.T) {
+	registry := NewRegistry("todo-backend", 20*time.Millisecond)
+	registry.AddReadinessCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	code, report := serve(t, registry.ReadyzHandler())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	result := findCheck(t, report, "slow")
+	assert.Equal(t, context.DeadlineExceeded.Error(), result.Error)
+	assert.GreaterOrEqual(t, result.LatencyMs, float64(20))
+}
+
+func TestRegistry_ChecksRunConcurrently(t *testing.T) {
+	registry := NewRegistry("todo-backend", time.Second)
+	for _, name := range []string{"a", "b", "c"} {
+		registry.AddReadinessCheck(name, func(context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})
+	}
+
+	start := time.Now()
+	code, report := serve(t, registry.ReadyzHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Less(t, time.Since(start), 140*time.Millisecond)
+
+	// Results keep registration order regardless of completion order
+	names := make([]string, 0, len(report.Checks))
+	for _, c := range report.Checks {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"shutdown", "a", "b", "c"}, names)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry("todo-backend", time.Second)
+	registry.MarkStarted()
+
+	mux := http.NewServeMux()
+	registry.Register(mux)
+
+	for _, path := range []string{"/livez", "/readyz", "/startupz"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"), path)
+	}
+}
+
+type fakePinger struct {
+	err error
+}
+
+func (p fakePinger) PingContext(context.Context) error {
+	return p.err
+}
+
+func TestPingCheck(t *testing.T) {
+	assert.NoError(t, PingCheck(fakePinger{})(context.Background()))
+
+	err := errors.New("connection refused")
+	assert.Equal(t, err, PingCheck(fakePinger{err: err})(context.Background()))
+}
+
+// stubDriver hands out connections that are never used for queries, so
+// tests can hold them to drive sql.DBStats.InUse
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+type stubConn struct{}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (stubConn) Close() error                        { return nil }
+func (stubConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("health-stub", stubDriver{})
+}
+
+func TestPoolSaturationCheck(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("health-stub", "")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(4)
+
+	pools := map[string]*sql.DB{"primary": db}
+	check := PoolSaturationCheck(pools, 0.5)
+	assert.NoError(t, check(ctx))
+
+	var conns []*sql.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err)
+		conns = append(conns, conn)
+	}
+
+	err = check(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "primary (3/4 in use)")
+
+	t.Run("DisabledThreshold", func(t *testing.T) {
+		assert.NoError(t, PoolSaturationCheck(pools, 0)(ctx))
+	})
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	assert.NoError(t, check(ctx))
+}
@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/wcygan/todo/backend/internal/config"
 )
 
@@ -21,6 +23,8 @@ const (
 // Logger wraps slog.Logger with additional functionality
 type Logger struct {
 	*slog.Logger
+	// level is shared by every logger derived from the same New call
+	level *slog.LevelVar
 }
 
 // New creates a new logger based on the configuration
@@ -28,8 +32,10 @@ func New(cfg *config.Config) *Logger {
 	var handler slog.Handler
 
 	// Configure handler based on format
+	level := &slog.LevelVar{}
+	level.Set(parseLogLevel(cfg.Logger.Level))
 	opts := &slog.HandlerOptions{
-		Level: parseLogLevel(cfg.Logger.Level),
+		Level: level,
 	}
 
 	if cfg.Logger.Format == "json" {
@@ -40,9 +46,24 @@ func New(cfg *config.Config) *Logger {
 
 	return &Logger{
 		Logger: slog.New(handler),
+		level:  level,
+	}
+}
+
+// SetLevel changes the minimum level of this logger and every logger
+// derived from it
+func (l *Logger) SetLevel(level string) {
+	if l.level != nil {
+		l.level.Set(parseLogLevel(level))
 	}
 }
 
+// ApplyConfig applies the reloadable logger settings in cfg. It can be
+// passed to config.Store.Subscribe.
+func (l *Logger) ApplyConfig(cfg *config.Config) {
+	l.SetLevel(cfg.Logger.Level)
+}
+
 // WithContext creates a new logger with context-specific fields
 func (l *Logger) WithContext(ctx context.Context) *Logger {
 	logger := l.Logger
@@ -57,13 +78,22 @@ func (l *Logger) WithContext(ctx context.Context) *Logger {
 		logger = logger.With("operation", operation)
 	}
 
-	return &Logger{Logger: logger}
+	// Add trace and span IDs if the context carries a valid span
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		logger = logger.With(
+			"trace_id", spanCtx.TraceID().String(),
+			"span_id", spanCtx.SpanID().String(),
+		)
+	}
+
+	return &Logger{Logger: logger, level: l.level}
 }
 
 // WithOperation creates a new logger with an operation field
 func (l *Logger) WithOperation(operation string) *Logger {
 	return &Logger{
 		Logger: l.Logger.With("operation", operation),
+		level:  l.level,
 	}
 }
 
@@ -71,6 +101,7 @@ func (l *Logger) WithOperation(operation string) *Logger {
 func (l *Logger) WithRequestID(requestID string) *Logger {
 	return &Logger{
 		Logger: l.Logger.With("request_id", requestID),
+		level:  l.level,
 	}
 }
 
@@ -78,6 +109,7 @@ func (l *Logger) WithRequestID(requestID string) *Logger {
 func (l *Logger) WithError(err error) *Logger {
 	return &Logger{
 		Logger: l.Logger.With("error", err.Error()),
+		level:  l.level,
 	}
 }
 
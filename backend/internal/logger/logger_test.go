@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/wcygan/todo/backend/internal/config"
 )
@@ -243,4 +244,54 @@ func TestLoggerWithContextEmpty(t *testing.T) {
 	ctx = AddOperationToContext(ctx, "")
 	contextLogger = logger.WithContext(ctx)
 	assert.NotNil(t, contextLogger)
-}
\ No newline at end of file
+}
+
+func TestLoggerWithContext_TraceIDs(t *testing.T) {
+	var buf bytes.Buffer
+
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})
+	logger := &Logger{Logger: slog.New(handler)}
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.LogInfo(ctx, "traced message")
+
+	var logEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", logEntry["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", logEntry["span_id"])
+
+	// Untraced contexts must not produce empty IDs
+	buf.Reset()
+	logger.LogInfo(context.Background(), "untraced message")
+	logEntry = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &logEntry))
+	assert.NotContains(t, logEntry, "trace_id")
+	assert.NotContains(t, logEntry, "span_id")
+}
+
+func TestLogger_ApplyConfig(t *testing.T) {
+	cfg := &config.Config{Logger: config.LoggerConfig{Level: "info", Format: "json"}}
+	logger := New(cfg)
+	derived := logger.WithOperation("CreateTask")
+	ctx := context.Background()
+	assert.False(t, derived.Enabled(ctx, slog.LevelDebug))
+
+	logger.ApplyConfig(&config.Config{Logger: config.LoggerConfig{Level: "debug"}})
+	assert.True(t, logger.Enabled(ctx, slog.LevelDebug))
+	assert.True(t, derived.Enabled(ctx, slog.LevelDebug))
+
+	derived.SetLevel("error")
+	assert.False(t, logger.Enabled(ctx, slog.LevelWarn))
+}
@@ -1,10 +1,14 @@
 package logger
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"net/http"
+	"sync"
 	"time"
+
+	"connectrpc.com/connect"
 )
 
 // RequestLoggingMiddleware adds request logging and request ID tracking
@@ -18,7 +22,10 @@ func RequestLoggingMiddleware(logger *Logger) func(http.Handler) http.Handler {
 			
 			// Add request ID to context
 			ctx := AddRequestIDToContext(r.Context(), requestID)
-			r = r.WithContext(ctx)
+
+			// Let StatusInterceptor report the RPC outcome back to us
+			status := &rpcStatus{}
+			r = r.WithContext(context.WithValue(ctx, rpcStatusKey{}, status))
 			
 			// Add request ID to response headers for debugging
 			w.Header().Set("X-Request-ID", requestID)
@@ -42,11 +49,15 @@ func RequestLoggingMiddleware(logger *Logger) func(http.Handler) http.Handler {
 			
 			// Log response
 			duration := time.Since(start)
-			logger.LogInfo(ctx, "request completed",
+			fields := []any{
 				"status_code", wrappedWriter.statusCode,
 				"duration_ms", duration.Milliseconds(),
 				"duration", duration.String(),
-			)
+			}
+			if procedure, code, ok := status.get(); ok {
+				fields = append(fields, "procedure", procedure, "rpc_code", code)
+			}
+			logger.LogInfo(ctx, "request completed", fields...)
 		})
 	}
 }
@@ -62,6 +73,87 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush implements http.Flusher so that streaming RPCs are not buffered
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// rpcStatusKey is the context key for the per-request rpcStatus
+type rpcStatusKey struct{}
+
+// rpcStatus carries the Connect outcome of a request from StatusInterceptor
+// back to RequestLoggingMiddleware. The HTTP status alone is not enough: gRPC
+// always answers 200 and reports the code in trailers.
+type rpcStatus struct {
+	mu        sync.Mutex
+	procedure string
+	code      string
+}
+
+// set records the outcome of an RPC
+func (s *rpcStatus) set(procedure string, err error) {
+	code := "ok"
+	if err != nil {
+		code = connect.CodeOf(err).String()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.procedure = procedure
+	s.code = code
+}
+
+// get returns the recorded outcome, if any
+func (s *rpcStatus) get() (procedure, code string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.procedure, s.code, s.procedure != ""
+}
+
+// statusInterceptor reports RPC outcomes to RequestLoggingMiddleware
+type statusInterceptor struct{}
+
+// StatusInterceptor returns a Connect interceptor that makes
+// RequestLoggingMiddleware log the procedure and Connect code of every RPC.
+// Register it first so it sees the final error from every other interceptor.
+func StatusInterceptor() connect.Interceptor {
+	return statusInterceptor{}
+}
+
+// WrapUnary implements connect.Interceptor
+func (statusInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if status, ok := ctx.Value(rpcStatusKey{}).(*rpcStatus); ok && !req.Spec().IsClient {
+			status.set(req.Spec().Procedure, err)
+		}
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (statusInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (statusInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		err := next(ctx, conn)
+		if status, ok := ctx.Value(rpcStatusKey{}).(*rpcStatus); ok {
+			status.set(conn.Spec().Procedure, err)
+		}
+		return err
+	}
+}
+
 // generateRequestID generates a random request ID
 func generateRequestID() string {
 	bytes := make([]byte, 8) // 16 character hex string
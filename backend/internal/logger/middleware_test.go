@@ -2,13 +2,17 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"connectrpc.com/connect"
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -121,6 +125,74 @@ func TestRequestLoggingMiddlewareErrorResponse(t *testing.T) {
 	assert.Equal(t, float64(500), completedLog["status_code"])
 }
 
+func TestRequestLoggingMiddleware_RecordsConnectCode(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+
+	const procedure = "/task.v1.TaskService/GetTask"
+	rpcHandler := connect.NewUnaryHandler(procedure,
+		func(ctx context.Context, req *connect.Request[taskv1.GetTaskRequest]) (*connect.Response[taskv1.GetTaskResponse], error) {
+			if req.Msg.Id == "missing" {
+				return nil, connect.NewError(connect.CodeNotFound, errors.New("task not found"))
+			}
+			return connect.NewResponse(&taskv1.GetTaskResponse{Task: &taskv1.Task{Id: req.Msg.Id}}), nil
+		},
+		connect.WithInterceptors(StatusInterceptor()),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle(procedure, rpcHandler)
+	server := httptest.NewUnstartedServer(RequestLoggingMiddleware(logger)(mux))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		opts         []connect.ClientOption
+		id           string
+		expectedCode string
+	}{
+		{name: "connect_ok", id: "1", expectedCode: "ok"},
+		{name: "connect_not_found", id: "missing", expectedCode: "not_found"},
+		// gRPC reports failures with HTTP 200 and the code in trailers
+		{name: "grpc_not_found", opts: []connect.ClientOption{connect.WithGRPC()}, id: "missing", expectedCode: "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			client := connect.NewClient[taskv1.GetTaskRequest, taskv1.GetTaskResponse](server.Client(), server.URL+procedure, tt.opts...)
+			client.CallUnary(context.Background(), connect.NewRequest(&taskv1.GetTaskRequest{Id: tt.id}))
+
+			logLines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, logLines, 2)
+
+			var completedLog map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(logLines[1]), &completedLog))
+			assert.Equal(t, procedure, completedLog["procedure"])
+			assert.Equal(t, tt.expectedCode, completedLog["rpc_code"])
+		})
+	}
+}
+
+func TestRequestLoggingMiddleware_NonRPCOmitsCode(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+
+	handler := RequestLoggingMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/livez", nil))
+
+	logLines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, logLines, 2)
+
+	var completedLog map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(logLines[1]), &completedLog))
+	assert.NotContains(t, completedLog, "rpc_code")
+}
+
 func TestResponseWriter(t *testing.T) {
 	w := httptest.NewRecorder()
 	rw := &responseWriter{
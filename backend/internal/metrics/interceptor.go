@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+// codeOK labels RPCs that completed without an error
+const codeOK = "ok"
+
+// rpcInterceptor records request counts and latency for every RPC
+type rpcInterceptor struct {
+	metrics *Metrics
+}
+
+// Interceptor returns a Connect interceptor that records RPC metrics
+func (m *Metrics) Interceptor() connect.Interceptor {
+	return &rpcInterceptor{metrics: m}
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *rpcInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := next(ctx, req)
+		i.observe(req.Spec().Procedure, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *rpcInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *rpcInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		err := next(ctx, conn)
+		i.observe(conn.Spec().Procedure, err, time.Since(start))
+		return err
+	}
+}
+
+// observe records a single finished RPC
+func (i *rpcInterceptor) observe(procedure string, err error, elapsed time.Duration) {
+	code := codeOK
+	if err != nil {
+		code = connect.CodeOf(err).String()
+	}
+
+	i.metrics.rpcRequests.WithLabelValues(procedure, code).Inc()
+	i.metrics.rpcDuration.WithLabelValues(procedure, code).Observe(elapsed.Seconds())
+}
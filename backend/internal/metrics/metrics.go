@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "todo"
+
+// Metrics owns the Prometheus registry and the server-wide instruments
+type Metrics struct {
+	registry *prometheus.Registry
+
+	rpcRequests *prometheus.CounterVec
+	rpcDuration *prometheus.HistogramVec
+	timeouts    *prometheus.CounterVec
+}
+
+// New creates a Metrics instance with Go runtime and process collectors
+// already registered
+func New() *Metrics {
+	registry := prometheus.NewRegistry()
+
+	m := &Metrics{
+		registry: registry,
+		rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "rpc",
+			Name:      "requests_total",
+			Help:      "Total number of RPCs handled, by procedure and Connect code.",
+		}, []string{"procedure", "code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "rpc",
+			Name:      "request_duration_seconds",
+			Help:      "RPC latency in seconds, by procedure and Connect code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"procedure", "code"}),
+		timeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "rpc",
+			Name:      "timeouts_total",
+			Help:      "Total number of RPCs that exceeded their deadline, by procedure.",
+		}, []string{"procedure"}),
+	}
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.rpcRequests,
+		m.rpcDuration,
+		m.timeouts,
+	)
+
+	return m
+}
+
+// Registry returns the underlying Prometheus registry
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns the HTTP handler that serves the /metrics endpoint
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RecordTimeout counts an RPC that exceeded its deadline
+func (m *Metrics) RecordTimeout(procedure string) {
+	m.timeouts.WithLabelValues(procedure).Inc()
+}
+
+// RegisterDBPools exports sql.DB.Stats() for every named connection pool
+func (m *Metrics) RegisterDBPools(pools map[string]*sql.DB) {
+	for name, db := range pools {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+	}
+}
+
+// RegisterTaskCounts exports task gauges computed by count on every scrape
+func (m *Metrics) RegisterTaskCounts(count func(ctx context.Context) (total, completed int64, err error)) {
+	m.registry.MustRegister(&taskCollector{count: count, timeout: 5 * time.Second})
+}
+
+// RegisterCacheStats exports the read cache hit and miss counters
+func (m *Metrics) RegisterCacheStats(stats func() (hits, misses uint64)) {
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "hits_total",
+			Help:      "Total number of task reads served from the cache.",
+		}, func() float64 {
+			hits, _ := stats()
+			return float64(hits)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "misses_total",
+			Help:      "Total number of task reads that missed the cache.",
+		}, func() float64 {
+			_, misses := stats()
+			return float64(misses)
+		}),
+	)
+}
+
+// taskCollector reports the number of total and completed tasks
+type taskCollector struct {
+	count   func(ctx context.Context) (total, completed int64, err error)
+	timeout time.Duration
+}
+
+var (
+	tasksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "tasks"),
+		"Number of tasks currently stored.",
+		nil, nil,
+	)
+	tasksCompletedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "tasks_completed"),
+		"Number of completed tasks currently stored.",
+		nil, nil,
+	)
+)
+
+// Describe implements prometheus.Collector
+func (c *taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tasksDesc
+	ch <- tasksCompletedDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *taskCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	total, completed, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(tasksDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(total))
+	ch <- prometheus.MustNewConstMetric(tasksCompletedDesc, prometheus.GaugeValue, float64(completed))
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/handler"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+func setupInstrumentedServer(t *testing.T, m *Metrics) taskconnect.TaskServiceClient {
+	t.Helper()
+
+	taskHandler := handler.NewTaskHandler(service.NewTaskService(testutil.NewMockStore()))
+	mux := http.NewServeMux()
+	mux.Handle(taskconnect.NewTaskServiceHandler(taskHandler, connect.WithInterceptors(m.Interceptor())))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return taskconnect.NewTaskServiceClient(server.Client(), server.URL)
+}
+
+func TestInterceptor_RecordsRequests(t *testing.T) {
+	m := New()
+	client := setupInstrumentedServer(t, m)
+	ctx := context.Background()
+
+	_, err := client.CreateTask(ctx, connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Task"}))
+	require.NoError(t, err)
+
+	_, err = client.CreateTask(ctx, connect.NewRequest(&taskv1.CreateTaskRequest{Description: ""}))
+	require.Error(t, err)
+
+	_, err = client.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: "99999"}))
+	require.Error(t, err)
+
+	createProcedure := taskconnect.TaskServiceCreateTaskProcedure
+	getProcedure := taskconnect.TaskServiceGetTaskProcedure
+
+	assert.Equal(t, 1.0, promtestutil.ToFloat64(m.rpcRequests.WithLabelValues(createProcedure, codeOK)))
+	assert.Equal(t, 1.0, promtestutil.ToFloat64(m.rpcRequests.WithLabelValues(createProcedure, connect.CodeInvalidArgument.String())))
+	assert.Equal(t, 1.0, promtestutil.ToFloat64(m.rpcRequests.WithLabelValues(getProcedure, connect.CodeNotFound.String())))
+	assert.Equal(t, 3, promtestutil.CollectAndCount(m.rpcDuration))
+}
+
+func TestMetrics_RecordTimeout(t *testing.T) {
+	m := New()
+
+	m.RecordTimeout("/task.v1.TaskService/CreateTask")
+	m.RecordTimeout("/task.v1.TaskService/CreateTask")
+
+	assert.Equal(t, 2.0, promtestutil.ToFloat64(m.timeouts.WithLabelValues("/task.v1.TaskService/CreateTask")))
+}
+
+func TestMetrics_TaskCounts(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		m := New()
+		m.RegisterTaskCounts(func(ctx context.Context) (int64, int64, error) {
+			return 5, 2, nil
+		})
+
+		body := scrape(t, m)
+		assert.Contains(t, body, "todo_tasks 5")
+		assert.Contains(t, body, "todo_tasks_completed 2")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		m := New()
+		m.RegisterTaskCounts(func(ctx context.Context) (int64, int64, error) {
+			return 0, 0, errors.New("database unavailable")
+		})
+
+		_, err := m.Registry().Gather()
+		assert.Error(t, err)
+	})
+}
+
+func TestMetrics_CacheStats(t *testing.T) {
+	m := New()
+	m.RegisterCacheStats(func() (uint64, uint64) { return 7, 3 })
+
+	body := scrape(t, m)
+	assert.Contains(t, body, "todo_cache_hits_total 7")
+	assert.Contains(t, body, "todo_cache_misses_total 3")
+}
+
+func TestMetrics_DBPools(t *testing.T) {
+	db, err := sql.Open("mysql", "user:pass@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	defer db.Close()
+
+	m := New()
+	m.RegisterDBPools(map[string]*sql.DB{"primary": db})
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `go_sql_open_connections{db_name="primary"}`)
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+
+	body := scrape(t, m)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+// scrape fetches the metrics endpoint and returns its body
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
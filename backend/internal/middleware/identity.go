@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+)
+
+// ClientIdentity describes a caller that authenticated with a verified TLS
+// client certificate
+type ClientIdentity struct {
+	// CommonName is the subject common name of the certificate
+	CommonName string
+	// DNSNames and URIs are the certificate's subject alternative names;
+	// URIs carry workload identities such as spiffe://cluster/ns/app
+	DNSNames []string
+	URIs     []string
+	// SerialNumber and Issuer identify the certificate itself
+	SerialNumber string
+	Issuer       string
+}
+
+// Name returns the most specific name of the caller: the first URI, the
+// common name, or the first DNS name
+func (id *ClientIdentity) Name() string {
+	switch {
+	case len(id.URIs) > 0:
+		return id.URIs[0]
+	case id.CommonName != "":
+		return id.CommonName
+	case len(id.DNSNames) > 0:
+		return id.DNSNames[0]
+	}
+	return ""
+}
+
+// clientIdentityKey is the context key of the ClientIdentity
+type clientIdentityKey struct{}
+
+// ClientIdentityFromContext returns the identity of the caller, if it
+// presented a verified client certificate
+func ClientIdentityFromContext(ctx context.Context) (*ClientIdentity, bool) {
+	id, ok := ctx.Value(clientIdentityKey{}).(*ClientIdentity)
+	return id, ok
+}
+
+// ClientName returns the name of the verified caller, or an empty string
+// for callers without a verified client certificate
+func ClientName(ctx context.Context) string {
+	if id, ok := ClientIdentityFromContext(ctx); ok {
+		return id.Name()
+	}
+	return ""
+}
+
+// ContextWithClientIdentity returns ctx carrying id
+func ContextWithClientIdentity(ctx context.Context, id *ClientIdentity) context.Context {
+	return context.WithValue(ctx, clientIdentityKey{}, id)
+}
+
+// ClientIdentityMiddleware puts the identity from a verified TLS client
+// certificate on the request context. Requests over plaintext or without a
+// verified certificate pass through without one.
+func ClientIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			id := identityFromCertificate(r.TLS.VerifiedChains[0][0])
+			r = r.WithContext(ContextWithClientIdentity(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// identityFromCertificate extracts the identity from a client certificate
+func identityFromCertificate(cert *x509.Certificate) *ClientIdentity {
+	id := &ClientIdentity{
+		CommonName:   cert.Subject.CommonName,
+		DNSNames:     cert.DNSNames,
+		SerialNumber: cert.SerialNumber.String(),
+		Issuer:       cert.Issuer.String(),
+	}
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+	}
+	return id
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureIdentity serves requests through ClientIdentityMiddleware and
+// returns the identity the handler saw
+func captureIdentity(t *testing.T, state *tls.ConnectionState) (*ClientIdentity, bool) {
+	t.Helper()
+	var (
+		id *ClientIdentity
+		ok bool
+	)
+	handler := ClientIdentityMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		id, ok = ClientIdentityFromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = state
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	return id, ok
+}
+
+func TestClientIdentityMiddleware(t *testing.T) {
+	uri, err := url.Parse("spiffe://cluster.local/ns/todo-app/sa/worker")
+	require.NoError(t, err)
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "worker"},
+		Issuer:       pkix.Name{CommonName: "clients"},
+		DNSNames:     []string{"worker.todo-app.svc"},
+		URIs:         []*url.URL{uri},
+	}
+
+	id, ok := captureIdentity(t, &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}})
+	require.True(t, ok)
+	assert.Equal(t, &ClientIdentity{
+		CommonName:   "worker",
+		DNSNames:     []string{"worker.todo-app.svc"},
+		URIs:         []string{"spiffe://cluster.local/ns/todo-app/sa/worker"},
+		SerialNumber: "42",
+		Issuer:       "CN=clients",
+	}, id)
+	assert.Equal(t, "spiffe://cluster.local/ns/todo-app/sa/worker", id.Name())
+}
+
+func TestClientIdentityMiddleware_NoVerifiedCertificate(t *testing.T) {
+	_, ok := captureIdentity(t, nil)
+	assert.False(t, ok, "plaintext requests have no identity")
+
+	// Unverified peer certificates are never trusted as an identity
+	unverified := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "mallory"}}
+	_, ok = captureIdentity(t, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{unverified}})
+	assert.False(t, ok)
+}
+
+func TestClientIdentity_Name(t *testing.T) {
+	assert.Equal(t, "worker", (&ClientIdentity{CommonName: "worker", DNSNames: []string{"worker.svc"}}).Name())
+	assert.Equal(t, "worker.svc", (&ClientIdentity{DNSNames: []string{"worker.svc"}}).Name())
+	assert.Equal(t, "", (&ClientIdentity{}).Name())
+}
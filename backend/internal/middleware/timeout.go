@@ -2,81 +2,133 @@ package middleware
 
 import (
 	"context"
-	"net/http"
+	"sync/atomic"
 	"time"
 
+	"connectrpc.com/connect"
+
 	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/errors"
 	"github.com/wcygan/todo/backend/internal/logger"
 )
 
-// timeoutResponseWriter wraps http.ResponseWriter to track if response was written
-type timeoutResponseWriter struct {
-	http.ResponseWriter
-	written bool
+// TimeoutRecorder is notified whenever an RPC exceeds its deadline
+type TimeoutRecorder interface {
+	RecordTimeout(procedure string)
+}
+
+// RequestTimeouts holds the per-procedure request timeouts, which can be
+// replaced while the server runs
+type RequestTimeouts struct {
+	cfg atomic.Pointer[config.TimeoutConfig]
+}
+
+// NewRequestTimeouts returns RequestTimeouts starting with cfg
+func NewRequestTimeouts(cfg *config.TimeoutConfig) *RequestTimeouts {
+	t := &RequestTimeouts{}
+	t.Set(cfg)
+	return t
+}
+
+// Set replaces the timeouts for requests that start after it returns
+func (t *RequestTimeouts) Set(cfg *config.TimeoutConfig) {
+	c := *cfg
+	t.cfg.Store(&c)
 }
 
-func (w *timeoutResponseWriter) WriteHeader(code int) {
-	if !w.written {
-		w.written = true
-		w.ResponseWriter.WriteHeader(code)
+// ForProcedure returns the current timeout for procedure
+func (t *RequestTimeouts) ForProcedure(procedure string) time.Duration {
+	return t.cfg.Load().ForProcedure(procedure)
+}
+
+// Interceptor returns a Connect interceptor that enforces the timeouts, as
+// TimeoutInterceptor does
+func (t *RequestTimeouts) Interceptor(log *logger.Logger, recorders ...TimeoutRecorder) connect.Interceptor {
+	return &timeoutInterceptor{
+		timeouts:  t,
+		log:       log,
+		recorders: recorders,
 	}
 }
 
-func (w *timeoutResponseWriter) Write(data []byte) (int, error) {
-	if !w.written {
-		w.written = true
+// timeoutInterceptor enforces per-procedure deadlines
+type timeoutInterceptor struct {
+	timeouts  *RequestTimeouts
+	log       *logger.Logger
+	recorders []TimeoutRecorder
+}
+
+// TimeoutInterceptor returns a Connect interceptor that bounds every RPC by
+// the deadline the client sent in Connect-Timeout-Ms or grpc-timeout, capped
+// at the configured maximum for the procedure. RPCs that run out of time fail
+// with deadline_exceeded and are reported to the given recorders.
+func TimeoutInterceptor(cfg *config.TimeoutConfig, log *logger.Logger, recorders ...TimeoutRecorder) connect.Interceptor {
+	return NewRequestTimeouts(cfg).Interceptor(log, recorders...)
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *timeoutInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		procedure := req.Spec().Procedure
+		ctx, cancel := i.withDeadline(ctx, procedure)
+		defer cancel()
+
+		resp, err := next(ctx, req)
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			return nil, i.timedOut(ctx, procedure)
+		}
+		return resp, err
 	}
-	return w.ResponseWriter.Write(data)
-}
-
-// TimeoutMiddleware adds request timeouts based on configuration
-func TimeoutMiddleware(cfg *config.Config, log *logger.Logger) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Create context with timeout
-			ctx, cancel := context.WithTimeout(r.Context(), cfg.Server.ReadTimeout)
-			defer cancel()
-
-			// Add operation context for logging
-			ctx = logger.AddOperationToContext(ctx, "http_request")
-
-			// Create request with timeout context
-			r = r.WithContext(ctx)
-
-			// Wrap response writer to track writes
-			tw := &timeoutResponseWriter{ResponseWriter: w}
-
-			// Channel to signal completion
-			done := make(chan struct{})
-			
-			// Execute request in goroutine
-			go func() {
-				defer close(done)
-				next.ServeHTTP(tw, r)
-			}()
-
-			// Wait for completion or timeout
-			select {
-			case <-done:
-				// Request completed normally
-				return
-			case <-ctx.Done():
-				// Request timed out
-				log.LogWarn(ctx, "request timeout",
-					"timeout", cfg.Server.ReadTimeout,
-					"path", r.URL.Path,
-					"method", r.Method,
-				)
-				
-				// Only write timeout response if no response was written yet
-				if !tw.written {
-					w.WriteHeader(http.StatusRequestTimeout)
-					w.Write([]byte(`{"error": "request timeout"}`))
-				}
-				return
-			}
-		})
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *timeoutInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *timeoutInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		procedure := conn.Spec().Procedure
+		ctx, cancel := i.withDeadline(ctx, procedure)
+		defer cancel()
+
+		err := next(ctx, conn)
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			return i.timedOut(ctx, procedure)
+		}
+		return err
+	}
+}
+
+// withDeadline applies the procedure's maximum unless the client already
+// asked for an earlier deadline, which Connect has set on ctx
+func (i *timeoutInterceptor) withDeadline(ctx context.Context, procedure string) (context.Context, context.CancelFunc) {
+	ctx = logger.AddOperationToContext(ctx, procedure)
+
+	max := i.timeouts.ForProcedure(procedure)
+	if max <= 0 {
+		return context.WithCancel(ctx)
 	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= max {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, max)
+}
+
+// timedOut logs and records an RPC that ran out of time. Whatever error the
+// handler produced is replaced, since layers below typically wrap the
+// context error as an internal failure.
+func (i *timeoutInterceptor) timedOut(ctx context.Context, procedure string) error {
+	i.log.LogWarn(ctx, "request timeout", "procedure", procedure)
+	for _, recorder := range i.recorders {
+		recorder.RecordTimeout(procedure)
+	}
+	return errors.ToConnectError(errors.Timeout(procedure))
 }
 
 // ContextWithRequestTimeout creates a context with a timeout for individual operations
@@ -92,4 +144,4 @@ func ContextWithDeadline(ctx context.Context, deadline time.Time) (context.Conte
 // ContextWithCancel creates a cancellable context for long-running operations
 func ContextWithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithCancel(ctx)
-}
\ No newline at end of file
+}
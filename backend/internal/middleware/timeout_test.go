@@ -1,97 +1,168 @@
-package middleware
+package middleware_test
 
 import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
+	"connectrpc.com/connect"
+	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/handler"
 	"github.com/wcygan/todo/backend/internal/logger"
+	"github.com/wcygan/todo/backend/internal/middleware"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/test/testutil"
 )
 
-func TestTimeoutMiddleware(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			ReadTimeout: 100 * time.Millisecond,
-		},
-		Logger: config.LoggerConfig{
-			Level:  "debug",
-			Format: "json",
-		},
+// slowStore blocks ListTasks until the request context is done
+type slowStore struct {
+	*testutil.MockStore
+
+	mu        sync.Mutex
+	operation string
+	remaining time.Duration
+}
+
+func (s *slowStore) ListTasks(ctx context.Context) ([]*taskv1.Task, error) {
+	s.mu.Lock()
+	s.operation, _ = logger.GetOperationFromContext(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		s.remaining = time.Until(deadline)
 	}
-	log := logger.New(cfg)
-
-	tests := []struct {
-		name           string
-		handlerDelay   time.Duration
-		expectedStatus int
-		expectTimeout  bool
-	}{
-		{
-			name:           "normal request",
-			handlerDelay:   10 * time.Millisecond,
-			expectedStatus: http.StatusOK,
-			expectTimeout:  false,
-		},
-		{
-			name:           "timeout request",
-			handlerDelay:   200 * time.Millisecond,
-			expectedStatus: http.StatusRequestTimeout,
-			expectTimeout:  true,
-		},
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// countingRecorder counts reported timeouts per procedure
+type countingRecorder struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (r *countingRecorder) RecordTimeout(procedure string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[procedure]++
+}
+
+func (r *countingRecorder) snapshot() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[string]int, len(r.counts))
+	for k, v := range r.counts {
+		counts[k] = v
 	}
+	return counts
+}
+
+// setupTimeoutServer serves TaskService over HTTP/2 with the timeout
+// interceptor and returns the server along with its store and recorder
+func setupTimeoutServer(t *testing.T, timeouts config.TimeoutConfig) (*httptest.Server, *slowStore, *countingRecorder) {
+	t.Helper()
+
+	cfg := &config.Config{Logger: config.LoggerConfig{Level: "error", Format: "json"}}
+	recorder := &countingRecorder{counts: make(map[string]int)}
+	store := &slowStore{MockStore: testutil.NewMockStore()}
+
+	mux := http.NewServeMux()
+	mux.Handle(taskconnect.NewTaskServiceHandler(
+		handler.NewTaskHandler(service.NewTaskService(store)),
+		connect.WithInterceptors(middleware.TimeoutInterceptor(&timeouts, logger.New(cfg), recorder)),
+	))
+
+	server := httptest.NewUnstartedServer(mux)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server, store, recorder
+}
+
+func TestTimeoutInterceptor_CapsAtProcedureMax(t *testing.T) {
+	server, store, recorder := setupTimeoutServer(t, config.TimeoutConfig{
+		Default:    10 * time.Second,
+		Procedures: map[string]time.Duration{"GetAllTasks": 50 * time.Millisecond},
+	})
+	client := taskconnect.NewTaskServiceClient(server.Client(), server.URL)
+
+	// No client deadline, so the procedure maximum applies
+	start := time.Now()
+	_, err := client.GetAllTasks(context.Background(), connect.NewRequest(&taskv1.GetAllTasksRequest{}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeDeadlineExceeded, connect.CodeOf(err))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// A generous client deadline is capped as well
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err = client.GetAllTasks(ctx, connect.NewRequest(&taskv1.GetAllTasksRequest{}))
+	assert.Equal(t, connect.CodeDeadlineExceeded, connect.CodeOf(err))
+
+	// Other procedures keep the default and complete normally
+	_, err = client.CreateTask(context.Background(), connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Task"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{taskconnect.TaskServiceGetAllTasksProcedure: 2}, recorder.snapshot())
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Create test handler with delay
-			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Simulate work
-				select {
-				case <-time.After(tt.handlerDelay):
-					w.WriteHeader(http.StatusOK)
-					w.Write([]byte("success"))
-				case <-r.Context().Done():
-					// Context cancelled due to timeout
-					return
-				}
-			})
-
-			// Wrap with timeout middleware
-			middleware := TimeoutMiddleware(cfg, log)
-			wrappedHandler := middleware(testHandler)
-
-			// Create test request
-			req := httptest.NewRequest("GET", "/test", nil)
-			w := httptest.NewRecorder()
-
-			// Execute request
-			wrappedHandler.ServeHTTP(w, req)
-
-			// Check result
-			if tt.expectTimeout {
-				// For timeout cases, we need to wait a bit longer to ensure timeout occurs
-				time.Sleep(150 * time.Millisecond)
-				// The response may be 0 (no response written) or timeout status
-				// Both are acceptable for timeout scenarios
-				assert.True(t, w.Code == http.StatusRequestTimeout || w.Code == 0, 
-					"Expected timeout response (408) or no response (0), got: %d", w.Code)
-			} else {
-				assert.Equal(t, tt.expectedStatus, w.Code)
-			}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.Equal(t, taskconnect.TaskServiceGetAllTasksProcedure, store.operation)
+}
+
+func TestTimeoutInterceptor_HonorsClientTimeout(t *testing.T) {
+	server, store, _ := setupTimeoutServer(t, config.TimeoutConfig{Default: 10 * time.Second})
+
+	clients := map[string]taskconnect.TaskServiceClient{
+		"connect": taskconnect.NewTaskServiceClient(server.Client(), server.URL),
+		"grpc":    taskconnect.NewTaskServiceClient(server.Client(), server.URL, connect.WithGRPC()),
+	}
+
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := client.GetAllTasks(ctx, connect.NewRequest(&taskv1.GetAllTasksRequest{}))
+			require.Error(t, err)
+			assert.Equal(t, connect.CodeDeadlineExceeded, connect.CodeOf(err))
+			assert.Less(t, time.Since(start), 5*time.Second)
+
+			// The handler ran under the client's deadline, not the default
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			assert.Greater(t, store.remaining, time.Duration(0))
+			assert.LessOrEqual(t, store.remaining, 100*time.Millisecond)
 		})
 	}
 }
 
+func TestTimeoutInterceptor_ZeroDefaultIsUnbounded(t *testing.T) {
+	server, _, recorder := setupTimeoutServer(t, config.TimeoutConfig{})
+	client := taskconnect.NewTaskServiceClient(server.Client(), server.URL)
+
+	resp, err := client.CreateTask(context.Background(), connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Task"}))
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Msg.Task.Id)
+	assert.Empty(t, recorder.snapshot())
+}
+
 func TestContextWithRequestTimeout(t *testing.T) {
 	ctx := context.Background()
 	timeout := 100 * time.Millisecond
 
 	// Create context with timeout
-	timeoutCtx, cancel := ContextWithRequestTimeout(ctx, timeout)
+	timeoutCtx, cancel := middleware.ContextWithRequestTimeout(ctx, timeout)
 	defer cancel()
 
 	// Check that context has deadline
@@ -114,7 +185,7 @@ func TestContextWithDeadline(t *testing.T) {
 	deadline := time.Now().Add(50 * time.Millisecond)
 
 	// Create context with deadline
-	deadlineCtx, cancel := ContextWithDeadline(ctx, deadline)
+	deadlineCtx, cancel := middleware.ContextWithDeadline(ctx, deadline)
 	defer cancel()
 
 	// Check that context has the correct deadline
@@ -135,7 +206,7 @@ func TestContextWithCancel(t *testing.T) {
 	ctx := context.Background()
 
 	// Create cancellable context
-	cancelCtx, cancel := ContextWithCancel(ctx)
+	cancelCtx, cancel := middleware.ContextWithCancel(ctx)
 
 	// Context should not be done initially
 	select {
@@ -156,92 +227,14 @@ func TestContextWithCancel(t *testing.T) {
 	}
 }
 
-func TestTimeoutMiddlewareWithLongRunningHandler(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			ReadTimeout: 50 * time.Millisecond,
-		},
-		Logger: config.LoggerConfig{
-			Level:  "debug",
-			Format: "text",
-		},
-	}
-	log := logger.New(cfg)
-
-	// Create handler that checks context cancellation
-	handlerCalled := false
-	contextCancelled := false
-
-	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		handlerCalled = true
-		
-		// Simulate long-running operation with context checking
-		ticker := time.NewTicker(10 * time.Millisecond)
-		defer ticker.Stop()
-
-		for i := 0; i < 10; i++ {
-			select {
-			case <-r.Context().Done():
-				contextCancelled = true
-				return
-			case <-ticker.C:
-				// Continue processing
-			}
-		}
-
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("completed"))
-	})
-
-	middleware := TimeoutMiddleware(cfg, log)
-	wrappedHandler := middleware(testHandler)
-
-	req := httptest.NewRequest("POST", "/long-operation", nil)
-	w := httptest.NewRecorder()
+func TestRequestTimeouts_Set(t *testing.T) {
+	timeouts := middleware.NewRequestTimeouts(&config.TimeoutConfig{Default: 10 * time.Second})
+	assert.Equal(t, 10*time.Second, timeouts.ForProcedure(taskconnect.TaskServiceGetAllTasksProcedure))
 
-	wrappedHandler.ServeHTTP(w, req)
-
-	// Handler should have been called
-	assert.True(t, handlerCalled)
-	
-	// Wait a bit to ensure context cancellation is propagated
-	time.Sleep(10 * time.Millisecond)
-	
-	// Context should have been cancelled due to timeout
-	assert.True(t, contextCancelled)
-}
-
-func TestTimeoutMiddlewareContextPropagation(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			ReadTimeout: 100 * time.Millisecond,
-		},
-		Logger: config.LoggerConfig{
-			Level:  "info",
-			Format: "json",
-		},
-	}
-	log := logger.New(cfg)
-
-	// Create handler that checks context values
-	var receivedOperation string
-
-	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if op, ok := logger.GetOperationFromContext(r.Context()); ok {
-			receivedOperation = op
-		}
-		w.WriteHeader(http.StatusOK)
+	timeouts.Set(&config.TimeoutConfig{
+		Default:    time.Second,
+		Procedures: map[string]time.Duration{"GetAllTasks": 50 * time.Millisecond},
 	})
-
-	middleware := TimeoutMiddleware(cfg, log)
-	wrappedHandler := middleware(testHandler)
-
-	req := httptest.NewRequest("GET", "/test", nil)
-	w := httptest.NewRecorder()
-
-	wrappedHandler.ServeHTTP(w, req)
-
-	// Check that operation context was propagated
-	assert.Equal(t, "http_request", receivedOperation)
-	assert.Equal(t, http.StatusOK, w.Code)
-}
\ No newline at end of file
+	assert.Equal(t, 50*time.Millisecond, timeouts.ForProcedure(taskconnect.TaskServiceGetAllTasksProcedure))
+	assert.Equal(t, time.Second, timeouts.ForProcedure(taskconnect.TaskServiceCreateTaskProcedure))
+}
@@ -0,0 +1,190 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"connectrpc.com/connect"
+
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/middleware"
+)
+
+// KeyFunc identifies the client an RPC is charged to
+type KeyFunc func(ctx context.Context, peer connect.Peer, header http.Header) string
+
+// Option configures a Limiter
+type Option func(*Limiter)
+
+// WithKeyFunc overrides how clients are identified. fn must only trust
+// credentials it has verified; a key taken from an unchecked header lets a
+// client pick a fresh bucket for every request.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(l *Limiter) {
+		l.keyFunc = fn
+	}
+}
+
+// WithErrorHandler is called when the store fails. The request is allowed
+// through so that a store outage never takes the API down with it.
+func WithErrorHandler(fn func(ctx context.Context, err error)) Option {
+	return func(l *Limiter) {
+		l.onError = fn
+	}
+}
+
+// Limiter enforces per-client, per-procedure token bucket quotas
+type Limiter struct {
+	cfg     atomic.Pointer[config.RateLimitConfig]
+	store   Store
+	keyFunc KeyFunc
+	onError func(ctx context.Context, err error)
+}
+
+// New creates a Limiter backed by store. Clients are identified by
+// ClientKey, trusting X-Forwarded-For from cfg.TrustedProxies; those are
+// fixed for the Limiter's lifetime.
+func New(cfg *config.RateLimitConfig, store Store, opts ...Option) *Limiter {
+	l := &Limiter{store: store}
+	l.SetConfig(cfg)
+
+	// Validate has already rejected malformed entries
+	trustedProxies, _ := config.ParsePrefixes(cfg.TrustedProxies)
+	l.keyFunc = func(ctx context.Context, peer connect.Peer, header http.Header) string {
+		return ClientKey(ctx, peer, header, trustedProxies)
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// SetConfig replaces the quotas. Requests already admitted are unaffected;
+// existing buckets adopt the new rate and burst on their next request.
+func (l *Limiter) SetConfig(cfg *config.RateLimitConfig) {
+	c := *cfg
+	l.cfg.Store(&c)
+}
+
+// Key identifies the client making a request
+func (l *Limiter) Key(ctx context.Context, peer connect.Peer, header http.Header) string {
+	return l.keyFunc(ctx, peer, header)
+}
+
+// Allow charges one request for procedure to the client identified by key
+func (l *Limiter) Allow(ctx context.Context, procedure, key string) error {
+	decision, err := l.store.Take(ctx, procedure+"|"+key, l.cfg.Load().ForProcedure(procedure))
+	if err != nil {
+		if l.onError != nil {
+			l.onError(ctx, err)
+		}
+		return nil
+	}
+	if !decision.Allowed {
+		return errors.RateLimited(decision.RetryAfter)
+	}
+	return nil
+}
+
+// ClientKey identifies a client by its verified TLS client certificate,
+// then by IP address. The remote address is the client's only when nothing
+// proxies requests; behind an ingress or load balancer every client would
+// share the proxy's bucket. When the remote address is in trustedProxies,
+// the client is the last X-Forwarded-For hop that is not itself a trusted
+// proxy. X-Forwarded-For from anyone else, and every other header, is
+// ignored: a client could send a new value with every request to dodge its
+// quota.
+func ClientKey(ctx context.Context, peer connect.Peer, header http.Header, trustedProxies []netip.Prefix) string {
+	if name := middleware.ClientName(ctx); name != "" {
+		return "client:" + name
+	}
+
+	host, _, err := net.SplitHostPort(peer.Addr)
+	if err != nil {
+		host = peer.Addr
+	}
+	return "ip:" + forwardedClient(host, header, trustedProxies)
+}
+
+// forwardedClient walks X-Forwarded-For back from host, the remote address,
+// while the hop it reached is a trusted proxy, and returns the first hop
+// that is not
+func forwardedClient(host string, header http.Header, trustedProxies []netip.Prefix) string {
+	if len(trustedProxies) == 0 {
+		return host
+	}
+
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops); ; i-- {
+		addr, err := netip.ParseAddr(host)
+		if err != nil || !trusted(addr.Unmap(), trustedProxies) || i == 0 {
+			return host
+		}
+		next, err := netip.ParseAddr(strings.TrimSpace(hops[i-1]))
+		if err != nil {
+			// A malformed hop was not written by a trusted proxy
+			return host
+		}
+		host = next.Unmap().String()
+	}
+}
+
+// trusted reports whether addr belongs to one of the trusted proxies
+func trusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Interceptor returns a Connect interceptor that rejects RPCs over quota with
+// CodeResourceExhausted and a Retry-After header
+func (l *Limiter) Interceptor() connect.Interceptor {
+	return &interceptor{limiter: l}
+}
+
+// interceptor applies a Limiter to unary and streaming handlers
+type interceptor struct {
+	limiter *Limiter
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		key := i.limiter.keyFunc(ctx, req.Peer(), req.Header())
+		if err := i.limiter.Allow(ctx, req.Spec().Procedure, key); err != nil {
+			return nil, errors.ToConnectError(err)
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		key := i.limiter.keyFunc(ctx, conn.Peer(), conn.RequestHeader())
+		if err := i.limiter.Allow(ctx, conn.Spec().Procedure, key); err != nil {
+			return errors.ToConnectError(err)
+		}
+		return next(ctx, conn)
+	}
+}
@@ -0,0 +1,211 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/handler"
+	"github.com/wcygan/todo/backend/internal/middleware"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+func setupLimitedServer(t *testing.T, limiter *Limiter) taskconnect.TaskServiceClient {
+	t.Helper()
+
+	taskHandler := handler.NewTaskHandler(service.NewTaskService(testutil.NewMockStore()))
+	mux := http.NewServeMux()
+	mux.Handle(taskconnect.NewTaskServiceHandler(taskHandler, connect.WithInterceptors(limiter.Interceptor())))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return taskconnect.NewTaskServiceClient(server.Client(), server.URL)
+}
+
+func testConfig() *config.RateLimitConfig {
+	return &config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimit{RequestsPerSecond: 100, Burst: 100},
+		Procedures: map[string]config.RateLimit{
+			"CreateTask": {RequestsPerSecond: 0.001, Burst: 2},
+		},
+	}
+}
+
+func createRequest(apiKey string) *connect.Request[taskv1.CreateTaskRequest] {
+	req := connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Task"})
+	if apiKey != "" {
+		req.Header().Set("X-API-Key", apiKey)
+	}
+	return req
+}
+
+func TestInterceptor_PerProcedureLimit(t *testing.T) {
+	client := setupLimitedServer(t, New(testConfig(), NewMemoryStore()))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateTask(ctx, createRequest(""))
+		require.NoError(t, err)
+	}
+
+	_, err := client.CreateTask(ctx, createRequest(""))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+
+	var connectErr *connect.Error
+	require.True(t, errors.As(err, &connectErr))
+	assert.NotEmpty(t, connectErr.Meta().Get("Retry-After"))
+
+	// Other procedures have their own bucket
+	_, err = client.GetAllTasks(ctx, connect.NewRequest(&taskv1.GetAllTasksRequest{}))
+	assert.NoError(t, err)
+}
+
+func TestInterceptor_IgnoresUnverifiedKeys(t *testing.T) {
+	client := setupLimitedServer(t, New(testConfig(), NewMemoryStore()))
+	ctx := context.Background()
+
+	// A new API key per request does not buy a new bucket
+	for i, apiKey := range []string{"alice", "bob"} {
+		req := createRequest(apiKey)
+		req.Header().Set("Authorization", "Bearer token-"+apiKey)
+		_, err := client.CreateTask(ctx, req)
+		require.NoError(t, err, i)
+	}
+	_, err := client.CreateTask(ctx, createRequest("carol"))
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, config.RateLimit) (Decision, error) {
+	return Decision{}, errors.New("store unavailable")
+}
+
+func TestInterceptor_FailsOpen(t *testing.T) {
+	var storeErrs int
+	limiter := New(testConfig(), failingStore{}, WithErrorHandler(func(context.Context, error) {
+		storeErrs++
+	}))
+	client := setupLimitedServer(t, limiter)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.CreateTask(context.Background(), createRequest(""))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, storeErrs)
+}
+
+func TestInterceptor_CustomKeyFunc(t *testing.T) {
+	limiter := New(testConfig(), NewMemoryStore(), WithKeyFunc(
+		func(context.Context, connect.Peer, http.Header) string { return "everyone" },
+	))
+	client := setupLimitedServer(t, limiter)
+	ctx := context.Background()
+
+	_, err := client.CreateTask(ctx, createRequest("alice"))
+	require.NoError(t, err)
+	_, err = client.CreateTask(ctx, createRequest("bob"))
+	require.NoError(t, err)
+
+	_, err = client.CreateTask(ctx, createRequest("carol"))
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+}
+
+func TestClientKey(t *testing.T) {
+	ctx := context.Background()
+	peer := connect.Peer{Addr: "10.0.0.7:51234"}
+	assert.Equal(t, "ip:10.0.0.7", ClientKey(ctx, peer, nil, nil))
+	assert.Equal(t, "ip:unix-socket", ClientKey(ctx, connect.Peer{Addr: "unix-socket"}, nil, nil))
+
+	// Clients with a verified certificate get their own bucket
+	verified := middleware.ContextWithClientIdentity(ctx, &middleware.ClientIdentity{
+		CommonName: "billing",
+		URIs:       []string{"spiffe://cluster/ns/billing"},
+	})
+	assert.Equal(t, "client:spiffe://cluster/ns/billing", ClientKey(verified, peer, nil, nil))
+
+	// An identity without a name falls back to the address
+	unnamed := middleware.ContextWithClientIdentity(ctx, &middleware.ClientIdentity{SerialNumber: "1"})
+	assert.Equal(t, "ip:10.0.0.7", ClientKey(unnamed, peer, nil, nil))
+}
+
+func TestClientKey_TrustedProxies(t *testing.T) {
+	ctx := context.Background()
+	proxies, err := config.ParsePrefixes([]string{"10.0.0.0/8", "192.168.1.10"})
+	require.NoError(t, err)
+
+	forwarded := func(values ...string) http.Header {
+		header := http.Header{}
+		for _, value := range values {
+			header.Add("X-Forwarded-For", value)
+		}
+		return header
+	}
+	ingress := connect.Peer{Addr: "10.0.0.7:51234"}
+
+	tests := []struct {
+		name   string
+		peer   connect.Peer
+		header http.Header
+		want   string
+	}{
+		{"no_header", ingress, nil, "ip:10.0.0.7"},
+		{"client_behind_ingress", ingress, forwarded("203.0.113.5"), "ip:203.0.113.5"},
+		{"chain_of_proxies", ingress, forwarded("203.0.113.5, 192.168.1.10"), "ip:203.0.113.5"},
+		{"spoofed_prefix_ignored", ingress, forwarded("198.51.100.1, 203.0.113.5"), "ip:203.0.113.5"},
+		{"repeated_headers", ingress, forwarded("198.51.100.1", "203.0.113.5"), "ip:203.0.113.5"},
+		{"malformed_hop", ingress, forwarded("garbage"), "ip:10.0.0.7"},
+		{"only_proxies", ingress, forwarded("10.1.1.1"), "ip:10.1.1.1"},
+		{"untrusted_peer", connect.Peer{Addr: "203.0.113.9:443"}, forwarded("198.51.100.1"), "ip:203.0.113.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClientKey(ctx, tt.peer, tt.header, proxies))
+		})
+	}
+
+	// Without trusted proxies the header is ignored
+	assert.Equal(t, "ip:10.0.0.7", ClientKey(ctx, ingress, forwarded("203.0.113.5"), nil))
+
+	// The Limiter trusts the proxies in its configuration
+	cfg := testConfig()
+	cfg.TrustedProxies = []string{"10.0.0.0/8"}
+	limiter := New(cfg, NewMemoryStore())
+	assert.Equal(t, "ip:203.0.113.5", limiter.Key(ctx, ingress, forwarded("203.0.113.5")))
+}
+
+func TestLimiter_SetConfig(t *testing.T) {
+	limiter := New(testConfig(), NewMemoryStore())
+	client := setupLimitedServer(t, limiter)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateTask(ctx, createRequest("client-a"))
+		require.NoError(t, err)
+	}
+	_, err := client.CreateTask(ctx, createRequest("client-a"))
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+
+	// The existing bucket refills at the new rate
+	cfg := testConfig()
+	cfg.Procedures["CreateTask"] = config.RateLimit{RequestsPerSecond: 1000, Burst: 1000}
+	limiter.SetConfig(cfg)
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = client.CreateTask(ctx, createRequest("client-a"))
+	assert.NoError(t, err)
+}
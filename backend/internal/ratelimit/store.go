@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+// sweepEvery controls how many Take calls happen between sweeps of idle
+// buckets in MemoryStore
+const sweepEvery = 1024
+
+// Decision is the outcome of taking a token from a bucket
+type Decision struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until a token is available when not allowed
+	RetryAfter time.Duration
+}
+
+// Store holds token bucket state. Implementations must be safe for concurrent
+// use. MemoryStore keeps state per process; a store backed by a shared cache
+// lets every replica enforce one quota per client.
+type Store interface {
+	Take(ctx context.Context, key string, limit config.RateLimit) (Decision, error)
+}
+
+// bucket is a single token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// limit is the quota the bucket was last charged under, which decides
+	// when it has refilled
+	limit config.RateLimit
+}
+
+// MemoryStore is an in-process Store
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+
+	// now is overridable in tests
+	now func() time.Time
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, limit config.RateLimit) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.takes++
+	if s.takes%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = refill(b.tokens, now.Sub(b.last), limit)
+	b.last = now
+	b.limit = limit
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Decision{Allowed: true, Remaining: int(b.tokens)}, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / limit.RequestsPerSecond * float64(time.Second))
+	return Decision{Allowed: false, RetryAfter: wait}, nil
+}
+
+// Len returns the number of tracked buckets
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// sweep drops buckets that have refilled completely under their own limit,
+// since a missing bucket behaves the same as a full one
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if refill(b.tokens, now.Sub(b.last), b.limit) >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// refill returns the token count after elapsed time, capped at the burst
+func refill(tokens float64, elapsed time.Duration, limit config.RateLimit) float64 {
+	if elapsed > 0 {
+		tokens += elapsed.Seconds() * limit.RequestsPerSecond
+	}
+	return math.Min(tokens, float64(limit.Burst))
+}
+
+// Verify that MemoryStore implements the Store interface
+var _ Store = (*MemoryStore)(nil)
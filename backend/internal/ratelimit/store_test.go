@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+// newTestStore returns a store with a controllable clock
+func newTestStore() (*MemoryStore, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestMemoryStore_Burst(t *testing.T) {
+	store, _ := newTestStore()
+	limit := config.RateLimit{RequestsPerSecond: 1, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		decision, err := store.Take(ctx, "client", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, i, decision.Remaining)
+	}
+
+	decision, err := store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+}
+
+func TestMemoryStore_Refill(t *testing.T) {
+	store, now := newTestStore()
+	limit := config.RateLimit{RequestsPerSecond: 2, Burst: 1}
+	ctx := context.Background()
+
+	decision, _ := store.Take(ctx, "client", limit)
+	assert.True(t, decision.Allowed)
+
+	*now = now.Add(250 * time.Millisecond)
+	decision, _ = store.Take(ctx, "client", limit)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 250*time.Millisecond, decision.RetryAfter)
+
+	*now = now.Add(250 * time.Millisecond)
+	decision, _ = store.Take(ctx, "client", limit)
+	assert.True(t, decision.Allowed)
+
+	// Idle time never accumulates more than the burst
+	*now = now.Add(time.Hour)
+	decision, _ = store.Take(ctx, "client", limit)
+	assert.True(t, decision.Allowed)
+	decision, _ = store.Take(ctx, "client", limit)
+	assert.False(t, decision.Allowed)
+}
+
+func TestMemoryStore_KeysAreIndependent(t *testing.T) {
+	store, _ := newTestStore()
+	limit := config.RateLimit{RequestsPerSecond: 1, Burst: 1}
+	ctx := context.Background()
+
+	decision, _ := store.Take(ctx, "a", limit)
+	assert.True(t, decision.Allowed)
+	decision, _ = store.Take(ctx, "a", limit)
+	assert.False(t, decision.Allowed)
+
+	decision, _ = store.Take(ctx, "b", limit)
+	assert.True(t, decision.Allowed)
+}
+
+func TestMemoryStore_SweepsIdleBuckets(t *testing.T) {
+	store, now := newTestStore()
+	limit := config.RateLimit{RequestsPerSecond: 1, Burst: 1}
+	ctx := context.Background()
+
+	for i := 0; i < sweepEvery-1; i++ {
+		store.Take(ctx, fmt.Sprintf("client-%d", i), limit)
+	}
+	assert.Equal(t, sweepEvery-1, store.Len())
+
+	*now = now.Add(time.Second)
+	store.Take(ctx, "latest", limit)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestMemoryStore_SweepUsesEachBucketsLimit(t *testing.T) {
+	store, now := newTestStore()
+	strict := config.RateLimit{RequestsPerSecond: 0.01, Burst: 1}
+	cheap := config.RateLimit{RequestsPerSecond: 1000, Burst: 1000}
+	ctx := context.Background()
+
+	decision, _ := store.Take(ctx, "CreateTask|client", strict)
+	assert.True(t, decision.Allowed)
+
+	// A sweep triggered by cheap calls must not treat the strict bucket as
+	// refilled
+	*now = now.Add(time.Second)
+	for i := 0; i < sweepEvery; i++ {
+		store.Take(ctx, "ListTasks|client", cheap)
+	}
+
+	decision, _ = store.Take(ctx, "CreateTask|client", strict)
+	assert.False(t, decision.Allowed)
+}
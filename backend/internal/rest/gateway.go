@@ -0,0 +1,317 @@
+// Package rest serves TaskService as plain REST/JSON for clients that do not
+// speak Connect or gRPC
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/gen/task/v2/taskv2connect"
+	"github.com/wcygan/todo/backend/internal/middleware"
+	"github.com/wcygan/todo/backend/internal/ratelimit"
+	"github.com/wcygan/todo/backend/internal/service"
+)
+
+const (
+	// IdempotencyKeyHeader makes a retried POST, PATCH or DELETE return the
+	// original response, as it does for the Connect API
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// maxBodyBytes bounds request bodies
+	maxBodyBytes = 1 << 20
+)
+
+// taskJSON renders tasks with every field present, so that REST clients see
+// "completed": false rather than a missing key
+var taskJSON = protojson.MarshalOptions{EmitUnpopulated: true}
+
+// Gateway maps REST routes onto TaskService. Routes are charged to the
+// matching task.v2 procedure, so per-procedure timeouts and rate limits
+// apply to both APIs alike.
+type Gateway struct {
+	service     *service.TaskService
+	timeouts    TimeoutPolicy
+	limiter     *ratelimit.Limiter
+	scrub       bool
+	errorWriter *connect.ErrorWriter
+}
+
+// Option configures a Gateway
+type Option func(*Gateway)
+
+// TimeoutPolicy returns the request timeout of a procedure; zero means no
+// timeout. *config.TimeoutConfig and *middleware.RequestTimeouts implement it.
+type TimeoutPolicy interface {
+	ForProcedure(procedure string) time.Duration
+}
+
+// WithTimeouts bounds each request by the timeout of its procedure
+func WithTimeouts(timeouts TimeoutPolicy) Option {
+	return func(g *Gateway) {
+		g.timeouts = timeouts
+	}
+}
+
+// WithRateLimiter charges each request to the client the limiter
+// identifies, as its Connect interceptor does
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(g *Gateway) {
+		g.limiter = limiter
+	}
+}
+
+// WithScrubbedErrors hides the message of internal errors, as
+// errors.ScrubInternalErrors does for the Connect API
+func WithScrubbedErrors() Option {
+	return func(g *Gateway) {
+		g.scrub = true
+	}
+}
+
+// New creates a Gateway for taskService
+func New(taskService *service.TaskService, opts ...Option) *Gateway {
+	g := &Gateway{
+		service:     taskService,
+		errorWriter: connect.NewErrorWriter(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// route is one REST endpoint and its OpenAPI description
+type route struct {
+	method      string
+	path        string
+	procedure   string
+	operationID string
+	summary     string
+	handle      func(g *Gateway, w http.ResponseWriter, r *http.Request) error
+}
+
+// routes lists every REST endpoint; Register and OpenAPI both read it
+var routes = []route{
+	{
+		method:      http.MethodGet,
+		path:        "/api/tasks",
+		procedure:   taskv2connect.TaskServiceListTasksProcedure,
+		operationID: "listTasks",
+		summary:     "List tasks, newest first",
+		handle:      (*Gateway).listTasks,
+	},
+	{
+		method:      http.MethodPost,
+		path:        "/api/tasks",
+		procedure:   taskv2connect.TaskServiceCreateTaskProcedure,
+		operationID: "createTask",
+		summary:     "Create a task",
+		handle:      (*Gateway).createTask,
+	},
+	{
+		method:      http.MethodGet,
+		path:        "/api/tasks/{id}",
+		procedure:   taskv2connect.TaskServiceGetTaskProcedure,
+		operationID: "getTask",
+		summary:     "Get a task",
+		handle:      (*Gateway).getTask,
+	},
+	{
+		method:      http.MethodPatch,
+		path:        "/api/tasks/{id}",
+		procedure:   taskv2connect.TaskServiceUpdateTaskProcedure,
+		operationID: "updateTask",
+		summary:     "Update the given fields of a task",
+		handle:      (*Gateway).updateTask,
+	},
+	{
+		method:      http.MethodDelete,
+		path:        "/api/tasks/{id}",
+		procedure:   taskv2connect.TaskServiceDeleteTaskProcedure,
+		operationID: "deleteTask",
+		summary:     "Delete a task",
+		handle:      (*Gateway).deleteTask,
+	},
+}
+
+// Register adds the REST routes and GET /openapi.json to mux
+func (g *Gateway) Register(mux *http.ServeMux) {
+	for _, rt := range routes {
+		mux.Handle(rt.method+" "+rt.path, g.wrap(rt))
+	}
+	mux.HandleFunc("GET /openapi.json", serveOpenAPI)
+}
+
+// wrap applies rate limiting, the procedure timeout and error rendering to
+// a route
+func (g *Gateway) wrap(rt route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if g.limiter != nil {
+			key := g.limiter.Key(ctx, connect.Peer{Addr: r.RemoteAddr}, r.Header)
+			if err := g.limiter.Allow(ctx, rt.procedure, key); err != nil {
+				g.writeError(w, r, err)
+				return
+			}
+		}
+
+		if g.timeouts != nil {
+			if timeout := g.timeouts.ForProcedure(rt.procedure); timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+		}
+
+		r = r.WithContext(service.WithIdempotencyKey(ctx, middleware.ClientName(ctx), r.Header.Get(IdempotencyKeyHeader)))
+		if err := rt.handle(g, w, r); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				err = errors.Timeout(rt.procedure)
+			}
+			g.writeError(w, r, err)
+		}
+	})
+}
+
+// writeError renders err as a Connect JSON error with the HTTP status that
+// Connect uses for its code
+func (g *Gateway) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	err = errors.ToConnectError(err)
+	if g.scrub {
+		err = errors.ScrubInternal(err)
+	}
+	g.errorWriter.Write(w, r, err)
+}
+
+// listTasks handles GET /api/tasks?pageSize=&pageToken=
+func (g *Gateway) listTasks(w http.ResponseWriter, r *http.Request) error {
+	pageSize := 0
+	if value := r.URL.Query().Get("pageSize"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Validation("pageSize", "must be an integer")
+		}
+		pageSize = size
+	}
+
+	page, err := g.service.ListTasksPage(r.Context(), pageSize, r.URL.Query().Get("pageToken"))
+	if err != nil {
+		return err
+	}
+
+	tasks := make([]json.RawMessage, len(page.Tasks))
+	for i, task := range page.Tasks {
+		data, err := taskJSON.Marshal(task)
+		if err != nil {
+			return errors.InternalWrap(err, "failed to encode task")
+		}
+		tasks[i] = data
+	}
+	return writeJSON(w, http.StatusOK, struct {
+		Tasks         []json.RawMessage `json:"tasks"`
+		NextPageToken string            `json:"nextPageToken,omitempty"`
+	}{tasks, page.NextPageToken})
+}
+
+// createTask handles POST /api/tasks
+func (g *Gateway) createTask(w http.ResponseWriter, r *http.Request) error {
+	var body struct {
+		Description string `json:"description"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		return err
+	}
+
+	task, err := g.service.CreateTask(r.Context(), body.Description)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Location", "/api/tasks/"+task.Id)
+	return writeTask(w, http.StatusCreated, task)
+}
+
+// getTask handles GET /api/tasks/{id}
+func (g *Gateway) getTask(w http.ResponseWriter, r *http.Request) error {
+	task, err := g.service.GetTask(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return writeTask(w, http.StatusOK, task)
+}
+
+// updateTask handles PATCH /api/tasks/{id}; fields left out of the body are
+// unchanged
+func (g *Gateway) updateTask(w http.ResponseWriter, r *http.Request) error {
+	var body struct {
+		Description *string `json:"description"`
+		Completed   *bool   `json:"completed"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		return err
+	}
+
+	task, err := g.service.PatchTask(r.Context(), r.PathValue("id"), service.TaskPatch{
+		Description: body.Description,
+		Completed:   body.Completed,
+	})
+	if err != nil {
+		return err
+	}
+	return writeTask(w, http.StatusOK, task)
+}
+
+// deleteTask handles DELETE /api/tasks/{id}
+func (g *Gateway) deleteTask(w http.ResponseWriter, r *http.Request) error {
+	if err := g.service.DeleteTask(r.Context(), r.PathValue("id")); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// decodeBody parses a JSON request body into dst, rejecting unknown fields
+func decodeBody(r *http.Request, dst any) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return errors.Validation("body", fmt.Sprintf("invalid JSON: %v", err))
+	}
+	return nil
+}
+
+// writeTask writes task as the response body
+func writeTask(w http.ResponseWriter, status int, task *taskv1.Task) error {
+	data, err := taskJSON.Marshal(task)
+	if err != nil {
+		return errors.InternalWrap(err, "failed to encode task")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The status is sent; a failed write means the client went away
+	w.Write(data)
+	return nil
+}
+
+// writeJSON writes value as the response body
+func writeJSON(w http.ResponseWriter, status int, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.InternalWrap(err, "failed to encode response")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// The status is sent; a failed write means the client went away
+	w.Write(data)
+	return nil
+}
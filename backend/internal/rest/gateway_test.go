@@ -0,0 +1,234 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/config"
+	"github.com/wcygan/todo/backend/internal/ratelimit"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// restTask is the JSON form of a task
+type restTask struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Completed   bool      `json:"completed"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// restError is the Connect JSON error form
+type restError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// newTestServer serves a Gateway over a fresh mock store
+func newTestServer(t *testing.T, opts ...Option) *httptest.Server {
+	taskService := service.NewTaskService(testutil.NewMockStore(),
+		service.WithIdempotency(store.NewMemoryIdempotencyStore(), time.Hour),
+	)
+	mux := http.NewServeMux()
+	New(taskService, opts...).Register(mux)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// do sends a request with an optional JSON body and headers
+func do(t *testing.T, server *httptest.Server, method, path, body string, headers ...string) *http.Response {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// decode reads a JSON response body into dst
+func decode(t *testing.T, resp *http.Response, dst any) {
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(dst))
+}
+
+func TestGateway_CRUD(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := do(t, server, http.MethodPost, "/api/tasks", `{"description":"Buy milk"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created restTask
+	decode(t, resp, &created)
+	assert.Equal(t, "Buy milk", created.Description)
+	assert.False(t, created.Completed)
+	assert.Equal(t, "/api/tasks/"+created.ID, resp.Header.Get("Location"))
+
+	resp = do(t, server, http.MethodGet, "/api/tasks/"+created.ID, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var fetched restTask
+	decode(t, resp, &fetched)
+	assert.Equal(t, created, fetched)
+
+	resp = do(t, server, http.MethodPatch, "/api/tasks/"+created.ID, `{"completed":true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated restTask
+	decode(t, resp, &updated)
+	assert.Equal(t, "Buy milk", updated.Description, "fields left out are unchanged")
+	assert.True(t, updated.Completed)
+
+	resp = do(t, server, http.MethodDelete, "/api/tasks/"+created.ID, "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = do(t, server, http.MethodGet, "/api/tasks/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	var notFound restError
+	decode(t, resp, &notFound)
+	assert.Equal(t, "not_found", notFound.Code)
+}
+
+func TestGateway_ListTasksPagination(t *testing.T) {
+	server := newTestServer(t)
+	for _, description := range []string{"one", "two", "three"} {
+		resp := do(t, server, http.MethodPost, "/api/tasks", `{"description":"`+description+`"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	var ids []string
+	path := "/api/tasks?pageSize=2"
+	for {
+		resp := do(t, server, http.MethodGet, path, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var page struct {
+			Tasks         []restTask `json:"tasks"`
+			NextPageToken string     `json:"nextPageToken"`
+		}
+		decode(t, resp, &page)
+		assert.LessOrEqual(t, len(page.Tasks), 2)
+		for _, task := range page.Tasks {
+			ids = append(ids, task.ID)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		path = "/api/tasks?pageSize=2&pageToken=" + page.NextPageToken
+	}
+	assert.Len(t, ids, 3)
+
+	resp := do(t, server, http.MethodGet, "/api/tasks?pageSize=many", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGateway_InvalidRequests(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"empty_description", http.MethodPost, "/api/tasks", `{"description":""}`, http.StatusBadRequest, "invalid_argument"},
+		{"malformed_json", http.MethodPost, "/api/tasks", `{"description":`, http.StatusBadRequest, "invalid_argument"},
+		{"unknown_field", http.MethodPost, "/api/tasks", `{"title":"Buy milk"}`, http.StatusBadRequest, "invalid_argument"},
+		{"get_malformed_id", http.MethodGet, "/api/tasks/abc", "", http.StatusBadRequest, "invalid_argument"},
+		{"patch_malformed_id", http.MethodPatch, "/api/tasks/abc", `{"completed":true}`, http.StatusBadRequest, "invalid_argument"},
+		{"patch_missing_task", http.MethodPatch, "/api/tasks/999", `{"completed":true}`, http.StatusNotFound, "not_found"},
+		{"delete_missing_task", http.MethodDelete, "/api/tasks/999", "", http.StatusNotFound, "not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, server, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+			var body restError
+			decode(t, resp, &body)
+			assert.Equal(t, tt.code, body.Code)
+		})
+	}
+}
+
+func TestGateway_IdempotencyKey(t *testing.T) {
+	server := newTestServer(t)
+
+	first := do(t, server, http.MethodPost, "/api/tasks", `{"description":"Buy milk"}`, IdempotencyKeyHeader, "create-1")
+	second := do(t, server, http.MethodPost, "/api/tasks", `{"description":"Buy milk"}`, IdempotencyKeyHeader, "create-1")
+	require.Equal(t, http.StatusCreated, first.StatusCode)
+	require.Equal(t, http.StatusCreated, second.StatusCode)
+
+	var a, b restTask
+	decode(t, first, &a)
+	decode(t, second, &b)
+	assert.Equal(t, a.ID, b.ID)
+}
+
+func TestGateway_RateLimit(t *testing.T) {
+	cfg := &config.RateLimitConfig{
+		Enabled: true,
+		Default: config.RateLimit{RequestsPerSecond: 1, Burst: 1},
+	}
+	server := newTestServer(t, WithRateLimiter(ratelimit.New(cfg, ratelimit.NewMemoryStore())))
+
+	resp := do(t, server, http.MethodGet, "/api/tasks", "", "X-API-Key", "client-a")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = do(t, server, http.MethodGet, "/api/tasks", "", "X-API-Key", "client-a")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	// Unverified API keys do not get their own bucket
+	resp = do(t, server, http.MethodGet, "/api/tasks", "", "X-API-Key", "client-b")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
+
+func TestOpenAPI(t *testing.T) {
+	server := newTestServer(t)
+
+	resp := do(t, server, http.MethodGet, "/openapi.json", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var document struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	decode(t, resp, &document)
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+
+	// Every route is documented, with its error responses
+	for _, rt := range routes {
+		operation, ok := document.Paths[rt.path][strings.ToLower(rt.method)]
+		require.True(t, ok, "%s %s is not documented", rt.method, rt.path)
+		assert.Equal(t, rt.operationID, operation["operationId"])
+
+		responses, ok := operation["responses"].(map[string]any)
+		require.True(t, ok)
+		assert.Contains(t, responses, "400")
+		assert.Contains(t, responses, "500")
+	}
+}
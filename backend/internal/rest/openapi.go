@@ -0,0 +1,199 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// object is a JSON object in the OpenAPI document
+type object = map[string]any
+
+// schemaRef refers to a schema under components
+func schemaRef(name string) object {
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+// jsonContent describes a JSON body with the named schema
+func jsonContent(schema string) object {
+	return object{"application/json": object{"schema": schemaRef(schema)}}
+}
+
+// errorResponse describes a Connect JSON error response
+func errorResponse(description string) object {
+	return object{"description": description, "content": jsonContent("Error")}
+}
+
+// operationDetails holds the parts of an operation that differ per route
+var operationDetails = map[string]object{
+	"listTasks": {
+		"parameters": []object{
+			{
+				"name":        "pageSize",
+				"in":          "query",
+				"description": "Maximum number of tasks to return; defaults to 50 and is capped at 1000",
+				"schema":      object{"type": "integer", "minimum": 0},
+			},
+			{
+				"name":        "pageToken",
+				"in":          "query",
+				"description": "nextPageToken from a previous response",
+				"schema":      object{"type": "string"},
+			},
+		},
+		"responses": object{
+			"200": object{"description": "A page of tasks", "content": jsonContent("TaskList")},
+		},
+	},
+	"createTask": {
+		"requestBody": object{"required": true, "content": jsonContent("CreateTaskRequest")},
+		"responses": object{
+			"201": object{
+				"description": "The created task",
+				"headers":     object{"Location": object{"schema": object{"type": "string"}}},
+				"content":     jsonContent("Task"),
+			},
+		},
+	},
+	"getTask": {
+		"responses": object{
+			"200": object{"description": "The task", "content": jsonContent("Task")},
+		},
+	},
+	"updateTask": {
+		"requestBody": object{"required": true, "content": jsonContent("UpdateTaskRequest")},
+		"responses": object{
+			"200": object{"description": "The updated task", "content": jsonContent("Task")},
+		},
+	},
+	"deleteTask": {
+		"responses": object{
+			"204": object{"description": "The task was deleted"},
+		},
+	},
+}
+
+// schemas are the components shared by the operations
+var schemas = object{
+	"Task": object{
+		"type":     "object",
+		"required": []string{"id", "description", "completed", "createdAt", "updatedAt"},
+		"properties": object{
+			"id":          object{"type": "string"},
+			"description": object{"type": "string"},
+			"completed":   object{"type": "boolean"},
+			"createdAt":   object{"type": "string", "format": "date-time"},
+			"updatedAt":   object{"type": "string", "format": "date-time"},
+		},
+	},
+	"TaskList": object{
+		"type":     "object",
+		"required": []string{"tasks"},
+		"properties": object{
+			"tasks":         object{"type": "array", "items": schemaRef("Task")},
+			"nextPageToken": object{"type": "string", "description": "Absent on the last page"},
+		},
+	},
+	"CreateTaskRequest": object{
+		"type":                 "object",
+		"required":             []string{"description"},
+		"additionalProperties": false,
+		"properties": object{
+			"description": object{"type": "string", "minLength": 1},
+		},
+	},
+	"UpdateTaskRequest": object{
+		"type":                 "object",
+		"description":          "Fields left out are unchanged",
+		"additionalProperties": false,
+		"properties": object{
+			"description": object{"type": "string", "minLength": 1},
+			"completed":   object{"type": "boolean"},
+		},
+	},
+	"Error": object{
+		"type":        "object",
+		"description": "A Connect error",
+		"required":    []string{"code"},
+		"properties": object{
+			"code":    object{"type": "string", "example": "not_found"},
+			"message": object{"type": "string"},
+			"details": object{"type": "array", "items": object{"type": "object"}},
+		},
+	},
+}
+
+// openAPIDocument builds the OpenAPI 3 document from routes
+func openAPIDocument() object {
+	paths := object{}
+	for _, rt := range routes {
+		operation := object{
+			"operationId": rt.operationID,
+			"summary":     rt.summary,
+		}
+		for key, value := range operationDetails[rt.operationID] {
+			operation[key] = value
+		}
+
+		// Copy the route's responses before adding the shared ones
+		responses := object{}
+		for status, response := range operation["responses"].(object) {
+			responses[status] = response
+		}
+		operation["responses"] = responses
+		responses["400"] = errorResponse("The request is invalid")
+		responses["429"] = errorResponse("Rate limit exceeded; see Retry-After")
+		responses["504"] = errorResponse("The request timed out")
+		responses["500"] = errorResponse("Internal error")
+		if strings.Contains(rt.path, "{id}") {
+			responses["404"] = errorResponse("The task does not exist")
+			operation["parameters"] = []object{
+				{"name": "id", "in": "path", "required": true, "schema": object{"type": "string"}},
+			}
+		}
+		if rt.method != http.MethodGet {
+			responses["409"] = errorResponse("A request with the same Idempotency-Key is in progress")
+			parameters, _ := operation["parameters"].([]object)
+			operation["parameters"] = append(parameters, object{
+				"name":        IdempotencyKeyHeader,
+				"in":          "header",
+				"description": "Retries with the same key return the original response",
+				"schema":      object{"type": "string", "maxLength": 255},
+			})
+		}
+
+		item, ok := paths[rt.path].(object)
+		if !ok {
+			item = object{}
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = operation
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "Todo REST API",
+			"version":     "1.0.0",
+			"description": "REST/JSON mapping of task.v2.TaskService. Errors use the Connect JSON error format.",
+		},
+		"paths":      paths,
+		"components": object{"schemas": schemas},
+	}
+}
+
+// openAPIJSON renders the document once
+var openAPIJSON = sync.OnceValue(func() []byte {
+	data, err := json.MarshalIndent(openAPIDocument(), "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return data
+})
+
+// serveOpenAPI handles GET /openapi.json
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIJSON())
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+)
+
+// TaskFormat is a file format for ExportTasks and ImportTasks
+type TaskFormat int
+
+const (
+	// FormatNDJSON is one task.v1.Task per line in protobuf JSON
+	FormatNDJSON TaskFormat = iota + 1
+	// FormatCSV is CSV with a header row
+	FormatCSV
+	// FormatMarkdown is a GitHub-style checklist
+	FormatMarkdown
+)
+
+// String returns the format name
+func (f TaskFormat) String() string {
+	switch f {
+	case FormatNDJSON:
+		return "ndjson"
+	case FormatCSV:
+		return "csv"
+	case FormatMarkdown:
+		return "markdown"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTaskFormat returns the format named name, as printed by String
+func ParseTaskFormat(name string) (TaskFormat, error) {
+	for _, format := range []TaskFormat{FormatNDJSON, FormatCSV, FormatMarkdown} {
+		if strings.EqualFold(name, format.String()) {
+			return format, nil
+		}
+	}
+	return 0, errors.Validation("format", fmt.Sprintf("unknown format %q (must be ndjson, csv or markdown)", name))
+}
+
+// maxLineBytes bounds a single NDJSON or Markdown line
+const maxLineBytes = 1 << 20
+
+// csvHeader is the header row of exported CSV files
+var csvHeader = []string{"id", "description", "completed", "created_at", "updated_at"}
+
+// checklistItem matches a Markdown checklist item such as "- [x] Done"
+var checklistItem = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*?)\s*$`)
+
+// checklistPrefix matches lines that look like checklist items, so that
+// malformed ones are reported instead of silently skipped
+var checklistPrefix = regexp.MustCompile(`^\s*[-*+]\s+\[`)
+
+// importRow is a task parsed from an import file
+type importRow struct {
+	line        int
+	description string
+	completed   bool
+	createdAt   time.Time
+}
+
+// taskEncoder writes tasks in one format
+type taskEncoder interface {
+	encode(task *taskv1.Task) error
+	flush() error
+}
+
+// newTaskEncoder returns an encoder writing format to w
+func newTaskEncoder(format TaskFormat, w io.Writer) (taskEncoder, error) {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonEncoder{w: w}, nil
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: cw}, nil
+	case FormatMarkdown:
+		return &markdownEncoder{w: w}, nil
+	default:
+		return nil, errors.Validation("format", "format must be ndjson, csv or markdown")
+	}
+}
+
+// ndjsonEncoder writes one JSON task per line
+type ndjsonEncoder struct {
+	w io.Writer
+}
+
+func (e *ndjsonEncoder) encode(task *taskv1.Task) error {
+	data, err := protojson.Marshal(task)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+func (e *ndjsonEncoder) flush() error {
+	return nil
+}
+
+// csvEncoder writes one task per CSV record
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) encode(task *taskv1.Task) error {
+	return e.w.Write([]string{
+		task.Id,
+		task.Description,
+		strconv.FormatBool(task.Completed),
+		task.CreatedAt.AsTime().Format(time.RFC3339Nano),
+		task.UpdatedAt.AsTime().Format(time.RFC3339Nano),
+	})
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// markdownEncoder writes one checklist item per task. Line breaks in
+// descriptions become spaces, since an item is a single line.
+type markdownEncoder struct {
+	w io.Writer
+}
+
+func (e *markdownEncoder) encode(task *taskv1.Task) error {
+	box := " "
+	if task.Completed {
+		box = "x"
+	}
+	description := strings.Join(strings.Fields(task.Description), " ")
+	_, err := fmt.Fprintf(e.w, "- [%s] %s\n", box, description)
+	return err
+}
+
+func (e *markdownEncoder) flush() error {
+	return nil
+}
+
+// decodeTasks parses an import file. Problems with individual rows are
+// returned as row errors; an error means the file as a whole is unusable.
+func decodeTasks(format TaskFormat, r io.Reader) ([]importRow, []ImportRowError, error) {
+	switch format {
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatMarkdown:
+		return decodeMarkdown(r)
+	default:
+		return nil, nil, errors.Validation("format", "format must be ndjson, csv or markdown")
+	}
+}
+
+// scanLines calls fn with each line of r and its 1-based number
+func scanLines(r io.Reader, fn func(line int, text string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fn(line, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		if stderrors.Is(err, bufio.ErrTooLong) {
+			return errors.Validation("data", fmt.Sprintf("line %d exceeds %d bytes", line+1, maxLineBytes))
+		}
+		return errors.Validation("data", err.Error())
+	}
+	return nil
+}
+
+// decodeNDJSON parses one task.v1.Task per non-blank line
+func decodeNDJSON(r io.Reader) ([]importRow, []ImportRowError, error) {
+	var rows []importRow
+	var rowErrors []ImportRowError
+	err := scanLines(r, func(line int, text string) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+
+		var task taskv1.Task
+		if err := protojson.Unmarshal([]byte(text), &task); err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Line: line, Message: fmt.Sprintf("invalid task JSON: %v", err)})
+			return
+		}
+
+		row := importRow{line: line, description: task.Description, completed: task.Completed}
+		if task.CreatedAt != nil {
+			row.createdAt = task.CreatedAt.AsTime()
+		}
+		rows = append(rows, row)
+	})
+	return rows, rowErrors, err
+}
+
+// decodeCSV parses CSV with a header row naming at least a description
+// column; completed and created_at columns are optional and others ignored
+func decodeCSV(r io.Reader) ([]importRow, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Validation("data", fmt.Sprintf("invalid CSV header: %v", err))
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	descriptionColumn, ok := columns["description"]
+	if !ok {
+		return nil, nil, errors.Validation("data", "CSV header must include a description column")
+	}
+	completedColumn, hasCompleted := columns["completed"]
+	createdAtColumn, hasCreatedAt := columns["created_at"]
+
+	var rows []importRow
+	var rowErrors []ImportRowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if stderrors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, ImportRowError{Line: parseErr.StartLine, Message: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, errors.Validation("data", err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(column int) string {
+			if column < len(record) {
+				return strings.TrimSpace(record[column])
+			}
+			return ""
+		}
+
+		row := importRow{line: line, description: field(descriptionColumn)}
+		if hasCompleted {
+			completed, err := parseCompleted(field(completedColumn))
+			if err != nil {
+				rowErrors = append(rowErrors, ImportRowError{Line: line, Field: "completed", Message: err.Error()})
+				continue
+			}
+			row.completed = completed
+		}
+		if hasCreatedAt && field(createdAtColumn) != "" {
+			createdAt, err := time.Parse(time.RFC3339Nano, field(createdAtColumn))
+			if err != nil {
+				rowErrors = append(rowErrors, ImportRowError{Line: line, Field: "created_at", Message: "must be an RFC 3339 timestamp"})
+				continue
+			}
+			row.createdAt = createdAt
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+// parseCompleted accepts the usual spellings of a boolean, plus "x" as
+// written in checklists
+func parseCompleted(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "false", "0", "no", "n":
+		return false, nil
+	case "true", "1", "yes", "y", "x":
+		return true, nil
+	default:
+		return false, fmt.Errorf("%q is not a boolean", value)
+	}
+}
+
+// decodeMarkdown parses checklist items and ignores every other line
+func decodeMarkdown(r io.Reader) ([]importRow, []ImportRowError, error) {
+	var rows []importRow
+	var rowErrors []ImportRowError
+	err := scanLines(r, func(line int, text string) {
+		match := checklistItem.FindStringSubmatch(text)
+		if match == nil {
+			if checklistPrefix.MatchString(text) {
+				rowErrors = append(rowErrors, ImportRowError{Line: line, Message: `malformed checklist item, expected "- [ ] task" or "- [x] task"`})
+			}
+			return
+		}
+		rows = append(rows, importRow{
+			line:        line,
+			description: match[2],
+			completed:   match[1] != " ",
+		})
+	})
+	return rows, rowErrors, err
+}
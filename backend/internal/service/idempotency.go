@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+)
+
+const (
+	// maxIdempotencyKeyLength matches the idempotency_keys primary key column
+	maxIdempotencyKeyLength = 255
+	// defaultIdempotencyTTL is used when WithIdempotency is given no TTL
+	defaultIdempotencyTTL = 24 * time.Hour
+	// defaultIdempotencyLease is used when WithIdempotencyLease is not given
+	defaultIdempotencyLease = time.Minute
+)
+
+// idempotencyKeyContextKey is the context key for the caller's Idempotency-Key
+type idempotencyKeyContextKey struct{}
+
+// idempotencyKey is an Idempotency-Key and the caller that sent it
+type idempotencyKey struct {
+	caller string
+	key    string
+}
+
+// WithIdempotencyKey returns a context carrying the idempotency key sent by
+// caller, the verified name of the client or "" for anonymous clients.
+// Mutations made by the same caller with the same key return the first
+// response instead of running again; keys from different callers never
+// collide.
+func WithIdempotencyKey(ctx context.Context, caller, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, idempotencyKey{caller: caller, key: key})
+}
+
+// IdempotencyKeyFromContext returns the idempotency key carried by ctx
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyContextKey{}).(idempotencyKey)
+	return key.key, ok
+}
+
+// storedKey is the key a caller's idempotency key is stored under
+func (k idempotencyKey) storedKey() string {
+	return requestHash("Idempotency-Key", k.caller, k.key)
+}
+
+// requestHash fingerprints a mutation by operation and arguments
+func requestHash(operation string, args ...string) string {
+	h := sha256.New()
+	h.Write([]byte(operation))
+	for _, arg := range args {
+		// Length-prefix each argument so that ("ab", "c") and ("a", "bc")
+		// hash differently
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(arg)))
+		h.Write(length[:])
+		h.Write([]byte(arg))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotent runs fn at most once per caller and idempotency key. Without a
+// key in ctx, or without an idempotency store, fn simply runs. A replay with
+// the same request returns the stored response; a replay with a different
+// request is rejected. Failed requests release their key so that they can be
+// retried, and a reservation left by a request that never finished can be
+// taken over once its lease runs out.
+func idempotent[T proto.Message](ctx context.Context, s *TaskService, operation string, args []string, newResponse func() T, fn func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+
+	callerKey, ok := ctx.Value(idempotencyKeyContextKey{}).(idempotencyKey)
+	if !ok || s.idempotency == nil {
+		return fn(ctx)
+	}
+	if len(callerKey.key) > maxIdempotencyKeyLength {
+		return zero, errors.Validation("idempotency_key", "idempotency key cannot exceed 255 characters")
+	}
+	key := callerKey.storedKey()
+
+	hash := requestHash(operation, args...)
+	existing, err := s.idempotency.ReserveIdempotencyKey(ctx, key, hash, s.idempotencyTTL, s.idempotencyLease)
+	if err != nil {
+		if errors.IsConflict(err) {
+			return zero, err
+		}
+		return zero, errors.InternalWrap(err, "failed to reserve idempotency key")
+	}
+
+	if existing != nil {
+		if existing.RequestHash != hash {
+			return zero, errors.Validation("idempotency_key", "idempotency key was already used with a different request")
+		}
+		if !existing.Completed {
+			return zero, errors.Conflict("a request with this idempotency key is still in progress")
+		}
+
+		response := newResponse()
+		if err := proto.Unmarshal(existing.Response, response); err != nil {
+			return zero, errors.InternalWrap(err, "failed to decode stored idempotent response")
+		}
+		return response, nil
+	}
+
+	// Record the outcome even if the caller goes away mid-request
+	storeCtx := context.WithoutCancel(ctx)
+
+	response, err := fn(ctx)
+	if err != nil {
+		s.idempotency.ReleaseIdempotencyKey(storeCtx, key)
+		return zero, err
+	}
+
+	data, err := proto.Marshal(response)
+	if err != nil {
+		s.idempotency.ReleaseIdempotencyKey(storeCtx, key)
+		return response, nil
+	}
+	// The mutation already happened, so the response is returned either way.
+	// If it cannot be recorded, the reservation is dropped so that a retry
+	// runs again rather than waiting out the TTL; should that fail too, the
+	// lease lets a retry take the reservation over once it runs out.
+	if err := s.idempotency.CompleteIdempotencyKey(storeCtx, key, data); err != nil {
+		s.idempotency.ReleaseIdempotencyKey(storeCtx, key)
+	}
+
+	return response, nil
+}
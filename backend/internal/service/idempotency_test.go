@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+func newIdempotentService() (*TaskService, *testutil.MockStore) {
+	repo := testutil.NewMockStore()
+	return NewTaskService(repo, WithIdempotency(store.NewMemoryIdempotencyStore(), time.Hour)), repo
+}
+
+func TestIdempotency_CreateTaskReplay(t *testing.T) {
+	service, repo := newIdempotentService()
+	ctx := WithIdempotencyKey(context.Background(), "", "create-1")
+
+	first, err := service.CreateTask(ctx, "Buy milk")
+	require.NoError(t, err)
+
+	second, err := service.CreateTask(ctx, "Buy milk")
+	require.NoError(t, err)
+	testutil.AssertTaskEquals(t, first, second)
+
+	tasks, err := repo.ListTasks(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, tasks, 1, "replay must not create a second task")
+}
+
+func TestIdempotency_DifferentPayloadRejected(t *testing.T) {
+	service, _ := newIdempotentService()
+	ctx := WithIdempotencyKey(context.Background(), "", "create-1")
+
+	_, err := service.CreateTask(ctx, "Buy milk")
+	require.NoError(t, err)
+
+	_, err = service.CreateTask(ctx, "Buy bread")
+	require.Error(t, err)
+	assert.True(t, errors.IsValidation(err))
+
+	// Reusing a key for another operation is also a different payload
+	_, err = service.UpdateTask(ctx, "1", "Buy milk", true)
+	require.Error(t, err)
+	assert.True(t, errors.IsValidation(err))
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	service, repo := newIdempotentService()
+	ctx := context.Background()
+
+	_, err := service.CreateTask(ctx, "Buy milk")
+	require.NoError(t, err)
+	_, err = service.CreateTask(ctx, "Buy milk")
+	require.NoError(t, err)
+
+	tasks, err := repo.ListTasks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+}
+
+func TestIdempotency_UpdateTaskReplay(t *testing.T) {
+	service, repo := newIdempotentService()
+	task, err := repo.CreateTask(context.Background(), "Buy milk")
+	require.NoError(t, err)
+
+	ctx := WithIdempotencyKey(context.Background(), "", "update-1")
+	first, err := service.UpdateTask(ctx, task.Id, "Buy oat milk", true)
+	require.NoError(t, err)
+
+	// A later change must not be undone by replaying the first update
+	_, err = repo.UpdateTask(context.Background(), task.Id, "Buy soy milk", false)
+	require.NoError(t, err)
+
+	replayed, err := service.UpdateTask(ctx, task.Id, "Buy oat milk", true)
+	require.NoError(t, err)
+	testutil.AssertTaskEquals(t, first, replayed)
+
+	current, err := repo.GetTask(context.Background(), task.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Buy soy milk", current.Description)
+}
+
+func TestIdempotency_DeleteTaskReplay(t *testing.T) {
+	service, repo := newIdempotentService()
+	task, err := repo.CreateTask(context.Background(), "Buy milk")
+	require.NoError(t, err)
+
+	ctx := WithIdempotencyKey(context.Background(), "", "delete-1")
+	require.NoError(t, service.DeleteTask(ctx, task.Id))
+
+	// Without the key a second delete reports not found; with it the first
+	// response is replayed
+	assert.NoError(t, service.DeleteTask(ctx, task.Id))
+	assert.True(t, errors.IsNotFound(service.DeleteTask(context.Background(), task.Id)))
+}
+
+func TestIdempotency_FailureReleasesKey(t *testing.T) {
+	mockRepo := &MockTaskRepository{}
+	service := NewTaskService(mockRepo, WithIdempotency(store.NewMemoryIdempotencyStore(), time.Hour))
+	ctx := WithIdempotencyKey(context.Background(), "", "create-1")
+
+	created := &taskv1.Task{Id: "1", Description: "Buy milk"}
+	mockRepo.On("CreateTask", mock.Anything, "Buy milk").Return(nil, errors.Internal("database unavailable")).Once()
+	mockRepo.On("CreateTask", mock.Anything, "Buy milk").Return(created, nil).Once()
+
+	_, err := service.CreateTask(ctx, "Buy milk")
+	require.Error(t, err)
+
+	task, err := service.CreateTask(ctx, "Buy milk")
+	require.NoError(t, err)
+	assert.Equal(t, "1", task.Id)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	idempotencyStore := store.NewMemoryIdempotencyStore()
+	service := NewTaskService(testutil.NewMockStore(), WithIdempotency(idempotencyStore, time.Hour))
+
+	_, err := idempotencyStore.ReserveIdempotencyKey(context.Background(), idempotencyKey{key: "create-1"}.storedKey(), requestHash("CreateTask", "Buy milk"), time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	_, err = service.CreateTask(WithIdempotencyKey(context.Background(), "", "create-1"), "Buy milk")
+	require.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+}
+
+func TestIdempotency_StaleReservationTakenOver(t *testing.T) {
+	idempotencyStore := store.NewMemoryIdempotencyStore()
+	service := NewTaskService(testutil.NewMockStore(), WithIdempotency(idempotencyStore, time.Hour))
+
+	// A request that reserved the key and died before completing
+	key := idempotencyKey{key: "create-1"}.storedKey()
+	_, err := idempotencyStore.ReserveIdempotencyKey(context.Background(), key, requestHash("CreateTask", "Buy milk"), time.Hour, time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	task, err := service.CreateTask(WithIdempotencyKey(context.Background(), "", "create-1"), "Buy milk")
+	require.NoError(t, err)
+	assert.Equal(t, "Buy milk", task.Description)
+}
+
+// failingCompleteStore fails every CompleteIdempotencyKey
+type failingCompleteStore struct {
+	*store.MemoryIdempotencyStore
+}
+
+func (s *failingCompleteStore) CompleteIdempotencyKey(context.Context, string, []byte) error {
+	return errors.Internal("database unavailable")
+}
+
+func TestIdempotency_FailedCompleteReleasesKey(t *testing.T) {
+	repo := testutil.NewMockStore()
+	service := NewTaskService(repo, WithIdempotency(&failingCompleteStore{store.NewMemoryIdempotencyStore()}, time.Hour))
+	ctx := WithIdempotencyKey(context.Background(), "", "create-1")
+
+	_, err := service.CreateTask(ctx, "Buy milk")
+	require.NoError(t, err, "the task was created, so the response is returned")
+
+	// The response could not be recorded, so a retry runs again rather than
+	// being rejected as in progress until the key expires
+	_, err = service.CreateTask(ctx, "Buy milk")
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.TaskCount())
+}
+
+func TestIdempotency_ScopedToCaller(t *testing.T) {
+	service, repo := newIdempotentService()
+
+	alice, err := service.CreateTask(WithIdempotencyKey(context.Background(), "alice", "create-1"), "Buy milk")
+	require.NoError(t, err)
+	bob, err := service.CreateTask(WithIdempotencyKey(context.Background(), "bob", "create-1"), "Buy bread")
+	require.NoError(t, err, "another caller's key must not collide")
+	assert.NotEqual(t, alice.Id, bob.Id)
+
+	replayed, err := service.CreateTask(WithIdempotencyKey(context.Background(), "alice", "create-1"), "Buy milk")
+	require.NoError(t, err)
+	assert.Equal(t, alice.Id, replayed.Id)
+	assert.Equal(t, 2, repo.TaskCount())
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	service, _ := newIdempotentService()
+	ctx := WithIdempotencyKey(context.Background(), "", strings.Repeat("k", maxIdempotencyKeyLength+1))
+
+	_, err := service.CreateTask(ctx, "Buy milk")
+	require.Error(t, err)
+	assert.True(t, errors.IsValidation(err))
+}
+
+func TestRequestHash(t *testing.T) {
+	assert.Equal(t, requestHash("CreateTask", "a"), requestHash("CreateTask", "a"))
+	assert.NotEqual(t, requestHash("CreateTask", "a"), requestHash("DeleteTask", "a"))
+	assert.NotEqual(t, requestHash("UpdateTask", "ab", "c"), requestHash("UpdateTask", "a", "bc"))
+	assert.Len(t, requestHash("CreateTask"), 64)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/internal/tracing"
+)
+
+const (
+	// DefaultPageSize is used when a ListTasksPage caller asks for no size
+	DefaultPageSize = 50
+	// MaxPageSize caps the page size a caller may ask for
+	MaxPageSize = 1000
+)
+
+// TaskPage is one page of tasks, newest first
+type TaskPage struct {
+	Tasks []*taskv1.Task
+	// NextPageToken continues the listing; empty on the last page
+	NextPageToken string
+}
+
+// encodePageToken returns the opaque page token for the position of the
+// last task on a page. Tasks are ordered by creation time then ID, both
+// descending, so a token stays valid when tasks are created or deleted
+// between pages.
+func encodePageToken(last *taskv1.Task) string {
+	raw := strconv.FormatInt(last.CreatedAt.AsTime().UnixNano(), 10) + ":" + last.Id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageToken parses a token produced by encodePageToken
+func decodePageToken(token string) (*store.TaskCursor, error) {
+	invalid := errors.Validation("page_token", "page token is invalid")
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return nil, invalid
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	return &store.TaskCursor{CreatedAt: time.Unix(0, unixNano), ID: id}, nil
+}
+
+// ListTasksPage returns up to pageSize tasks following pageToken, newest
+// first. A zero pageSize means DefaultPageSize; larger sizes are capped at
+// MaxPageSize.
+func (s *TaskService) ListTasksPage(ctx context.Context, pageSize int, pageToken string) (_ *TaskPage, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.ListTasksPage")
+	span.SetAttributes(attribute.Int("page.size", pageSize))
+	defer func() { tracing.End(span, err) }()
+
+	switch {
+	case pageSize < 0:
+		return nil, errors.Validation("page_size", "page size cannot be negative")
+	case pageSize == 0:
+		pageSize = DefaultPageSize
+	case pageSize > MaxPageSize:
+		pageSize = MaxPageSize
+	}
+
+	var cursor *store.TaskCursor
+	if pageToken != "" {
+		if cursor, err = decodePageToken(pageToken); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read one task past the page to learn whether another page follows
+	tasks, err := store.ListTasksAfter(ctx, s.repo, cursor, pageSize+1)
+	if err != nil {
+		if errors.IsValidation(err) {
+			return nil, errors.Validation("page_token", "page token is invalid")
+		}
+		return nil, errors.InternalWrap(err, "failed to list tasks")
+	}
+
+	page := &TaskPage{Tasks: tasks}
+	if len(tasks) > pageSize {
+		page.Tasks = tasks[:pageSize]
+		page.NextPageToken = encodePageToken(page.Tasks[pageSize-1])
+	}
+	return page, nil
+}
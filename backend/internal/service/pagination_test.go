@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// seedTasks adds n tasks to repo, two per creation time so that pages must
+// break ties by ID
+func seedTasks(repo *testutil.MockStore, n int) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= n; i++ {
+		createdAt := timestamppb.New(base.Add(time.Duration(i/2) * time.Minute))
+		repo.AddTask(&taskv1.Task{
+			Id:          strconv.Itoa(i),
+			Description: "Task " + strconv.Itoa(i),
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		})
+	}
+}
+
+// collectPages lists every task pageSize at a time
+func collectPages(t *testing.T, service *TaskService, pageSize int) []string {
+	var ids []string
+	token := ""
+	for {
+		page, err := service.ListTasksPage(context.Background(), pageSize, token)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Tasks), pageSize)
+		for _, task := range page.Tasks {
+			ids = append(ids, task.Id)
+		}
+		if page.NextPageToken == "" {
+			return ids
+		}
+		token = page.NextPageToken
+	}
+}
+
+func TestListTasksPage_MatchesListTasks(t *testing.T) {
+	repo := testutil.NewMockStore()
+	seedTasks(repo, 11)
+	service := NewTaskService(repo)
+
+	all, err := service.ListTasks(context.Background())
+	require.NoError(t, err)
+	var expected []string
+	for _, task := range all {
+		expected = append(expected, task.Id)
+	}
+
+	for _, pageSize := range []int{1, 2, 3, 5, 11, 50} {
+		assert.Equal(t, expected, collectPages(t, service, pageSize), "page size %d", pageSize)
+	}
+}
+
+func TestListTasksPage_StableAcrossDeletes(t *testing.T) {
+	repo := testutil.NewMockStore()
+	seedTasks(repo, 6)
+	service := NewTaskService(repo)
+	ctx := context.Background()
+
+	first, err := service.ListTasksPage(ctx, 3, "")
+	require.NoError(t, err)
+	require.Len(t, first.Tasks, 3)
+
+	// Deleting the task the cursor points at must not skip or repeat tasks
+	require.NoError(t, repo.DeleteTask(ctx, first.Tasks[2].Id))
+
+	second, err := service.ListTasksPage(ctx, 3, first.NextPageToken)
+	require.NoError(t, err)
+	var ids []string
+	for _, task := range second.Tasks {
+		ids = append(ids, task.Id)
+	}
+	assert.Equal(t, []string{"3", "2", "1"}, ids)
+	assert.Empty(t, second.NextPageToken)
+}
+
+func TestListTasksPage_PageSize(t *testing.T) {
+	repo := testutil.NewMockStore()
+	seedTasks(repo, DefaultPageSize+1)
+	service := NewTaskService(repo)
+	ctx := context.Background()
+
+	page, err := service.ListTasksPage(ctx, 0, "")
+	require.NoError(t, err)
+	assert.Len(t, page.Tasks, DefaultPageSize)
+	assert.NotEmpty(t, page.NextPageToken)
+
+	page, err = service.ListTasksPage(ctx, MaxPageSize+1, "")
+	require.NoError(t, err)
+	assert.Len(t, page.Tasks, DefaultPageSize+1)
+	assert.Empty(t, page.NextPageToken)
+
+	_, err = service.ListTasksPage(ctx, -1, "")
+	assert.True(t, errors.IsValidation(err))
+}
+
+func TestListTasksPage_InvalidToken(t *testing.T) {
+	service := NewTaskService(testutil.NewMockStore())
+
+	for _, token := range []string{"not base64!", "bm9jb2xvbg", "eDox"} {
+		_, err := service.ListTasksPage(context.Background(), 10, token)
+		assert.True(t, errors.IsValidation(err), "token %q", token)
+	}
+}
+
+// pagerStore serves pages through store.TaskPager and refuses full listings
+type pagerStore struct {
+	*testutil.MockStore
+	limits []int
+}
+
+func (s *pagerStore) ListTasks(context.Context) ([]*taskv1.Task, error) {
+	return nil, errors.Internal("ListTasksPage must not list every task")
+}
+
+func (s *pagerStore) ListTasksAfter(ctx context.Context, cursor *store.TaskCursor, limit int) ([]*taskv1.Task, error) {
+	s.limits = append(s.limits, limit)
+	return store.ListTasksAfter(ctx, s.MockStore, cursor, limit)
+}
+
+func TestListTasksPage_UsesPager(t *testing.T) {
+	repo := &pagerStore{MockStore: testutil.NewMockStore()}
+	seedTasks(repo.MockStore, 5)
+	service := NewTaskService(repo)
+
+	assert.Equal(t, []string{"5", "4", "3", "2", "1"}, collectPages(t, service, 2))
+	assert.Equal(t, []int{3, 3, 3}, repo.limits, "pages read one task ahead")
+}
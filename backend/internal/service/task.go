@@ -2,51 +2,103 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/wcygan/todo/backend/internal/errors"
 	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/wcygan/todo/backend/internal/service")
+
 // TaskService handles business logic for task operations
 type TaskService struct {
 	repo store.TaskRepository
+
+	idempotency      store.IdempotencyStore
+	idempotencyTTL   time.Duration
+	idempotencyLease time.Duration
+}
+
+// Option configures a TaskService
+type Option func(*TaskService)
+
+// WithIdempotency enables Idempotency-Key handling for CreateTask, UpdateTask
+// and DeleteTask, keeping responses for ttl
+func WithIdempotency(idempotency store.IdempotencyStore, ttl time.Duration) Option {
+	return func(s *TaskService) {
+		if ttl <= 0 {
+			ttl = defaultIdempotencyTTL
+		}
+		s.idempotency = idempotency
+		s.idempotencyTTL = ttl
+	}
+}
+
+// WithIdempotencyLease sets how long a request holds its idempotency key
+// before a retry may take it over. It should outlast the longest request
+// timeout, or a retry can run alongside a request that is still going.
+func WithIdempotencyLease(lease time.Duration) Option {
+	return func(s *TaskService) {
+		if lease > 0 {
+			s.idempotencyLease = lease
+		}
+	}
 }
 
 // NewTaskService creates a new TaskService instance
-func NewTaskService(repo store.TaskRepository) *TaskService {
-	return &TaskService{
-		repo: repo,
+func NewTaskService(repo store.TaskRepository, opts ...Option) *TaskService {
+	s := &TaskService{
+		repo:             repo,
+		idempotencyLease: defaultIdempotencyLease,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateTask creates a new task with validation
-func (s *TaskService) CreateTask(ctx context.Context, description string) (*taskv1.Task, error) {
+func (s *TaskService) CreateTask(ctx context.Context, description string) (_ *taskv1.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.CreateTask")
+	defer func() { tracing.End(span, err) }()
+
 	// Validate input
 	if description == "" {
 		return nil, errors.Validation("description", "description cannot be empty")
 	}
 
-	// Create task
-	task, err := s.repo.CreateTask(ctx, description)
-	if err != nil {
-		return nil, errors.InternalWrap(err, "failed to create task")
-	}
-
-	return task, nil
+	return idempotent(ctx, s, "CreateTask", []string{description}, newTask,
+		func(ctx context.Context) (*taskv1.Task, error) {
+			task, err := s.repo.CreateTask(ctx, description)
+			if err != nil {
+				return nil, errors.InternalWrap(err, "failed to create task")
+			}
+			return task, nil
+		})
 }
 
 // GetTask retrieves a task by ID
-func (s *TaskService) GetTask(ctx context.Context, id string) (*taskv1.Task, error) {
+func (s *TaskService) GetTask(ctx context.Context, id string) (_ *taskv1.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.GetTask")
+	span.SetAttributes(attribute.String("task.id", id))
+	defer func() { tracing.End(span, err) }()
+
 	if id == "" {
 		return nil, errors.Validation("id", "task ID cannot be empty")
 	}
 
 	task, err := s.repo.GetTask(ctx, id)
 	if err != nil {
-		// Pass through not found errors, wrap others
-		if errors.IsNotFound(err) {
+		// Pass through not found and validation errors, wrap others
+		if errors.IsNotFound(err) || errors.IsValidation(err) {
 			return nil, err
 		}
 		return nil, errors.InternalWrap(err, "failed to get task")
@@ -56,7 +108,10 @@ func (s *TaskService) GetTask(ctx context.Context, id string) (*taskv1.Task, err
 }
 
 // ListTasks returns all tasks
-func (s *TaskService) ListTasks(ctx context.Context) ([]*taskv1.Task, error) {
+func (s *TaskService) ListTasks(ctx context.Context) (_ []*taskv1.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.ListTasks")
+	defer func() { tracing.End(span, err) }()
+
 	tasks, err := s.repo.ListTasks(ctx)
 	if err != nil {
 		return nil, errors.InternalWrap(err, "failed to list tasks")
@@ -66,37 +121,102 @@ func (s *TaskService) ListTasks(ctx context.Context) ([]*taskv1.Task, error) {
 }
 
 // UpdateTask updates an existing task
-func (s *TaskService) UpdateTask(ctx context.Context, id, description string, completed bool) (*taskv1.Task, error) {
+func (s *TaskService) UpdateTask(ctx context.Context, id, description string, completed bool) (_ *taskv1.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.UpdateTask")
+	span.SetAttributes(attribute.String("task.id", id))
+	defer func() { tracing.End(span, err) }()
+
 	if id == "" {
 		return nil, errors.Validation("id", "task ID cannot be empty")
 	}
 
-	task, err := s.repo.UpdateTask(ctx, id, description, completed)
-	if err != nil {
-		// Pass through not found errors, wrap others
-		if errors.IsNotFound(err) {
-			return nil, err
-		}
-		return nil, errors.InternalWrap(err, "failed to update task")
+	args := []string{id, description, strconv.FormatBool(completed)}
+	return idempotent(ctx, s, "UpdateTask", args, newTask,
+		func(ctx context.Context) (*taskv1.Task, error) {
+			task, err := s.repo.UpdateTask(ctx, id, description, completed)
+			if err != nil {
+				// Pass through not found and validation errors, wrap others
+				if errors.IsNotFound(err) || errors.IsValidation(err) {
+					return nil, err
+				}
+				return nil, errors.InternalWrap(err, "failed to update task")
+			}
+			return task, nil
+		})
+}
+
+// TaskPatch lists the fields of a partial update; nil fields are unchanged
+type TaskPatch = store.TaskPatch
+
+// PatchTask updates only the fields set in patch
+func (s *TaskService) PatchTask(ctx context.Context, id string, patch TaskPatch) (_ *taskv1.Task, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.PatchTask")
+	span.SetAttributes(attribute.String("task.id", id))
+	defer func() { tracing.End(span, err) }()
+
+	if id == "" {
+		return nil, errors.Validation("id", "task ID cannot be empty")
+	}
+	if patch.Description != nil && *patch.Description == "" {
+		return nil, errors.Validation("description", "description cannot be empty")
+	}
+	if patch.Description == nil && patch.Completed == nil {
+		return s.GetTask(ctx, id)
 	}
 
-	return task, nil
+	args := []string{id, optionalArg(patch.Description), optionalArg(patch.Completed)}
+	return idempotent(ctx, s, "PatchTask", args, newTask,
+		func(ctx context.Context) (*taskv1.Task, error) {
+			task, err := store.PatchTask(ctx, s.repo, id, patch)
+			if err != nil {
+				if errors.IsNotFound(err) || errors.IsValidation(err) {
+					return nil, err
+				}
+				return nil, errors.InternalWrap(err, "failed to update task")
+			}
+			return task, nil
+		})
+}
+
+// optionalArg renders an optional patch field for the request hash, keeping
+// unset distinct from every value
+func optionalArg[T any](value *T) string {
+	if value == nil {
+		return "unset"
+	}
+	return fmt.Sprintf("set:%v", *value)
 }
 
 // DeleteTask removes a task by ID
-func (s *TaskService) DeleteTask(ctx context.Context, id string) error {
+func (s *TaskService) DeleteTask(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.DeleteTask")
+	span.SetAttributes(attribute.String("task.id", id))
+	defer func() { tracing.End(span, err) }()
+
 	if id == "" {
 		return errors.Validation("id", "task ID cannot be empty")
 	}
 
-	err := s.repo.DeleteTask(ctx, id)
-	if err != nil {
-		// Pass through not found errors, wrap others
-		if errors.IsNotFound(err) {
-			return err
-		}
-		return errors.InternalWrap(err, "failed to delete task")
-	}
+	_, err = idempotent(ctx, s, "DeleteTask", []string{id}, newEmpty,
+		func(ctx context.Context) (*emptypb.Empty, error) {
+			if err := s.repo.DeleteTask(ctx, id); err != nil {
+				// Pass through not found and validation errors, wrap others
+				if errors.IsNotFound(err) || errors.IsValidation(err) {
+					return nil, err
+				}
+				return nil, errors.InternalWrap(err, "failed to delete task")
+			}
+			return &emptypb.Empty{}, nil
+		})
+	return err
+}
+
+// newTask allocates a task for decoding a stored response
+func newTask() *taskv1.Task {
+	return &taskv1.Task{}
+}
 
-	return nil
+// newEmpty allocates an empty message for decoding a stored response
+func newEmpty() *emptypb.Empty {
+	return &emptypb.Empty{}
 }
\ No newline at end of file
@@ -8,9 +8,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/test/testutil"
 )
 
 // MockTaskRepository is a mock implementation of TaskRepository
@@ -336,4 +341,104 @@ func TestTaskService_DeleteTask(t *testing.T) {
 			mockRepo.AssertExpectations(t)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestTaskService_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	mockRepo := &MockTaskRepository{}
+	mockRepo.On("GetTask", mock.Anything, "1").Return(&taskv1.Task{Id: "1"}, nil)
+	mockRepo.On("GetTask", mock.Anything, "2").Return(nil, errors.NotFound("task", "2"))
+	service := NewTaskService(mockRepo)
+	ctx := context.Background()
+
+	_, err := service.GetTask(ctx, "1")
+	require.NoError(t, err)
+	_, err = service.GetTask(ctx, "2")
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "TaskService.GetTask", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestTaskService_PatchTask(t *testing.T) {
+	description := "Buy oat milk"
+	completed := true
+	empty := ""
+
+	tests := []struct {
+		name                string
+		patch               TaskPatch
+		expectedDescription string
+		expectedCompleted   bool
+		errCode             errors.ErrorCode
+	}{
+		{
+			name:                "description_only",
+			patch:               TaskPatch{Description: &description},
+			expectedDescription: "Buy oat milk",
+		},
+		{
+			name:                "completed_only",
+			patch:               TaskPatch{Completed: &completed},
+			expectedDescription: "Buy milk",
+			expectedCompleted:   true,
+		},
+		{
+			name:                "both",
+			patch:               TaskPatch{Description: &description, Completed: &completed},
+			expectedDescription: "Buy oat milk",
+			expectedCompleted:   true,
+		},
+		{
+			name:                "empty_patch_is_a_no_op",
+			patch:               TaskPatch{},
+			expectedDescription: "Buy milk",
+		},
+		{
+			name:    "empty_description",
+			patch:   TaskPatch{Description: &empty},
+			errCode: errors.CodeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := testutil.NewMockStore()
+			service := NewTaskService(repo)
+			ctx := context.Background()
+
+			created, err := repo.CreateTask(ctx, "Buy milk")
+			require.NoError(t, err)
+
+			task, err := service.PatchTask(ctx, created.Id, tt.patch)
+			if tt.errCode != "" {
+				require.Error(t, err)
+				var appErr *errors.Error
+				require.True(t, errors.As(err, &appErr))
+				assert.Equal(t, tt.errCode, appErr.Code)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedDescription, task.Description)
+			assert.Equal(t, tt.expectedCompleted, task.Completed)
+		})
+	}
+}
+
+func TestTaskService_PatchTask_NotFound(t *testing.T) {
+	service := NewTaskService(testutil.NewMockStore())
+	completed := true
+
+	_, err := service.PatchTask(context.Background(), "999", TaskPatch{Completed: &completed})
+	assert.True(t, errors.IsNotFound(err))
+
+	_, err = service.PatchTask(context.Background(), "", TaskPatch{Completed: &completed})
+	assert.True(t, errors.IsValidation(err))
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/internal/tracing"
+)
+
+const (
+	// MaxImportRows is the largest number of rows accepted by ImportTasks
+	MaxImportRows = 10000
+	// maxDescriptionBytes matches the TEXT column holding descriptions
+	maxDescriptionBytes = 65535
+)
+
+// ImportOptions configures ImportTasks
+type ImportOptions struct {
+	// DryRun validates the file and reports what would be imported without
+	// writing anything
+	DryRun bool
+}
+
+// ImportRowError describes a row that could not be imported
+type ImportRowError struct {
+	Line    int
+	Field   string
+	Message string
+}
+
+// ImportDuplicate describes a row skipped because its description matches an
+// existing task or an earlier row
+type ImportDuplicate struct {
+	Line        int
+	Description string
+}
+
+// ImportResult is the outcome of ImportTasks. Nothing is written when Errors
+// is not empty.
+type ImportResult struct {
+	// Tasks holds the created tasks, or on a dry run the tasks that would be
+	// created, without IDs
+	Tasks      []*taskv1.Task
+	Errors     []ImportRowError
+	Duplicates []ImportDuplicate
+	DryRun     bool
+}
+
+// ExportTasks writes every task to w in format, newest first
+func (s *TaskService) ExportTasks(ctx context.Context, format TaskFormat, w io.Writer) (err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.ExportTasks")
+	span.SetAttributes(attribute.String("export.format", format.String()))
+	defer func() { tracing.End(span, err) }()
+
+	encoder, err := newTaskEncoder(format, w)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := s.repo.ListTasks(ctx)
+	if err != nil {
+		return errors.InternalWrap(err, "failed to list tasks")
+	}
+	span.SetAttributes(attribute.Int("export.tasks", len(tasks)))
+
+	for _, task := range tasks {
+		if err := encoder.encode(task); err != nil {
+			return err
+		}
+	}
+	return encoder.flush()
+}
+
+// ImportTasks creates tasks from a file in format. Every row is validated
+// first; duplicates are skipped, and if any row is invalid nothing is
+// written. The remaining rows are written in one transaction.
+func (s *TaskService) ImportTasks(ctx context.Context, format TaskFormat, r io.Reader, opts ImportOptions) (_ *ImportResult, err error) {
+	ctx, span := tracer.Start(ctx, "TaskService.ImportTasks")
+	span.SetAttributes(
+		attribute.String("import.format", format.String()),
+		attribute.Bool("import.dry_run", opts.DryRun),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	rows, rowErrors, err := decodeTasks(format, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxImportRows {
+		return nil, errors.Validation("data", fmt.Sprintf("import is limited to %d rows", MaxImportRows))
+	}
+	span.SetAttributes(attribute.Int("import.rows", len(rows)))
+
+	// Compare against the primary so tasks created just before the import
+	// are seen as duplicates
+	existing, err := s.repo.ListTasks(store.WithPrimaryReads(ctx))
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to list tasks")
+	}
+	seen := make(map[string]bool, len(existing)+len(rows))
+	for _, task := range existing {
+		seen[normalizeDescription(task.Description)] = true
+	}
+
+	result := &ImportResult{DryRun: opts.DryRun}
+	var tasks []store.NewTask
+	for _, row := range rows {
+		description := strings.TrimSpace(row.description)
+		switch {
+		case description == "":
+			rowErrors = append(rowErrors, ImportRowError{Line: row.line, Field: "description", Message: "description cannot be empty"})
+			continue
+		case len(description) > maxDescriptionBytes:
+			rowErrors = append(rowErrors, ImportRowError{Line: row.line, Field: "description", Message: fmt.Sprintf("description exceeds %d bytes", maxDescriptionBytes)})
+			continue
+		}
+
+		key := normalizeDescription(description)
+		if seen[key] {
+			result.Duplicates = append(result.Duplicates, ImportDuplicate{Line: row.line, Description: description})
+			continue
+		}
+		seen[key] = true
+
+		tasks = append(tasks, store.NewTask{
+			Description: description,
+			Completed:   row.completed,
+			CreatedAt:   row.createdAt,
+		})
+	}
+
+	if len(rowErrors) > 0 {
+		sortRowErrors(rowErrors)
+		result.Errors = rowErrors
+		return result, nil
+	}
+
+	if opts.DryRun || len(tasks) == 0 {
+		for _, task := range tasks {
+			result.Tasks = append(result.Tasks, &taskv1.Task{
+				Description: task.Description,
+				Completed:   task.Completed,
+			})
+		}
+		return result, nil
+	}
+
+	importer, ok := s.repo.(store.TaskImporter)
+	if !ok {
+		return nil, errors.Internal("task store does not support imports")
+	}
+	result.Tasks, err = importer.ImportTasks(ctx, tasks)
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to import tasks")
+	}
+	span.SetAttributes(attribute.Int("import.created", len(result.Tasks)))
+
+	return result, nil
+}
+
+// normalizeDescription folds case and whitespace so that near-identical
+// descriptions count as duplicates
+func normalizeDescription(description string) string {
+	return strings.ToLower(strings.Join(strings.Fields(description), " "))
+}
+
+// sortRowErrors orders row errors by line, keeping the order of errors on
+// the same line
+func sortRowErrors(rowErrors []ImportRowError) {
+	slices.SortStableFunc(rowErrors, func(a, b ImportRowError) int {
+		return a.Line - b.Line
+	})
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// importStore adds store.TaskImporter to the mock store
+type importStore struct {
+	*testutil.MockStore
+	batches int
+}
+
+func (s *importStore) ImportTasks(ctx context.Context, tasks []store.NewTask) ([]*taskv1.Task, error) {
+	s.batches++
+	imported := make([]*taskv1.Task, len(tasks))
+	for i, task := range tasks {
+		created, err := s.CreateTask(ctx, task.Description)
+		if err != nil {
+			return nil, err
+		}
+		if task.Completed {
+			if created, err = s.UpdateTask(ctx, created.Id, task.Description, true); err != nil {
+				return nil, err
+			}
+		}
+		if !task.CreatedAt.IsZero() {
+			created.CreatedAt = timestamppb.New(task.CreatedAt)
+		}
+		imported[i] = created
+	}
+	return imported, nil
+}
+
+func newImportService(descriptions ...string) (*TaskService, *importStore) {
+	repo := &importStore{MockStore: testutil.SetupTestStore(descriptions...)}
+	return NewTaskService(repo), repo
+}
+
+func TestTaskService_ImportTasks_Formats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format TaskFormat
+		data   string
+	}{
+		{
+			name:   "ndjson",
+			format: FormatNDJSON,
+			data:   `{"description":"Buy milk"}` + "\n\n" + `{"description":"Walk dog","completed":true,"createdAt":"2024-01-02T03:04:05Z"}` + "\n",
+		},
+		{
+			name:   "csv",
+			format: FormatCSV,
+			data:   "description,completed,created_at\nBuy milk,false,\n\"Walk dog\",true,2024-01-02T03:04:05Z\n",
+		},
+		{
+			name:   "markdown",
+			format: FormatMarkdown,
+			data:   "# Chores\n\n- [ ] Buy milk\n* [x] Walk dog\n\nSome notes\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newImportService()
+
+			result, err := service.ImportTasks(context.Background(), tt.format, strings.NewReader(tt.data), ImportOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, result.Errors)
+			require.Len(t, result.Tasks, 2)
+			assert.Equal(t, "Buy milk", result.Tasks[0].Description)
+			assert.False(t, result.Tasks[0].Completed)
+			assert.Equal(t, "Walk dog", result.Tasks[1].Description)
+			assert.True(t, result.Tasks[1].Completed)
+			assert.NotEmpty(t, result.Tasks[0].Id)
+			assert.Equal(t, 2, repo.TaskCount())
+			assert.Equal(t, 1, repo.batches, "rows are written in one batch")
+		})
+	}
+}
+
+func TestTaskService_ImportTasks_RowErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format TaskFormat
+		data   string
+		lines  []int
+	}{
+		{"ndjson_invalid_json", FormatNDJSON, "{\"description\":\"ok\"}\n{not json}\n", []int{2}},
+		{"ndjson_empty_description", FormatNDJSON, "{\"completed\":true}\n", []int{1}},
+		{"csv_invalid_completed", FormatCSV, "description,completed\nok,maybe\n", []int{2}},
+		{"csv_invalid_created_at", FormatCSV, "description,created_at\nok,yesterday\n", []int{2}},
+		{"csv_empty_description", FormatCSV, "description\n\"\"\nok\n  \n", []int{2, 4}},
+		{"markdown_malformed", FormatMarkdown, "- [ ] ok\n- [?] huh\n- [ ]   \n", []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newImportService()
+
+			result, err := service.ImportTasks(context.Background(), tt.format, strings.NewReader(tt.data), ImportOptions{})
+			require.NoError(t, err)
+			var lines []int
+			for _, rowErr := range result.Errors {
+				lines = append(lines, rowErr.Line)
+				assert.NotEmpty(t, rowErr.Message)
+			}
+			assert.Equal(t, tt.lines, lines)
+			assert.Equal(t, 0, repo.TaskCount(), "nothing is written when a row is invalid")
+		})
+	}
+}
+
+func TestTaskService_ImportTasks_Duplicates(t *testing.T) {
+	service, repo := newImportService("Buy milk")
+
+	data := "- [ ] buy   MILK\n- [ ] Walk dog\n- [x] Walk dog\n"
+	result, err := service.ImportTasks(context.Background(), FormatMarkdown, strings.NewReader(data), ImportOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, result.Tasks, 1)
+	assert.Equal(t, "Walk dog", result.Tasks[0].Description)
+	assert.Equal(t, []ImportDuplicate{
+		{Line: 1, Description: "buy   MILK"},
+		{Line: 3, Description: "Walk dog"},
+	}, result.Duplicates)
+	assert.Equal(t, 2, repo.TaskCount())
+}
+
+func TestTaskService_ImportTasks_DryRun(t *testing.T) {
+	service, repo := newImportService()
+
+	result, err := service.ImportTasks(context.Background(), FormatMarkdown, strings.NewReader("- [ ] Buy milk\n"), ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	require.Len(t, result.Tasks, 1)
+	assert.Equal(t, "Buy milk", result.Tasks[0].Description)
+	assert.Empty(t, result.Tasks[0].Id)
+	assert.Equal(t, 0, repo.TaskCount())
+	assert.Equal(t, 0, repo.batches)
+}
+
+func TestTaskService_ImportTasks_Unsupported(t *testing.T) {
+	service := NewTaskService(testutil.NewMockStore())
+
+	_, err := service.ImportTasks(context.Background(), FormatMarkdown, strings.NewReader("- [ ] Buy milk\n"), ImportOptions{})
+	assert.Error(t, err)
+
+	_, err = service.ImportTasks(context.Background(), TaskFormat(0), strings.NewReader(""), ImportOptions{})
+	assert.Error(t, err)
+
+	_, err = service.ImportTasks(context.Background(), FormatCSV, strings.NewReader("title\nBuy milk\n"), ImportOptions{})
+	assert.Error(t, err, "CSV without a description column is rejected")
+}
+
+func TestTaskService_ExportTasks_RoundTrip(t *testing.T) {
+	for _, format := range []TaskFormat{FormatNDJSON, FormatCSV, FormatMarkdown} {
+		t.Run(format.String(), func(t *testing.T) {
+			source, repo := newImportService()
+			repo.AddTask(testutil.CreateTestTaskWithID("1", "Buy milk"))
+			repo.AddTask(testutil.CreateCompletedTestTask("2", "Walk, \"the\" dog"))
+
+			var exported bytes.Buffer
+			require.NoError(t, source.ExportTasks(context.Background(), format, &exported))
+
+			target, _ := newImportService()
+			result, err := target.ImportTasks(context.Background(), format, &exported, ImportOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, result.Errors)
+			assert.Empty(t, result.Duplicates)
+
+			imported := make(map[string]bool)
+			for _, task := range result.Tasks {
+				imported[task.Description] = task.Completed
+			}
+			assert.Equal(t, map[string]bool{"Buy milk": false, "Walk, \"the\" dog": true}, imported)
+		})
+	}
+}
+
+func TestTaskService_ExportTasks_Markdown(t *testing.T) {
+	service, repo := newImportService()
+	repo.AddTask(testutil.CreateCompletedTestTask("1", "Line one\nline two"))
+
+	var exported bytes.Buffer
+	require.NoError(t, service.ExportTasks(context.Background(), FormatMarkdown, &exported))
+	assert.Equal(t, "- [x] Line one line two\n", exported.String())
+}
+
+func TestParseTaskFormat(t *testing.T) {
+	for _, format := range []TaskFormat{FormatNDJSON, FormatCSV, FormatMarkdown} {
+		parsed, err := ParseTaskFormat(format.String())
+		require.NoError(t, err)
+		assert.Equal(t, format, parsed)
+	}
+
+	parsed, err := ParseTaskFormat("CSV")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, parsed)
+
+	_, err = ParseTaskFormat("yaml")
+	assert.True(t, errors.IsValidation(err))
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// taskListCacheKey caches the full ListTasks result
+	taskListCacheKey = "tasks:list"
+	// taskCacheKeyPrefix prefixes cached GetTask results
+	taskCacheKeyPrefix = "task:"
+)
+
+// CacheStats reports cache effectiveness
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// CachingTaskStore is a TaskRepository decorator that caches GetTask and
+// ListTasks results and invalidates them on every mutation it sees. Misses
+// are read from the primary, so a lagging replica never fills the cache
+// with rows older than a write that has already returned. Writes made
+// directly against the underlying store are only picked up once the cached
+// entries expire.
+type CachingTaskStore struct {
+	next  TaskRepository
+	cache CacheBackend
+	ttl   time.Duration
+
+	// generation is bumped before and after every mutation so that a read
+	// which raced with a write skips repopulating the cache with data it
+	// loaded before the write committed
+	generation atomic.Uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCachingTaskStore wraps next with a read-through cache
+func NewCachingTaskStore(next TaskRepository, cache CacheBackend, ttl time.Duration) *CachingTaskStore {
+	return &CachingTaskStore{
+		next:  next,
+		cache: cache,
+		ttl:   ttl,
+	}
+}
+
+// Stats returns the hit and miss counters
+func (s *CachingTaskStore) Stats() CacheStats {
+	return CacheStats{
+		Hits:   s.hits.Load(),
+		Misses: s.misses.Load(),
+	}
+}
+
+// Repository returns the store as a TaskRepository that also implements
+// TaskImporter when, and only when, the underlying store does
+func (s *CachingTaskStore) Repository() TaskRepository {
+	if importer, ok := s.next.(TaskImporter); ok {
+		return &cachingImporter{CachingTaskStore: s, importer: importer}
+	}
+	return s
+}
+
+// Unwrap returns the underlying repository
+func (s *CachingTaskStore) Unwrap() TaskRepository {
+	return s.next
+}
+
+// CreateTask creates a task and invalidates the cached task list
+func (s *CachingTaskStore) CreateTask(ctx context.Context, description string) (*taskv1.Task, error) {
+	s.generation.Add(1)
+	task, err := s.next.CreateTask(ctx, description)
+	s.invalidate(ctx, taskListCacheKey)
+	return task, err
+}
+
+// GetTask returns a cached task or loads it from the underlying store
+func (s *CachingTaskStore) GetTask(ctx context.Context, id string) (*taskv1.Task, error) {
+	if PrimaryReadsRequested(ctx) {
+		return s.next.GetTask(ctx, id)
+	}
+
+	key := taskCacheKeyPrefix + id
+	if data, ok := s.cache.Get(ctx, key); ok {
+		var task taskv1.Task
+		if err := proto.Unmarshal(data, &task); err == nil {
+			s.hits.Add(1)
+			return &task, nil
+		}
+		s.cache.Delete(ctx, key)
+	}
+	s.misses.Add(1)
+
+	generation := s.generation.Load()
+	task, err := s.next.GetTask(WithPrimaryReads(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := proto.Marshal(task); err == nil {
+		s.store(ctx, generation, key, data)
+	}
+	return task, nil
+}
+
+// ListTasks returns the cached task list or loads it from the underlying store
+func (s *CachingTaskStore) ListTasks(ctx context.Context) ([]*taskv1.Task, error) {
+	if PrimaryReadsRequested(ctx) {
+		return s.next.ListTasks(ctx)
+	}
+
+	if data, ok := s.cache.Get(ctx, taskListCacheKey); ok {
+		var list taskv1.GetAllTasksResponse
+		if err := proto.Unmarshal(data, &list); err == nil {
+			s.hits.Add(1)
+			return list.Tasks, nil
+		}
+		s.cache.Delete(ctx, taskListCacheKey)
+	}
+	s.misses.Add(1)
+
+	generation := s.generation.Load()
+	tasks, err := s.next.ListTasks(WithPrimaryReads(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := proto.Marshal(&taskv1.GetAllTasksResponse{Tasks: tasks}); err == nil {
+		s.store(ctx, generation, taskListCacheKey, data)
+	}
+	return tasks, nil
+}
+
+// ListTasksAfter reads a page from the underlying store. Pages are not
+// cached: each one is a bounded query, and caching them would multiply the
+// keys every mutation has to invalidate.
+func (s *CachingTaskStore) ListTasksAfter(ctx context.Context, cursor *TaskCursor, limit int) ([]*taskv1.Task, error) {
+	return ListTasksAfter(ctx, s.next, cursor, limit)
+}
+
+// UpdateTask updates a task and invalidates its cached entries
+func (s *CachingTaskStore) UpdateTask(ctx context.Context, id, description string, completed bool) (*taskv1.Task, error) {
+	s.generation.Add(1)
+	task, err := s.next.UpdateTask(ctx, id, description, completed)
+	s.invalidate(ctx, taskCacheKeyPrefix+id, taskListCacheKey)
+	return task, err
+}
+
+// PatchTask patches a task through the underlying store and invalidates its
+// cached entries
+func (s *CachingTaskStore) PatchTask(ctx context.Context, id string, patch TaskPatch) (*taskv1.Task, error) {
+	s.generation.Add(1)
+	task, err := PatchTask(ctx, s.next, id, patch)
+	s.invalidate(ctx, taskCacheKeyPrefix+id, taskListCacheKey)
+	return task, err
+}
+
+// DeleteTask deletes a task and invalidates its cached entries
+func (s *CachingTaskStore) DeleteTask(ctx context.Context, id string) error {
+	s.generation.Add(1)
+	err := s.next.DeleteTask(ctx, id)
+	s.invalidate(ctx, taskCacheKeyPrefix+id, taskListCacheKey)
+	return err
+}
+
+// cachingImporter is a CachingTaskStore over a store that supports imports
+type cachingImporter struct {
+	*CachingTaskStore
+	importer TaskImporter
+}
+
+// ImportTasks imports through the underlying store and invalidates the cached
+// task list
+func (s *cachingImporter) ImportTasks(ctx context.Context, tasks []NewTask) ([]*taskv1.Task, error) {
+	s.generation.Add(1)
+	imported, err := s.importer.ImportTasks(ctx, tasks)
+	s.invalidate(ctx, taskListCacheKey)
+	return imported, err
+}
+
+// invalidate drops keys once a mutation has returned. Bumping generation
+// first stops reads that loaded rows before the write committed from
+// caching them after the delete; reads that cache them earlier are removed
+// by the delete.
+func (s *CachingTaskStore) invalidate(ctx context.Context, keys ...string) {
+	s.generation.Add(1)
+	s.cache.Delete(ctx, keys...)
+}
+
+// store caches data unless a mutation started after the read began. The
+// check happens inside the backend's write, so an invalidation cannot slip
+// in between the two.
+func (s *CachingTaskStore) store(ctx context.Context, generation uint64, key string, data []byte) {
+	s.cache.SetIf(ctx, key, data, s.ttl, func() bool {
+		return s.generation.Load() == generation
+	})
+}
+
+// Verify that CachingTaskStore implements the TaskRepository, TaskPatcher
+// and TaskPager interfaces, and cachingImporter TaskImporter as well
+var (
+	_ TaskRepository = (*CachingTaskStore)(nil)
+	_ TaskPatcher    = (*CachingTaskStore)(nil)
+	_ TaskPager      = (*CachingTaskStore)(nil)
+	_ TaskImporter   = (*cachingImporter)(nil)
+)
@@ -0,0 +1,288 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+func newTestCachingStore() (*CachingTaskStore, *testutil.MockStore) {
+	backing := testutil.NewMockStore()
+	return NewCachingTaskStore(backing, NewLRUCache(100), time.Minute), backing
+}
+
+func TestCachingTaskStore_GetTaskHitsCache(t *testing.T) {
+	ctx := context.Background()
+	cached, backing := newTestCachingStore()
+
+	created, err := cached.CreateTask(ctx, "Cached task")
+	require.NoError(t, err)
+
+	first, err := cached.GetTask(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 1}, cached.Stats())
+
+	// Change the backing store behind the cache's back
+	_, err = backing.UpdateTask(ctx, created.Id, "Changed directly", true)
+	require.NoError(t, err)
+
+	second, err := cached.GetTask(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, cached.Stats())
+	assert.Equal(t, first.Description, second.Description, "second read should be served from cache")
+}
+
+func TestCachingTaskStore_ListTasksHitsCache(t *testing.T) {
+	ctx := context.Background()
+	cached, _ := newTestCachingStore()
+
+	_, err := cached.CreateTask(ctx, "Task 1")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		tasks, err := cached.ListTasks(ctx)
+		require.NoError(t, err)
+		assert.Len(t, tasks, 1)
+	}
+
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1}, cached.Stats())
+}
+
+func TestCachingTaskStore_InvalidatesOnMutation(t *testing.T) {
+	ctx := context.Background()
+	cached, _ := newTestCachingStore()
+
+	created, err := cached.CreateTask(ctx, "Original")
+	require.NoError(t, err)
+
+	// Warm both caches
+	_, err = cached.GetTask(ctx, created.Id)
+	require.NoError(t, err)
+	_, err = cached.ListTasks(ctx)
+	require.NoError(t, err)
+
+	t.Run("Create", func(t *testing.T) {
+		_, err := cached.CreateTask(ctx, "Another")
+		require.NoError(t, err)
+
+		tasks, err := cached.ListTasks(ctx)
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		_, err := cached.UpdateTask(ctx, created.Id, "Updated", true)
+		require.NoError(t, err)
+
+		task, err := cached.GetTask(ctx, created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated", task.Description)
+		assert.True(t, task.Completed)
+
+		tasks, err := cached.ListTasks(ctx)
+		require.NoError(t, err)
+		testutil.AssertTaskListContains(t, tasks, created.Id)
+		for _, listed := range tasks {
+			if listed.Id == created.Id {
+				assert.Equal(t, "Updated", listed.Description)
+			}
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, cached.DeleteTask(ctx, created.Id))
+
+		_, err := cached.GetTask(ctx, created.Id)
+		assert.True(t, errors.IsNotFound(err))
+
+		tasks, err := cached.ListTasks(ctx)
+		require.NoError(t, err)
+		testutil.AssertTaskListDoesNotContain(t, tasks, created.Id)
+	})
+}
+
+// racingStore lets a test pause an update before it commits and a read
+// after it has loaded its row
+type racingStore struct {
+	*testutil.MockStore
+	writing chan struct{}
+	commit  chan struct{}
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+func (s *racingStore) UpdateTask(ctx context.Context, id, description string, completed bool) (*taskv1.Task, error) {
+	close(s.writing)
+	<-s.commit
+	return s.MockStore.UpdateTask(ctx, id, description, completed)
+}
+
+func (s *racingStore) GetTask(ctx context.Context, id string) (*taskv1.Task, error) {
+	task, err := s.MockStore.GetTask(ctx, id)
+	select {
+	case <-s.loaded:
+	default:
+		close(s.loaded)
+	}
+	<-s.release
+	return task, err
+}
+
+func TestCachingTaskStore_ReadRacingWriteIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	backing := &racingStore{
+		MockStore: testutil.NewMockStore(),
+		writing:   make(chan struct{}),
+		commit:    make(chan struct{}),
+		loaded:    make(chan struct{}),
+		release:   make(chan struct{}),
+	}
+	created, err := backing.CreateTask(ctx, "Original")
+	require.NoError(t, err)
+	cached := NewCachingTaskStore(backing, NewLRUCache(100), time.Minute)
+
+	// The update starts, then a read loads the row before the update commits
+	updated := make(chan error)
+	go func() {
+		_, err := cached.UpdateTask(ctx, created.Id, "Updated", true)
+		updated <- err
+	}()
+	<-backing.writing
+	read := make(chan error)
+	go func() {
+		_, err := cached.GetTask(ctx, created.Id)
+		read <- err
+	}()
+	<-backing.loaded
+
+	// The update commits and invalidates before the stale read finishes
+	close(backing.commit)
+	require.NoError(t, <-updated)
+	close(backing.release)
+	require.NoError(t, <-read)
+
+	task, err := cached.GetTask(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", task.Description)
+}
+
+func TestCachingTaskStore_MissReadsPrimary(t *testing.T) {
+	ctx := context.Background()
+	backing := &replicaLagStore{MockStore: testutil.NewMockStore()}
+	created, err := backing.CreateTask(ctx, "Original")
+	require.NoError(t, err)
+	backing.stale = created
+	cached := NewCachingTaskStore(backing, NewLRUCache(100), time.Minute)
+
+	// The update has returned, but the replica has not applied it yet
+	_, err = cached.UpdateTask(ctx, created.Id, "Updated", true)
+	require.NoError(t, err)
+
+	for range 2 {
+		task, err := cached.GetTask(ctx, created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated", task.Description, "the stale replica row must not be served or cached")
+	}
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, cached.Stats())
+}
+
+// importStore adds TaskImporter to the mock store
+type importStore struct {
+	*testutil.MockStore
+}
+
+func (s *importStore) ImportTasks(ctx context.Context, tasks []NewTask) ([]*taskv1.Task, error) {
+	imported := make([]*taskv1.Task, len(tasks))
+	for i, task := range tasks {
+		created, err := s.CreateTask(ctx, task.Description)
+		if err != nil {
+			return nil, err
+		}
+		imported[i] = created
+	}
+	return imported, nil
+}
+
+func TestCachingTaskStore_Repository(t *testing.T) {
+	ctx := context.Background()
+
+	// Import support is only claimed when the underlying store has it
+	plain, _ := newTestCachingStore()
+	_, ok := plain.Repository().(TaskImporter)
+	assert.False(t, ok)
+
+	cached := NewCachingTaskStore(&importStore{MockStore: testutil.NewMockStore()}, NewLRUCache(100), time.Minute)
+	repo := cached.Repository()
+	importer, ok := repo.(TaskImporter)
+	require.True(t, ok)
+
+	tasks, err := repo.ListTasks(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	_, err = importer.ImportTasks(ctx, []NewTask{{Description: "Imported"}})
+	require.NoError(t, err)
+
+	tasks, err = repo.ListTasks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 1, "importing invalidates the cached list")
+}
+
+func TestCachingTaskStore_DoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	cached, _ := newTestCachingStore()
+
+	_, err := cached.GetTask(ctx, "1")
+	require.True(t, errors.IsNotFound(err))
+
+	created, err := cached.CreateTask(ctx, "Now it exists")
+	require.NoError(t, err)
+	require.Equal(t, "1", created.Id)
+
+	task, err := cached.GetTask(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Now it exists", task.Description)
+}
+
+func TestCachingTaskStore_PrimaryReadsBypassCache(t *testing.T) {
+	ctx := context.Background()
+	cached, backing := newTestCachingStore()
+
+	created, err := cached.CreateTask(ctx, "Original")
+	require.NoError(t, err)
+	_, err = cached.GetTask(ctx, created.Id)
+	require.NoError(t, err)
+
+	_, err = backing.UpdateTask(ctx, created.Id, "Changed directly", false)
+	require.NoError(t, err)
+
+	task, err := cached.GetTask(WithPrimaryReads(ctx), created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Changed directly", task.Description)
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 1}, cached.Stats())
+}
+
+func TestCachingTaskStore_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	cached, _ := newTestCachingStore()
+
+	created, err := cached.CreateTask(ctx, "Original")
+	require.NoError(t, err)
+	_, err = cached.GetTask(ctx, created.Id)
+	require.NoError(t, err)
+
+	hit, err := cached.GetTask(ctx, created.Id)
+	require.NoError(t, err)
+	hit.Description = "Mutated by caller"
+
+	again, err := cached.GetTask(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Original", again.Description)
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+// credentialConnector opens MySQL connections with the current password. A
+// sql.DB keeps one connector for its lifetime, so rotating the password
+// rebuilds the driver connector inside it: new connections use the new
+// password while open ones keep working until ConnMaxLifetime retires them.
+type credentialConnector struct {
+	mu        sync.RWMutex
+	cfg       *mysql.Config
+	connector driver.Connector
+}
+
+// newCredentialConnector returns a connector for the database in cfg. With
+// config.SSLModeCustom it registers the CA bundle and client certificate as
+// the driver's "custom" TLS configuration; the driver verifies each server
+// against its own host name, so primary and replicas can share it.
+func newCredentialConnector(cfg *config.DatabaseConfig) (*credentialConnector, error) {
+	tlsCfg, err := cfg.TLSClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		if err := mysql.RegisterTLSConfig(config.SSLModeCustom, tlsCfg); err != nil {
+			return nil, fmt.Errorf("failed to register database TLS configuration: %w", err)
+		}
+	}
+
+	mysqlCfg, err := mysql.ParseDSN(cfg.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+	connector, err := mysql.NewConnector(mysqlCfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+	return &credentialConnector{cfg: mysqlCfg, connector: connector}, nil
+}
+
+// Connect implements driver.Connector
+func (c *credentialConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.RLock()
+	connector := c.connector
+	c.mu.RUnlock()
+	return connector.Connect(ctx)
+}
+
+// Driver implements driver.Connector
+func (c *credentialConnector) Driver() driver.Driver {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connector.Driver()
+}
+
+// setPassword makes new connections authenticate with password
+func (c *credentialConnector) setPassword(password config.Secret) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cfg.Passwd == password.Reveal() {
+		return nil
+	}
+	mysqlCfg := c.cfg.Clone()
+	mysqlCfg.Passwd = password.Reveal()
+	connector, err := mysql.NewConnector(mysqlCfg)
+	if err != nil {
+		return err
+	}
+	c.cfg, c.connector = mysqlCfg, connector
+	return nil
+}
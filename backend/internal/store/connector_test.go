@@ -0,0 +1,55 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/certs/certstest"
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+func TestCredentialConnector_SetPassword(t *testing.T) {
+	connector, err := newCredentialConnector(&config.DatabaseConfig{
+		Host: "localhost", Port: 3306, User: "todoapp", Password: "first", Database: "todoapp",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "first", connector.cfg.Passwd)
+	first := connector.connector
+
+	require.NoError(t, connector.setPassword("first"))
+	assert.Same(t, first, connector.connector, "an unchanged password keeps the connector")
+
+	require.NoError(t, connector.setPassword("second"))
+	assert.Equal(t, "second", connector.cfg.Passwd)
+	assert.NotSame(t, first, connector.connector)
+	assert.Equal(t, "localhost:3306", connector.cfg.Addr)
+	assert.Equal(t, "todoapp", connector.cfg.DBName)
+}
+
+func TestCredentialConnector_CustomTLS(t *testing.T) {
+	ca := certstest.NewCA(t, "database")
+	connector, err := newCredentialConnector(&config.DatabaseConfig{
+		Host: "db.example.com", Port: 3306, User: "todoapp", Password: "secret", Database: "todoapp",
+		SSLMode: config.SSLModeCustom, SSLCA: ca.CertFile,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, connector.cfg.TLS)
+	assert.True(t, connector.cfg.TLS.RootCAs.Equal(ca.Pool()))
+	assert.Equal(t, "db.example.com", connector.cfg.TLS.ServerName, "the server is verified against its host name")
+
+	// Rotating the password keeps the TLS configuration
+	require.NoError(t, connector.setPassword("rotated"))
+	require.NotNil(t, connector.cfg.TLS)
+	assert.True(t, connector.cfg.TLS.RootCAs.Equal(ca.Pool()))
+}
+
+func TestCredentialConnector_CustomTLSMissingCA(t *testing.T) {
+	_, err := newCredentialConnector(&config.DatabaseConfig{
+		Host: "db", Port: 3306, User: "todoapp", Database: "todoapp",
+		SSLMode: config.SSLModeCustom, SSLCA: filepath.Join(t.TempDir(), "missing.crt"),
+	})
+	assert.ErrorContains(t, err, "failed to read database CA bundle")
+}
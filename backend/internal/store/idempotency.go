@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord is the stored outcome of a request made with an
+// idempotency key
+type IdempotencyRecord struct {
+	Key string
+	// RequestHash fingerprints the request so that a key reused with a
+	// different payload can be rejected
+	RequestHash string
+	// Response holds the serialized response once Completed is set
+	Response  []byte
+	Completed bool
+	// LockedUntil ends the lease of an in-progress request. A reservation
+	// whose lease ran out belongs to a request that died before completing,
+	// and a retry may take it over.
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+}
+
+// IdempotencyStore persists idempotency keys for mutating RPCs
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey claims key for a new request, leased for lease
+	// and kept for ttl. It returns nil if the key was unused, expired or
+	// held by a request whose lease ran out and is now reserved, and the
+	// existing record otherwise.
+	ReserveIdempotencyKey(ctx context.Context, key, requestHash string, ttl, lease time.Duration) (*IdempotencyRecord, error)
+
+	// CompleteIdempotencyKey stores the response for a reserved key
+	CompleteIdempotencyKey(ctx context.Context, key string, response []byte) error
+
+	// ReleaseIdempotencyKey drops a reservation so the request can be retried
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+
+	// PurgeExpiredIdempotencyKeys deletes expired keys and returns how many
+	// were removed
+	PurgeExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore for tests and
+// single-instance deployments
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*IdempotencyRecord
+
+	// now is overridable in tests
+	now func() time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records: make(map[string]*IdempotencyRecord),
+		now:     time.Now,
+	}
+}
+
+// ReserveIdempotencyKey implements IdempotencyStore
+func (s *MemoryIdempotencyStore) ReserveIdempotencyKey(_ context.Context, key, requestHash string, ttl, lease time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if existing, ok := s.records[key]; ok && now.Before(existing.ExpiresAt) &&
+		(existing.Completed || now.Before(existing.LockedUntil)) {
+		record := *existing
+		return &record, nil
+	}
+
+	s.records[key] = &IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		LockedUntil: now.Add(lease),
+		ExpiresAt:   now.Add(ttl),
+	}
+	return nil, nil
+}
+
+// CompleteIdempotencyKey implements IdempotencyStore
+func (s *MemoryIdempotencyStore) CompleteIdempotencyKey(_ context.Context, key string, response []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		record.Response = append([]byte(nil), response...)
+		record.Completed = true
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey implements IdempotencyStore
+func (s *MemoryIdempotencyStore) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok && !record.Completed {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys implements IdempotencyStore
+func (s *MemoryIdempotencyStore) PurgeExpiredIdempotencyKeys(context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var purged int64
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Verify that MemoryIdempotencyStore implements the IdempotencyStore interface
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIdempotencyStore_Reserve(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	existing, err := s.ReserveIdempotencyKey(ctx, "key-1", "hash-a", time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = s.ReserveIdempotencyKey(ctx, "key-1", "hash-b", time.Hour, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "hash-a", existing.RequestHash)
+	assert.False(t, existing.Completed)
+
+	require.NoError(t, s.CompleteIdempotencyKey(ctx, "key-1", []byte("response")))
+
+	existing, err = s.ReserveIdempotencyKey(ctx, "key-1", "hash-a", time.Hour, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.True(t, existing.Completed)
+	assert.Equal(t, []byte("response"), existing.Response)
+}
+
+func TestMemoryIdempotencyStore_Release(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	_, err := s.ReserveIdempotencyKey(ctx, "key-1", "hash", time.Hour, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, s.ReleaseIdempotencyKey(ctx, "key-1"))
+
+	existing, err := s.ReserveIdempotencyKey(ctx, "key-1", "hash", time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "released key should be reservable again")
+
+	// Completed keys are never released
+	require.NoError(t, s.CompleteIdempotencyKey(ctx, "key-1", nil))
+	require.NoError(t, s.ReleaseIdempotencyKey(ctx, "key-1"))
+	existing, err = s.ReserveIdempotencyKey(ctx, "key-1", "hash", time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.NotNil(t, existing)
+}
+
+func TestMemoryIdempotencyStore_Expiry(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := s.ReserveIdempotencyKey(ctx, "old", "hash", time.Minute, time.Hour)
+	require.NoError(t, err)
+	_, err = s.ReserveIdempotencyKey(ctx, "new", "hash", time.Hour, time.Hour)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+
+	existing, err := s.ReserveIdempotencyKey(ctx, "old", "other", time.Minute, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "expired key should be reservable again")
+
+	now = now.Add(2 * time.Minute)
+	purged, err := s.PurgeExpiredIdempotencyKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	existing, err = s.ReserveIdempotencyKey(ctx, "new", "hash", time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.NotNil(t, existing)
+}
+
+func TestMemoryIdempotencyStore_Lease(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := s.ReserveIdempotencyKey(ctx, "stale", "hash-a", time.Hour, time.Minute)
+	require.NoError(t, err)
+	_, err = s.ReserveIdempotencyKey(ctx, "done", "hash-a", time.Hour, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, s.CompleteIdempotencyKey(ctx, "done", []byte("response")))
+
+	existing, err := s.ReserveIdempotencyKey(ctx, "stale", "hash-b", time.Hour, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing, "a leased reservation is still in progress")
+	assert.False(t, existing.Completed)
+
+	now = now.Add(2 * time.Minute)
+
+	existing, err = s.ReserveIdempotencyKey(ctx, "stale", "hash-b", time.Hour, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "a reservation whose lease ran out should be taken over")
+
+	existing, err = s.ReserveIdempotencyKey(ctx, "done", "hash-a", time.Hour, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, existing, "completed keys are kept for the TTL")
+	assert.Equal(t, []byte("response"), existing.Response)
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+)
+
+// NewTask is a task to be written by ImportTasks
+type NewTask struct {
+	Description string
+	Completed   bool
+	// CreatedAt keeps the creation time from another tool; zero means now
+	CreatedAt time.Time
+}
+
+// TaskImporter writes a batch of tasks atomically. Repositories that support
+// bulk imports implement it alongside TaskRepository.
+type TaskImporter interface {
+	// ImportTasks creates every task or none of them and returns the
+	// created tasks in input order
+	ImportTasks(ctx context.Context, tasks []NewTask) ([]*taskv1.Task, error)
+}
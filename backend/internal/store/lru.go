@@ -0,0 +1,143 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheBackend stores serialized cache entries for CachingTaskStore.
+// Implementations must be safe for concurrent use. The byte-oriented API
+// mirrors Redis GET/SET EX/DEL so a networked cache can be swapped in.
+type CacheBackend interface {
+	// Get returns the value stored under key, if present and not expired
+	Get(ctx context.Context, key string) ([]byte, bool)
+
+	// Set stores value under key for at most ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+
+	// SetIf stores value under key for at most ttl if current returns true.
+	// current is checked atomically with the write, so a Delete that
+	// follows a change to what current checks is never undone by the write.
+	SetIf(ctx context.Context, key string, value []byte, ttl time.Duration, current func() bool)
+
+	// Delete removes the given keys
+	Delete(ctx context.Context, keys ...string)
+}
+
+// lruEntry is a single item held by LRUCache
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRUCache is a bounded in-process CacheBackend that evicts the least
+// recently used entry once capacity is reached
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+// NewLRUCache creates an LRUCache holding at most capacity entries
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &LRUCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value stored under key, if present and not expired
+func (c *LRUCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set stores value under key for at most ttl
+func (c *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+}
+
+// SetIf stores value under key for at most ttl if current returns true,
+// checking it under the cache's lock
+func (c *LRUCache) SetIf(ctx context.Context, key string, value []byte, ttl time.Duration, current func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current() {
+		c.set(key, value, ttl)
+	}
+}
+
+// set stores value under key; the caller holds mu
+func (c *LRUCache) set(key string, value []byte, ttl time.Duration) {
+	expiresAt := c.now().Add(ttl)
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	c.items[key] = elem
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Delete removes the given keys
+func (c *LRUCache) Delete(ctx context.Context, keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// Len returns the number of entries currently held, including expired
+// entries that have not been evicted yet
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// removeElement drops elem from both the list and the index
+func (c *LRUCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).key)
+}
+
+// Verify that LRUCache implements the CacheBackend interface
+var _ CacheBackend = (*LRUCache)(nil)
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache_GetSet(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(2)
+
+	_, ok := cache.Get(ctx, "missing")
+	assert.False(t, ok)
+
+	cache.Set(ctx, "a", []byte("1"), time.Minute)
+	value, ok := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	cache.Set(ctx, "a", []byte("2"), time.Minute)
+	value, ok = cache.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), value)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(2)
+
+	cache.Set(ctx, "a", []byte("1"), time.Minute)
+	cache.Set(ctx, "b", []byte("2"), time.Minute)
+
+	// Touch "a" so that "b" becomes the eviction candidate
+	_, ok := cache.Get(ctx, "a")
+	assert.True(t, ok)
+
+	cache.Set(ctx, "c", []byte("3"), time.Minute)
+
+	_, ok = cache.Get(ctx, "b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	_, ok = cache.Get(ctx, "a")
+	assert.True(t, ok)
+	_, ok = cache.Get(ctx, "c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestLRUCache_Expiry(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(2)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.Set(ctx, "a", []byte("1"), time.Second)
+	_, ok := cache.Get(ctx, "a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = cache.Get(ctx, "a")
+	assert.False(t, ok, "entry should expire after its TTL")
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestLRUCache_Delete(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(3)
+
+	cache.Set(ctx, "a", []byte("1"), time.Minute)
+	cache.Set(ctx, "b", []byte("2"), time.Minute)
+
+	cache.Delete(ctx, "a", "b", "missing")
+
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestLRUCache_SetIf(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(2)
+
+	cache.SetIf(ctx, "a", []byte("1"), time.Minute, func() bool { return false })
+	_, ok := cache.Get(ctx, "a")
+	assert.False(t, ok)
+
+	cache.SetIf(ctx, "a", []byte("1"), time.Minute, func() bool {
+		// The condition is checked under the lock that Delete takes
+		assert.False(t, cache.mu.TryLock())
+		return true
+	})
+	value, ok := cache.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/wcygan/todo/backend/internal/config"
@@ -11,7 +12,13 @@ import (
 
 // Manager handles database connections and provides store instances
 type Manager struct {
-	taskStore TaskRepository
+	mysqlStore *MySQLTaskStore
+	cacheStore *CachingTaskStore
+	taskStore  TaskRepository
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
 // NewManager creates a new store manager with MySQL backend
@@ -40,10 +47,27 @@ func NewManager(cfg *config.Config) (*Manager, error) {
 		envMode = "development"
 	}
 	fmt.Printf("Successfully connected to MySQL database in %s mode\n", envMode)
+	if cfg.Database.MigrateOnStartup != config.MigrateOff {
+		fmt.Printf("Database schema is at version %d, migrations checksum %s from %s (MIGRATE_ON_STARTUP=%s)\n",
+			taskStore.schemaVersion, taskStore.schemaChecksum, taskStore.migrationsSource, cfg.Database.MigrateOnStartup)
+	}
+
+	manager := &Manager{
+		mysqlStore: taskStore,
+		taskStore:  taskStore,
+		stop:       make(chan struct{}),
+	}
+
+	// Wrap the store with a read cache if enabled
+	if cfg.Cache.Enabled {
+		manager.cacheStore = NewCachingTaskStore(taskStore, NewLRUCache(cfg.Cache.Capacity), cfg.Cache.TTL)
+		manager.taskStore = manager.cacheStore.Repository()
+		fmt.Printf("Task read cache enabled (capacity: %d, ttl: %v)\n", cfg.Cache.Capacity, cfg.Cache.TTL)
+	}
+
+	manager.startIdempotencyPurge(cfg.Idempotency.PurgeInterval)
 
-	return &Manager{
-		taskStore: taskStore,
-	}, nil
+	return manager, nil
 }
 
 // TaskStore returns the task repository instance
@@ -51,28 +75,109 @@ func (m *Manager) TaskStore() TaskRepository {
 	return m.taskStore
 }
 
-// Close closes all database connections
+// CacheStats returns the read cache counters, or false if caching is disabled
+func (m *Manager) CacheStats() (CacheStats, bool) {
+	if m.cacheStore == nil {
+		return CacheStats{}, false
+	}
+	return m.cacheStore.Stats(), true
+}
+
+// IdempotencyStore returns the store backing Idempotency-Key handling
+func (m *Manager) IdempotencyStore() IdempotencyStore {
+	return m.mysqlStore
+}
+
+// startIdempotencyPurge deletes expired idempotency keys on the given interval
+func (m *Manager) startIdempotencyPurge(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				purged, err := m.mysqlStore.PurgeExpiredIdempotencyKeys(ctx)
+				cancel()
+				if err != nil {
+					fmt.Printf("Failed to purge expired idempotency keys: %v\n", err)
+				} else if purged > 0 {
+					fmt.Printf("Purged %d expired idempotency keys\n", purged)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops background work and closes all database connections
 func (m *Manager) Close() error {
-	if mysqlStore, ok := m.taskStore.(*MySQLTaskStore); ok {
-		return mysqlStore.Close()
+	if m.stop != nil {
+		m.stopOnce.Do(func() { close(m.stop) })
+		m.wg.Wait()
+	}
+	if m.mysqlStore != nil {
+		return m.mysqlStore.Close()
 	}
 	return nil
 }
 
-// HealthCheck performs a basic health check on the database connection
+// HealthCheck pings the primary database
 func (m *Manager) HealthCheck(ctx context.Context) error {
-	// Try to list tasks to verify connection is working
-	_, err := m.taskStore.ListTasks(ctx)
-	if err != nil {
+	if m.mysqlStore == nil {
+		return fmt.Errorf("database connection not available")
+	}
+	if err := m.mysqlStore.HealthCheck(ctx); err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
 	return nil
 }
 
+// CheckMigrations verifies the schema is at the expected migration version
+func (m *Manager) CheckMigrations(ctx context.Context) error {
+	if m.mysqlStore == nil {
+		return fmt.Errorf("database connection not available")
+	}
+	return m.mysqlStore.CheckMigrations(ctx)
+}
+
+// Pools returns the database connection pools keyed by name
+func (m *Manager) Pools() map[string]*sql.DB {
+	if m.mysqlStore != nil {
+		return m.mysqlStore.Pools()
+	}
+	return nil
+}
+
+// CountTasks returns the number of total and completed tasks
+func (m *Manager) CountTasks(ctx context.Context) (total, completed int64, err error) {
+	if m.mysqlStore == nil {
+		return 0, 0, fmt.Errorf("database connection not available")
+	}
+	return m.mysqlStore.CountTasks(ctx)
+}
+
+// SetPassword rotates the database password used for new connections
+func (m *Manager) SetPassword(password config.Secret) error {
+	if m.mysqlStore == nil {
+		return fmt.Errorf("database connection not available")
+	}
+	return m.mysqlStore.SetPassword(password)
+}
+
 // GetDB returns the underlying database connection for advanced operations
 func (m *Manager) GetDB() (*sql.DB, error) {
-	if mysqlStore, ok := m.taskStore.(*MySQLTaskStore); ok {
-		return mysqlStore.GetDB(), nil
+	if m.mysqlStore != nil {
+		return m.mysqlStore.GetDB(), nil
 	}
 	return nil, fmt.Errorf("database connection not available")
 }
@@ -96,23 +201,19 @@ func WaitForDatabase(cfg *config.DatabaseConfig, timeout time.Duration) error {
 		case <-ticker.C:
 			attempt++
 			
-			// Try to create a test connection
-			store, err := NewMySQLTaskStore(cfg)
+			// Try to open a test connection; migrations run later, once
+			db, _, err := openDB(cfg)
 			if err == nil {
-				// Test the connection with a simple health check
 				healthCtx, healthCancel := context.WithTimeout(context.Background(), 5*time.Second)
-				healthErr := store.HealthCheck(healthCtx)
+				err = db.PingContext(healthCtx)
 				healthCancel()
-				store.Close()
-				
-				if healthErr == nil {
-					fmt.Printf("Database connection successful after %d attempts\n", attempt)
-					return nil
-				}
-				fmt.Printf("Attempt %d: Database connected but health check failed: %v\n", attempt, healthErr)
-			} else {
-				fmt.Printf("Attempt %d: Waiting for database connection: %v\n", attempt, err)
+				db.Close()
+			}
+			if err == nil {
+				fmt.Printf("Database connection successful after %d attempts\n", attempt)
+				return nil
 			}
+			fmt.Printf("Attempt %d: Waiting for database connection: %v\n", attempt, err)
 		}
 	}
 }
\ No newline at end of file
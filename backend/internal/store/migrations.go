@@ -0,0 +1,147 @@
+package store
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// embeddedMigrations holds the schema migrations built into the binary
+//
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// embeddedOrigin is the Origin of migrations built into the binary
+const embeddedOrigin = "embedded"
+
+// migrationSet is the migrations a binary runs: the embedded ones, or those
+// in an override directory when one is configured
+type migrationSet struct {
+	fsys fs.FS
+	// origin is "embedded" or the override directory
+	origin   string
+	versions []uint
+}
+
+// loadMigrations reads the embedded migrations, or those in dir when it is
+// not empty
+func loadMigrations(dir string) (*migrationSet, error) {
+	set := &migrationSet{origin: embeddedOrigin}
+	if dir == "" {
+		sub, err := fs.Sub(embeddedMigrations, "migrations")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+		}
+		set.fsys = sub
+	} else {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("migrations directory %s is not a directory", dir)
+		}
+		set.fsys = os.DirFS(dir)
+		set.origin = dir
+	}
+
+	src, err := set.source()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	for err == nil {
+		set.versions = append(set.versions, version)
+		version, err = src.Next(version)
+	}
+	if !stderrors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read migrations from %s: %w", set.origin, err)
+	}
+	return set, nil
+}
+
+// source returns a golang-migrate source over the migrations
+func (s *migrationSet) source() (source.Driver, error) {
+	src, err := iofs.New(s.fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations from %s: %w", s.origin, err)
+	}
+	return src, nil
+}
+
+// latest returns the highest migration version, or zero when there are none
+func (s *migrationSet) latest() uint {
+	if len(s.versions) == 0 {
+		return 0
+	}
+	return s.versions[len(s.versions)-1]
+}
+
+// status describes a schema at version against this migration set. The
+// checksum is left empty when the schema is ahead of the set, since these
+// files cannot describe it.
+func (s *migrationSet) status(version uint, dirty bool) (*MigrationStatus, error) {
+	status := &MigrationStatus{
+		Version: version,
+		Dirty:   dirty,
+		Latest:  s.latest(),
+		Source:  s.origin,
+	}
+	for _, v := range s.versions {
+		if v > version {
+			status.Pending = append(status.Pending, v)
+		}
+	}
+	if version > status.Latest {
+		return status, nil
+	}
+
+	var err error
+	if status.Checksum, err = s.checksum(version); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// checksum hashes the up migrations up to and including version, so that
+// instances that applied the same migrations report the same value and an
+// edited migration file shows up as drift
+func (s *migrationSet) checksum(version uint) (string, error) {
+	src, err := s.source()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	h := sha256.New()
+	for _, v := range s.versions {
+		if v > version {
+			break
+		}
+		r, identifier, err := src.ReadUp(v)
+		if stderrors.Is(err, fs.ErrNotExist) {
+			// Down-only migrations contribute nothing
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read migration %d: %w", v, err)
+		}
+		fmt.Fprintf(h, "%d_%s\n", v, identifier)
+		_, err = io.Copy(h, r)
+		r.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read migration %d: %w", v, err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8]), nil
+}
@@ -0,0 +1,10 @@
+CREATE TABLE idempotency_keys (
+    idempotency_key VARCHAR(255) NOT NULL PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    response MEDIUMBLOB NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    locked_until TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    expires_at TIMESTAMP(6) NOT NULL,
+    INDEX idx_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
@@ -0,0 +1,322 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+const (
+	// migrationLockName names the advisory lock held while migrating. The
+	// server and todoctl share it, so only one of them migrates at a time.
+	migrationLockName = "todo.schema_migrations"
+	// defaultMigrationLockTimeout bounds the wait for the migration lock
+	defaultMigrationLockTimeout = 5 * time.Minute
+	// checksumTable records the checksum of the migrations that brought the
+	// schema to its version. schema_migrations only holds the version, so
+	// without it an edited migration file would go unnoticed.
+	checksumTable = "schema_migrations_checksum"
+	// mysqlErrNoSuchTable is the MySQL error number for a missing table
+	mysqlErrNoSuchTable = 1146
+)
+
+// MigrationStatus describes the schema version of a database and the
+// migrations this binary ships
+type MigrationStatus struct {
+	// Version is the applied version; zero when no migration has run
+	Version uint
+	Dirty   bool
+	// Latest is the highest version this binary has a migration for
+	Latest uint
+	// Pending lists the versions above Version, in order
+	Pending []uint
+	// Checksum identifies the contents of this binary's migrations up to
+	// Version; empty when Version is above Latest
+	Checksum string
+	// AppliedChecksum is the checksum recorded when the schema was migrated
+	// to Version; empty when none was recorded
+	AppliedChecksum string
+	// Source is "embedded" or the override directory migrations come from
+	Source string
+}
+
+// Drifted reports whether the migrations applied to the schema differ from
+// the ones this binary ships for the same version
+func (s *MigrationStatus) Drifted() bool {
+	return s.Checksum != "" && s.AppliedChecksum != "" && s.Checksum != s.AppliedChecksum
+}
+
+// driftError describes a drifted schema
+func (s *MigrationStatus) driftError() error {
+	return fmt.Errorf("migrations checksum %s does not match %s recorded when version %d was applied; reconcile the schema and force version %d",
+		s.Checksum, s.AppliedChecksum, s.Version, s.Version)
+}
+
+// Migrator applies schema migrations to one database over a dedicated
+// connection. Every method except Status holds a GET_LOCK advisory lock
+// while it runs, so concurrent runs from several instances do not
+// interleave. The lock belongs to the connection's session and is released
+// if the process dies. Under the same lock the Migrator records the
+// checksum of the applied migrations, and it refuses to migrate a schema
+// whose recorded checksum differs from its own.
+type Migrator struct {
+	m           *migrate.Migrate
+	conn        *sql.Conn
+	migrations  *migrationSet
+	lockTimeout time.Duration
+}
+
+// NewMigrator returns a Migrator for db using the migrations embedded in the
+// binary, or those in cfg.MigrationsDir when it is set. It waits up to
+// cfg.MigrateLockTimeout for another instance to finish migrating. The
+// Migrator takes one connection from db and returns it on Close; db itself
+// stays open.
+func NewMigrator(db *sql.DB, cfg *config.DatabaseConfig) (*Migrator, error) {
+	lockTimeout := cfg.MigrateLockTimeout
+	if lockTimeout <= 0 {
+		lockTimeout = defaultMigrationLockTimeout
+	}
+
+	migrations, err := loadMigrations(cfg.MigrationsDir)
+	if err != nil {
+		return nil, err
+	}
+	src, err := migrations.source()
+	if err != nil {
+		return nil, err
+	}
+
+	// WithInstance would make Close close db as well, so the driver gets a
+	// connection of its own
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("failed to get connection for migrations: %w", err)
+	}
+	driver, err := mysql.WithConnection(ctx, conn, &mysql.Config{})
+	if err != nil {
+		conn.Close()
+		src.Close()
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "mysql", driver)
+	if err != nil {
+		driver.Close()
+		src.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return &Migrator{m: m, conn: conn, migrations: migrations, lockTimeout: lockTimeout}, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up() error {
+	return m.migrating(func() error {
+		return ignoreNoChange(m.m.Up())
+	})
+}
+
+// Down rolls back the given number of applied migrations
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+	return m.migrating(func() error {
+		return ignoreNoChange(m.m.Steps(-steps))
+	})
+}
+
+// DownAll rolls back every applied migration, leaving an empty schema
+func (m *Migrator) DownAll() error {
+	return m.migrating(func() error {
+		return ignoreNoChange(m.m.Down())
+	})
+}
+
+// Goto migrates up or down to version, which must exist
+func (m *Migrator) Goto(version uint) error {
+	if !slices.Contains(m.migrations.versions, version) {
+		return fmt.Errorf("migration version %d does not exist (latest is %d)", version, m.migrations.latest())
+	}
+	return m.migrating(func() error {
+		return ignoreNoChange(m.m.Migrate(version))
+	})
+}
+
+// Force records version as applied and clears the dirty flag without running
+// any migration. A version of -1 records that no migration has run. Use it
+// after repairing a failed migration by hand, or after reconciling a schema
+// whose migrations drifted; the checksum of this binary's migrations is
+// recorded in place of the old one.
+func (m *Migrator) Force(version int) error {
+	if version < -1 {
+		return fmt.Errorf("invalid migration version %d", version)
+	}
+	if version >= 0 && !slices.Contains(m.migrations.versions, uint(version)) {
+		return fmt.Errorf("migration version %d does not exist (latest is %d)", version, m.migrations.latest())
+	}
+	return m.locked(func() error {
+		if err := m.m.Force(version); err != nil {
+			return err
+		}
+		return m.recordChecksum()
+	})
+}
+
+// Status reports the applied version and the pending migrations
+func (m *Migrator) Status() (*MigrationStatus, error) {
+	version, dirty, err := m.m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	status, err := m.migrations.status(version, dirty)
+	if err != nil {
+		return nil, err
+	}
+	if status.AppliedChecksum, err = readAppliedChecksum(context.Background(), m.conn, version); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Close releases the migration source and returns the connection to the
+// pool
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.m.Close()
+	if sourceErr != nil {
+		return sourceErr
+	}
+	return dbErr
+}
+
+// locked runs fn while holding the migration lock on the Migrator's
+// connection
+func (m *Migrator) locked(fn func() error) error {
+	ctx := context.Background()
+
+	// GET_LOCK returns 1 once acquired, 0 on timeout and NULL on error
+	var acquired sql.NullInt64
+	seconds := int(math.Ceil(m.lockTimeout.Seconds()))
+	err := m.conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, migrationLockName, seconds).Scan(&acquired)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return fmt.Errorf("timed out after %v waiting for another instance to finish migrating", m.lockTimeout)
+	}
+	defer m.conn.ExecContext(ctx, `DO RELEASE_LOCK(?)`, migrationLockName)
+
+	return fn()
+}
+
+// migrating runs step under the migration lock. It refuses to start when the
+// schema's migrations have drifted from this binary's, and records the
+// checksum of the version step leaves the schema at.
+func (m *Migrator) migrating(step func() error) error {
+	return m.locked(func() error {
+		status, err := m.Status()
+		if err != nil {
+			return err
+		}
+		if status.Drifted() {
+			return status.driftError()
+		}
+
+		if err := step(); err != nil {
+			return err
+		}
+		return m.recordChecksum()
+	})
+}
+
+// recordChecksum stores the checksum of this binary's migrations up to the
+// current version. A dirty schema, or one ahead of this binary, keeps its
+// recorded checksum. The caller holds the migration lock.
+func (m *Migrator) recordChecksum() error {
+	version, dirty, err := m.m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty || version > m.migrations.latest() {
+		return nil
+	}
+	checksum, err := m.migrations.checksum(version)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	_, err = m.conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+checksumTable+` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		checksum VARCHAR(64) NOT NULL,
+		recorded_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", checksumTable, err)
+	}
+
+	// The table holds one row, for the current version
+	tx, err := m.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to record migrations checksum: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+checksumTable); err != nil {
+		return fmt.Errorf("failed to record migrations checksum: %w", err)
+	}
+	if version > 0 {
+		_, err := tx.ExecContext(ctx, `INSERT INTO `+checksumTable+` (version, checksum) VALUES (?, ?)`, version, checksum)
+		if err != nil {
+			return fmt.Errorf("failed to record migrations checksum: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record migrations checksum: %w", err)
+	}
+	return nil
+}
+
+// rowQuerier is the part of *sql.DB and *sql.Conn that reads a single row
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// readAppliedChecksum returns the migrations checksum recorded for version,
+// or an empty string when none is
+func readAppliedChecksum(ctx context.Context, q rowQuerier, version uint) (string, error) {
+	var recorded uint
+	var checksum string
+	err := q.QueryRowContext(ctx, `SELECT version, checksum FROM `+checksumTable+` LIMIT 1`).Scan(&recorded, &checksum)
+
+	var mysqlErr *mysqldriver.MySQLError
+	if err == sql.ErrNoRows || (stderrors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrNoSuchTable) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read applied migrations checksum: %w", err)
+	}
+	if recorded != version {
+		return "", nil
+	}
+	return checksum, nil
+}
+
+// ignoreNoChange treats migrate.ErrNoChange as success
+func ignoreNoChange(err error) error {
+	if err == migrate.ErrNoChange {
+		return nil
+	}
+	return err
+}
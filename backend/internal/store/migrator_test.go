@@ -0,0 +1,256 @@
+package store
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/modules/mariadb"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	embedded, err := loadMigrations("")
+	require.NoError(t, err)
+	assert.Equal(t, "embedded", embedded.origin)
+	assert.Equal(t, []uint{1, 2}, embedded.versions)
+	assert.Equal(t, uint(2), embedded.latest())
+
+	first, err := embedded.checksum(1)
+	require.NoError(t, err)
+	latest, err := embedded.checksum(2)
+	require.NoError(t, err)
+	assert.Len(t, latest, 16)
+	assert.NotEqual(t, first, latest)
+
+	// An override directory with the same files has the same checksum, and
+	// an edited migration shows up as drift
+	dir := t.TempDir()
+	entries, err := fs.ReadDir(embeddedMigrations, "migrations")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		data, err := fs.ReadFile(embeddedMigrations, "migrations/"+entry.Name())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, entry.Name()), data, 0o644))
+	}
+
+	override, err := loadMigrations(dir)
+	require.NoError(t, err)
+	assert.Equal(t, dir, override.origin)
+	sum, err := override.checksum(2)
+	require.NoError(t, err)
+	assert.Equal(t, latest, sum)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "002_create_idempotency_keys.up.sql"), []byte("SELECT 1;"), 0o644))
+	sum, err = override.checksum(2)
+	require.NoError(t, err)
+	assert.NotEqual(t, latest, sum)
+	sum, err = override.checksum(1)
+	require.NoError(t, err)
+	assert.Equal(t, first, sum, "later migrations do not affect earlier checksums")
+
+	// A hotfix directory may add migrations
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "003_hotfix.up.sql"), []byte("SELECT 1;"), 0o644))
+	override, err = loadMigrations(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), override.latest())
+
+	_, err = loadMigrations(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+// startMariaDB starts a MariaDB container for the test and returns its
+// connection settings
+func startMariaDB(t *testing.T) *config.DatabaseConfig {
+	t.Helper()
+	ctx := context.Background()
+
+	mariadbContainer, err := mariadb.Run(ctx,
+		"mariadb:11.5",
+		mariadb.WithDatabase("testdb"),
+		mariadb.WithUsername("testuser"),
+		mariadb.WithPassword("testpass"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mariadbContainer.Terminate(ctx))
+	})
+
+	host, err := mariadbContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := mariadbContainer.MappedPort(ctx, "3306")
+	require.NoError(t, err)
+
+	return &config.DatabaseConfig{
+		Host:            host,
+		Port:            port.Int(),
+		User:            "testuser",
+		Password:        "testpass",
+		Database:        "testdb",
+		MaxOpenConns:    10,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 5 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+		SSLMode:         "false",
+	}
+}
+
+func TestMigrator_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	store, err := OpenMySQLTaskStore(startMariaDB(t))
+	require.NoError(t, err)
+	defer store.Close()
+
+	m, err := NewMigrator(store.GetDB(), &config.DatabaseConfig{})
+	require.NoError(t, err)
+
+	// OpenMySQLTaskStore does not migrate
+	status, err := m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), status.Version)
+	assert.Equal(t, uint(2), status.Latest)
+	assert.Equal(t, []uint{1, 2}, status.Pending)
+	assert.Equal(t, "embedded", status.Source)
+
+	require.NoError(t, m.Up())
+	require.NoError(t, m.Up(), "Up with nothing pending succeeds")
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), status.Version)
+	assert.Empty(t, status.Pending)
+	assert.Equal(t, status.Checksum, status.AppliedChecksum)
+
+	// Purge deletes only completed tasks updated before the cutoff
+	pending, err := store.CreateTask(ctx, "Pending")
+	require.NoError(t, err)
+	done, err := store.CreateTask(ctx, "Done")
+	require.NoError(t, err)
+	_, err = store.UpdateTask(ctx, done.Id, done.Description, true)
+	require.NoError(t, err)
+
+	purged, err := store.PurgeCompletedTasks(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	purged, err = store.PurgeCompletedTasks(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = store.GetTask(ctx, pending.Id)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Down(1))
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Version)
+
+	require.NoError(t, m.Goto(2))
+	assert.Error(t, m.Goto(7))
+
+	require.NoError(t, m.Force(1))
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), status.Version)
+	assert.False(t, status.Dirty)
+	assert.Equal(t, []uint{2}, status.Pending)
+	assert.Equal(t, status.Checksum, status.AppliedChecksum)
+
+	// Migrations that differ from the ones applied are refused until the
+	// schema is reconciled and forced
+	_, err = store.GetDB().ExecContext(ctx, `UPDATE schema_migrations_checksum SET checksum = 'edited'`)
+	require.NoError(t, err)
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.True(t, status.Drifted())
+	assert.ErrorContains(t, m.Up(), "does not match")
+	require.NoError(t, m.Force(1))
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.False(t, status.Drifted())
+
+	// Closing the migrator leaves the pool it was given open
+	require.NoError(t, m.Close())
+	_, err = store.ListTasks(ctx)
+	require.NoError(t, err)
+}
+
+func TestMigrateOnStartup_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+	cfg := startMariaDB(t)
+
+	// verify-only refuses an empty schema, and off does not touch it
+	cfg.MigrateOnStartup = config.MigrateVerifyOnly
+	_, err := NewMySQLTaskStore(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "todoctl migrate up")
+
+	cfg.MigrateOnStartup = config.MigrateOff
+	store, err := NewMySQLTaskStore(cfg)
+	require.NoError(t, err)
+	assert.Error(t, store.CheckMigrations(ctx))
+	require.NoError(t, store.Close())
+
+	// Instances starting together in auto mode migrate one at a time
+	cfg.MigrateOnStartup = config.MigrateAuto
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store, err := NewMySQLTaskStore(cfg)
+			if err == nil {
+				assert.Equal(t, uint(2), store.schemaVersion)
+				// The store stays usable after the migrator it ran closes
+				if _, err = store.CreateTask(ctx, "Created after migrating"); err == nil {
+					_, err = store.ListTasks(ctx)
+				}
+				store.Close()
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	cfg.MigrateOnStartup = config.MigrateVerifyOnly
+	store, err = NewMySQLTaskStore(cfg)
+	require.NoError(t, err)
+	assert.NoError(t, store.CheckMigrations(ctx))
+	assert.Equal(t, uint(2), store.schemaVersion)
+
+	// A schema migrated with different migrations is refused
+	_, err = store.GetDB().ExecContext(ctx, `UPDATE schema_migrations_checksum SET checksum = 'edited'`)
+	require.NoError(t, err)
+	_, err = NewMySQLTaskStore(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match")
+	m, err := NewMigrator(store.GetDB(), &config.DatabaseConfig{})
+	require.NoError(t, err)
+	require.NoError(t, m.Force(2))
+
+	// A schema that lags the binary is refused
+	require.NoError(t, m.Down(1))
+	require.NoError(t, m.Close())
+	require.NoError(t, store.Close())
+
+	_, err = NewMySQLTaskStore(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "behind")
+}
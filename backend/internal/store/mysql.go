@@ -4,39 +4,58 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/mysql"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
 	"github.com/wcygan/todo/backend/internal/config"
 	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/tracing"
 )
 
-// MySQLTaskStore provides MySQL-backed storage for tasks
+// primaryPool names the primary connection pool in metrics and traces
+const primaryPool = "primary"
+
+// MySQLTaskStore provides MySQL-backed storage for tasks. Writes always go to
+// the primary; reads are spread across read replicas when any are configured.
 type MySQLTaskStore struct {
-	db *sql.DB
+	db        *sql.DB
+	connector *credentialConnector
+	replicas  *replicaSet
+
+	// schemaVersion is the migration version the schema was at once startup
+	// migrated or verified it, with the checksum and source of those
+	// migrations
+	schemaVersion    uint
+	schemaChecksum   string
+	migrationsSource string
 }
 
-// NewMySQLTaskStore creates a new MySQLTaskStore instance
+// NewMySQLTaskStore creates a new MySQLTaskStore instance, handling schema
+// migrations as cfg.MigrateOnStartup says
 func NewMySQLTaskStore(cfg *config.DatabaseConfig) (*MySQLTaskStore, error) {
-	db, err := sql.Open("mysql", cfg.DSN())
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	mode := cfg.MigrateOnStartup
+	if mode == "" {
+		mode = config.MigrateAuto
 	}
+	return newMySQLTaskStore(cfg, mode)
+}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+// OpenMySQLTaskStore creates a MySQLTaskStore without running or checking
+// migrations, for tools that manage the schema themselves
+func OpenMySQLTaskStore(cfg *config.DatabaseConfig) (*MySQLTaskStore, error) {
+	return newMySQLTaskStore(cfg, config.MigrateOff)
+}
+
+// newMySQLTaskStore connects to the primary and read replicas and applies
+// the given migration mode
+func newMySQLTaskStore(cfg *config.DatabaseConfig, migrateMode string) (*MySQLTaskStore, error) {
+	db, connector, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Test the connection
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -47,82 +66,176 @@ func NewMySQLTaskStore(cfg *config.DatabaseConfig) (*MySQLTaskStore, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	store := &MySQLTaskStore{db: db}
+	store := &MySQLTaskStore{db: db, connector: connector}
 
-	// Run migrations
-	if err := store.migrate(); err != nil {
+	switch migrateMode {
+	case config.MigrateAuto:
+		if err := store.migrate(cfg); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
+	case config.MigrateVerifyOnly:
+		if err := store.verifySchema(ctx, cfg); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to verify schema: %w", err)
+		}
+	}
+
+	// Open read replicas
+	replicas, err := newReplicaSet(cfg)
+	if err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+		return nil, err
 	}
+	replicas.startHealthChecks(cfg.ReplicaHealthInterval, cfg.ReplicaHealthTimeout)
+	store.replicas = replicas
 
 	return store, nil
 }
 
-// findMigrationsPath returns the path to the migrations directory
-func findMigrationsPath() (string, error) {
-	// Try the standard path first: internal/store/migrations
-	// This works for:
-	// - Development: Run from backend/ directory  
-	// - Container: Dockerfile copies to ./internal/store/migrations with workdir /app
-	migrationsPath := "internal/store/migrations"
-	
-	if absPath, err := filepath.Abs(migrationsPath); err == nil {
-		if _, err := os.Stat(absPath); err == nil {
-			return "file://" + absPath, nil
-		}
-	}
-	
-	// Fallback: search upward for internal/store/migrations
-	// This handles cases where tests run from subdirectories
-	wd, err := os.Getwd()
+// openDB opens a connection pool configured from cfg, returning the
+// connector that can rotate its password
+func openDB(cfg *config.DatabaseConfig) (*sql.DB, *credentialConnector, error) {
+	connector, err := newCredentialConnector(cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to get working directory: %w", err)
-	}
-	
-	dir := wd
-	for i := 0; i < 5; i++ { // Search up to 5 levels up
-		migrationsPath := filepath.Join(dir, "internal", "store", "migrations")
-		if _, err := os.Stat(migrationsPath); err == nil {
-			return "file://" + migrationsPath, nil
-		}
-		
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			break // Reached filesystem root
-		}
-		dir = parent
+		return nil, nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
-	
-	return "", fmt.Errorf("migrations directory 'internal/store/migrations' not found from working directory: %s", wd)
+	db := sql.OpenDB(connector)
+
+	// Configure connection pool
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	return db, connector, nil
 }
 
-// migrate runs database migrations
-func (s *MySQLTaskStore) migrate() error {
-	driver, err := mysql.WithInstance(s.db, &mysql.Config{})
+// migrate applies pending migrations, waiting for another instance that is
+// already migrating
+func (s *MySQLTaskStore) migrate(cfg *config.DatabaseConfig) error {
+	m, err := NewMigrator(s.db, cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	status, err := m.Status()
 	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+		return err
+	}
+	s.schemaVersion = status.Version
+	s.schemaChecksum = status.Checksum
+	if s.schemaChecksum == "" {
+		s.schemaChecksum = status.AppliedChecksum
 	}
+	s.migrationsSource = status.Source
 
-	migrationsPath, err := findMigrationsPath()
+	return nil
+}
+
+// verifySchema checks that the schema is clean, not behind the latest
+// version this binary has a migration for, and was migrated with the same
+// migrations, without changing it
+func (s *MySQLTaskStore) verifySchema(ctx context.Context, cfg *config.DatabaseConfig) error {
+	migrations, err := loadMigrations(cfg.MigrationsDir)
 	if err != nil {
-		return fmt.Errorf("failed to find migrations path: %w", err)
+		return err
+	}
+	s.schemaVersion = migrations.latest()
+	if err := s.CheckMigrations(ctx); err != nil {
+		return fmt.Errorf("schema is not current, run 'todoctl migrate up': %w", err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(migrationsPath, "mysql", driver)
+	version, dirty, err := s.readSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := migrations.status(version, dirty)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance with path %s: %w", migrationsPath, err)
+		return err
+	}
+	if status.AppliedChecksum, err = readAppliedChecksum(ctx, s.db, version); err != nil {
+		return err
+	}
+	if status.Drifted() {
+		return status.driftError()
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	// Report the database's version, which may be ahead of this binary
+	s.schemaVersion = status.Version
+	s.schemaChecksum = status.Checksum
+	if s.schemaChecksum == "" {
+		s.schemaChecksum = status.AppliedChecksum
 	}
+	s.migrationsSource = status.Source
+	return nil
+}
 
+// CheckMigrations verifies that the schema is clean and not behind the
+// version it was at once startup migrated or verified it
+func (s *MySQLTaskStore) CheckMigrations(ctx context.Context) error {
+	version, dirty, err := s.readSchemaVersion(ctx)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no migrations applied (expected version %d)", s.schemaVersion)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty", version)
+	}
+	if version < s.schemaVersion {
+		return fmt.Errorf("schema version %d is behind expected version %d", version, s.schemaVersion)
+	}
 	return nil
 }
 
-// Close closes the database connection
+// readSchemaVersion reads the version and dirty flag from schema_migrations,
+// returning sql.ErrNoRows when no migration has run
+func (s *MySQLTaskStore) readSchemaVersion(ctx context.Context) (uint, bool, error) {
+	query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	ctx, span := startQuerySpan(ctx, "SELECT", query, primaryPool)
+
+	var version uint
+	var dirty bool
+	err := s.db.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	tracing.End(span, err)
+	return version, dirty, err
+}
+
+// Close closes the primary and all read replica connections
 func (s *MySQLTaskStore) Close() error {
-	return s.db.Close()
+	var replicaErr error
+	if s.replicas != nil {
+		replicaErr = s.replicas.close()
+	}
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	return replicaErr
+}
+
+// SetPassword makes new connections to the primary and read replicas use
+// password, for rotating credentials without a restart. Open connections are
+// unaffected.
+func (s *MySQLTaskStore) SetPassword(password config.Secret) error {
+	if err := s.connector.setPassword(password); err != nil {
+		return fmt.Errorf("failed to update primary credentials: %w", err)
+	}
+	if s.replicas != nil {
+		for _, r := range s.replicas.replicas {
+			if err := r.connector.setPassword(password); err != nil {
+				return fmt.Errorf("failed to update credentials for read replica %s: %w", r.addr, err)
+			}
+		}
+	}
+	return nil
 }
 
 // GetDB returns the underlying database connection
@@ -130,6 +243,30 @@ func (s *MySQLTaskStore) GetDB() *sql.DB {
 	return s.db
 }
 
+// Pools returns every connection pool keyed by a stable name, for exporting
+// sql.DB statistics
+func (s *MySQLTaskStore) Pools() map[string]*sql.DB {
+	pools := map[string]*sql.DB{primaryPool: s.db}
+	if s.replicas != nil {
+		for _, r := range s.replicas.replicas {
+			pools[r.name()] = r.db
+		}
+	}
+	return pools
+}
+
+// CountTasks returns the number of total and completed tasks
+func (s *MySQLTaskStore) CountTasks(ctx context.Context) (total, completed int64, err error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(completed), 0) FROM tasks`
+	ctx, span := startQuerySpan(ctx, "SELECT", query, primaryPool)
+	err = s.db.QueryRowContext(ctx, query).Scan(&total, &completed)
+	tracing.End(span, err)
+	if err != nil {
+		return 0, 0, errors.InternalWrap(err, "failed to count tasks")
+	}
+	return total, completed, nil
+}
+
 // HealthCheck performs a basic health check on the database connection
 func (s *MySQLTaskStore) HealthCheck(ctx context.Context) error {
 	return s.db.PingContext(ctx)
@@ -138,11 +275,13 @@ func (s *MySQLTaskStore) HealthCheck(ctx context.Context) error {
 // CreateTask creates a new task with the given description
 func (s *MySQLTaskStore) CreateTask(ctx context.Context, description string) (*taskv1.Task, error) {
 	if description == "" {
-		return nil, fmt.Errorf("task description cannot be empty")
+		return nil, errors.Validation("description", "task description cannot be empty")
 	}
 
 	query := `INSERT INTO tasks (description, completed) VALUES (?, ?)`
-	result, err := s.db.ExecContext(ctx, query, description, false)
+	spanCtx, span := startQuerySpan(ctx, "INSERT", query, primaryPool)
+	result, err := s.db.ExecContext(spanCtx, query, description, false)
+	tracing.End(span, err)
 	if err != nil {
 		return nil, errors.InternalWrap(err, "failed to create task")
 	}
@@ -152,33 +291,53 @@ func (s *MySQLTaskStore) CreateTask(ctx context.Context, description string) (*t
 		return nil, errors.InternalWrap(err, "failed to get last insert ID")
 	}
 
-	// Retrieve the created task to get timestamps
-	return s.GetTask(ctx, strconv.FormatInt(id, 10))
+	// Retrieve the created task from the primary to get timestamps
+	return s.getTask(ctx, s.db, primaryPool, id)
 }
 
 // GetTask retrieves a task by ID
 func (s *MySQLTaskStore) GetTask(ctx context.Context, id string) (*taskv1.Task, error) {
 	taskID, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf("invalid task ID format: %s", id)
+		return nil, invalidTaskID(id)
+	}
+
+	if r := s.readReplica(ctx); r != nil {
+		task, err := s.getTask(ctx, r.db, r.name(), taskID)
+		if err == nil || errors.IsNotFound(err) || ctx.Err() != nil {
+			return task, err
+		}
+		s.replicas.markUnhealthy(r)
 	}
 
+	return s.getTask(ctx, s.db, primaryPool, taskID)
+}
+
+// getTask retrieves a task by ID from the given connection pool
+func (s *MySQLTaskStore) getTask(ctx context.Context, db *sql.DB, pool string, taskID int64) (*taskv1.Task, error) {
 	query := `SELECT id, description, completed, created_at, updated_at FROM tasks WHERE id = ?`
-	row := s.db.QueryRowContext(ctx, query, taskID)
+	ctx, span := startQuerySpan(ctx, "SELECT", query, pool)
+	row := db.QueryRowContext(ctx, query, taskID)
 
 	var task taskv1.Task
 	var createdAt, updatedAt time.Time
 
-	err = row.Scan(
+	err := row.Scan(
 		&taskID,
 		&task.Description,
 		&task.Completed,
 		&createdAt,
 		&updatedAt,
 	)
+	// A missing row is an expected outcome, not a failed statement
+	spanErr := err
+	if err == sql.ErrNoRows {
+		spanErr = nil
+	}
+	tracing.End(span, spanErr)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.NotFound("task", id)
+			return nil, errors.NotFound("task", strconv.FormatInt(taskID, 10))
 		}
 		return nil, errors.InternalWrap(err, "failed to scan task")
 	}
@@ -192,8 +351,44 @@ func (s *MySQLTaskStore) GetTask(ctx context.Context, id string) (*taskv1.Task,
 
 // ListTasks returns all tasks in the store
 func (s *MySQLTaskStore) ListTasks(ctx context.Context) ([]*taskv1.Task, error) {
-	query := `SELECT id, description, completed, created_at, updated_at FROM tasks ORDER BY created_at DESC`
-	rows, err := s.db.QueryContext(ctx, query)
+	return s.readTasks(ctx, `SELECT id, description, completed, created_at, updated_at FROM tasks ORDER BY created_at DESC, id DESC`)
+}
+
+// ListTasksAfter implements TaskPager with a keyset query that reads only
+// the requested page
+func (s *MySQLTaskStore) ListTasksAfter(ctx context.Context, cursor *TaskCursor, limit int) ([]*taskv1.Task, error) {
+	if cursor == nil {
+		return s.readTasks(ctx, `SELECT id, description, completed, created_at, updated_at FROM tasks ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+	}
+
+	cursorID, err := strconv.ParseInt(cursor.ID, 10, 64)
+	if err != nil {
+		return nil, invalidTaskID(cursor.ID)
+	}
+	return s.readTasks(ctx, `SELECT id, description, completed, created_at, updated_at FROM tasks WHERE created_at < ? OR (created_at = ? AND id < ?) ORDER BY created_at DESC, id DESC LIMIT ?`,
+		cursor.CreatedAt, cursor.CreatedAt, cursorID, limit)
+}
+
+// readTasks runs a task query on a read replica, falling back to the
+// primary when no replica is usable
+func (s *MySQLTaskStore) readTasks(ctx context.Context, query string, args ...any) ([]*taskv1.Task, error) {
+	if r := s.readReplica(ctx); r != nil {
+		tasks, err := s.listTasks(ctx, r.db, r.name(), query, args...)
+		if err == nil || ctx.Err() != nil {
+			return tasks, err
+		}
+		s.replicas.markUnhealthy(r)
+	}
+
+	return s.listTasks(ctx, s.db, primaryPool, query, args...)
+}
+
+// listTasks runs a task query against the given connection pool
+func (s *MySQLTaskStore) listTasks(ctx context.Context, db *sql.DB, pool, query string, args ...any) (_ []*taskv1.Task, err error) {
+	ctx, span := startQuerySpan(ctx, "SELECT", query, pool)
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, errors.InternalWrap(err, "failed to query tasks")
 	}
@@ -241,7 +436,7 @@ func (s *MySQLTaskStore) ListTasks(ctx context.Context) ([]*taskv1.Task, error)
 func (s *MySQLTaskStore) UpdateTask(ctx context.Context, id, description string, completed bool) (*taskv1.Task, error) {
 	taskID, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf("invalid task ID format: %s", id)
+		return nil, invalidTaskID(id)
 	}
 
 	// Build dynamic query based on what needs to be updated
@@ -256,7 +451,38 @@ func (s *MySQLTaskStore) UpdateTask(ctx context.Context, id, description string,
 		args = []interface{}{completed, taskID}
 	}
 
-	result, err := s.db.ExecContext(ctx, query, args...)
+	spanCtx, span := startQuerySpan(ctx, "UPDATE", query, primaryPool)
+	result, err := s.db.ExecContext(spanCtx, query, args...)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to update task")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to get rows affected")
+	}
+
+	if rowsAffected == 0 {
+		return nil, errors.NotFound("task", id)
+	}
+
+	// Retrieve the updated task from the primary
+	return s.getTask(ctx, s.db, primaryPool, taskID)
+}
+
+// PatchTask implements TaskPatcher with a single UPDATE that leaves fields
+// missing from patch untouched
+func (s *MySQLTaskStore) PatchTask(ctx context.Context, id string, patch TaskPatch) (*taskv1.Task, error) {
+	taskID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, invalidTaskID(id)
+	}
+
+	query := `UPDATE tasks SET description = COALESCE(?, description), completed = COALESCE(?, completed), updated_at = NOW(6) WHERE id = ?`
+	spanCtx, span := startQuerySpan(ctx, "UPDATE", query, primaryPool)
+	result, err := s.db.ExecContext(spanCtx, query, patch.Description, patch.Completed, taskID)
+	tracing.End(span, err)
 	if err != nil {
 		return nil, errors.InternalWrap(err, "failed to update task")
 	}
@@ -270,19 +496,21 @@ func (s *MySQLTaskStore) UpdateTask(ctx context.Context, id, description string,
 		return nil, errors.NotFound("task", id)
 	}
 
-	// Retrieve the updated task
-	return s.GetTask(ctx, id)
+	// Retrieve the updated task from the primary
+	return s.getTask(ctx, s.db, primaryPool, taskID)
 }
 
 // DeleteTask removes a task by ID
 func (s *MySQLTaskStore) DeleteTask(ctx context.Context, id string) error {
 	taskID, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		return fmt.Errorf("invalid task ID format: %s", id)
+		return invalidTaskID(id)
 	}
 
 	query := `DELETE FROM tasks WHERE id = ?`
+	ctx, span := startQuerySpan(ctx, "DELETE", query, primaryPool)
 	result, err := s.db.ExecContext(ctx, query, taskID)
+	tracing.End(span, err)
 	if err != nil {
 		return errors.InternalWrap(err, "failed to delete task")
 	}
@@ -299,5 +527,24 @@ func (s *MySQLTaskStore) DeleteTask(ctx context.Context, id string) error {
 	return nil
 }
 
-// Verify that MySQLTaskStore implements the TaskRepository interface
-var _ TaskRepository = (*MySQLTaskStore)(nil)
+// readReplica returns the replica that should serve a read, or nil when the
+// read must go to the primary
+func (s *MySQLTaskStore) readReplica(ctx context.Context) *replica {
+	if s.replicas == nil || PrimaryReadsRequested(ctx) {
+		return nil
+	}
+	return s.replicas.pick()
+}
+
+// invalidTaskID returns the validation error for a malformed task ID
+func invalidTaskID(id string) *errors.Error {
+	return errors.Validation("id", fmt.Sprintf("invalid task ID format: %s", id))
+}
+
+// Verify that MySQLTaskStore implements the TaskRepository and TaskPatcher
+// interfaces
+var (
+	_ TaskRepository = (*MySQLTaskStore)(nil)
+	_ TaskPatcher    = (*MySQLTaskStore)(nil)
+	_ TaskPager      = (*MySQLTaskStore)(nil)
+)
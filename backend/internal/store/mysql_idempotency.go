@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/tracing"
+)
+
+// mysqlErrDuplicateEntry is the MySQL error number for a unique key violation
+const mysqlErrDuplicateEntry = 1062
+
+// ReserveIdempotencyKey implements IdempotencyStore. Expiry and leases are
+// computed by the database clock so that every server instance agrees on
+// them.
+func (s *MySQLTaskStore) ReserveIdempotencyKey(ctx context.Context, key, requestHash string, ttl, lease time.Duration) (*IdempotencyRecord, error) {
+	deleteQuery := `DELETE FROM idempotency_keys WHERE idempotency_key = ?
+		AND (expires_at <= NOW(6) OR (completed = FALSE AND locked_until <= NOW(6)))`
+	spanCtx, span := startQuerySpan(ctx, "DELETE", deleteQuery, primaryPool)
+	_, err := s.db.ExecContext(spanCtx, deleteQuery, key)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to expire idempotency key")
+	}
+
+	insertQuery := `INSERT INTO idempotency_keys (idempotency_key, request_hash, locked_until, expires_at)
+		VALUES (?, ?, DATE_ADD(NOW(6), INTERVAL ? MICROSECOND), DATE_ADD(NOW(6), INTERVAL ? MICROSECOND))`
+	spanCtx, span = startQuerySpan(ctx, "INSERT", insertQuery, primaryPool)
+	_, err = s.db.ExecContext(spanCtx, insertQuery, key, requestHash, lease.Microseconds(), ttl.Microseconds())
+	tracing.End(span, err)
+	if err == nil {
+		return nil, nil
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if !stderrors.As(err, &mysqlErr) || mysqlErr.Number != mysqlErrDuplicateEntry {
+		return nil, errors.InternalWrap(err, "failed to reserve idempotency key")
+	}
+
+	selectQuery := `SELECT request_hash, response, completed, locked_until, expires_at FROM idempotency_keys WHERE idempotency_key = ?`
+	spanCtx, span = startQuerySpan(ctx, "SELECT", selectQuery, primaryPool)
+	record := &IdempotencyRecord{Key: key}
+	err = s.db.QueryRowContext(spanCtx, selectQuery, key).Scan(
+		&record.RequestHash,
+		&record.Response,
+		&record.Completed,
+		&record.LockedUntil,
+		&record.ExpiresAt,
+	)
+	tracing.End(span, err)
+	if err == sql.ErrNoRows {
+		// The conflicting reservation was released in the meantime
+		return nil, errors.Conflict("idempotency key was released concurrently, retry the request")
+	}
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to load idempotency key")
+	}
+
+	return record, nil
+}
+
+// CompleteIdempotencyKey implements IdempotencyStore
+func (s *MySQLTaskStore) CompleteIdempotencyKey(ctx context.Context, key string, response []byte) error {
+	query := `UPDATE idempotency_keys SET response = ?, completed = TRUE WHERE idempotency_key = ?`
+	ctx, span := startQuerySpan(ctx, "UPDATE", query, primaryPool)
+	_, err := s.db.ExecContext(ctx, query, response, key)
+	tracing.End(span, err)
+	if err != nil {
+		return errors.InternalWrap(err, "failed to store idempotent response")
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey implements IdempotencyStore
+func (s *MySQLTaskStore) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE idempotency_key = ? AND completed = FALSE`
+	ctx, span := startQuerySpan(ctx, "DELETE", query, primaryPool)
+	_, err := s.db.ExecContext(ctx, query, key)
+	tracing.End(span, err)
+	if err != nil {
+		return errors.InternalWrap(err, "failed to release idempotency key")
+	}
+	return nil
+}
+
+// PurgeExpiredIdempotencyKeys implements IdempotencyStore
+func (s *MySQLTaskStore) PurgeExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= NOW(6)`
+	ctx, span := startQuerySpan(ctx, "DELETE", query, primaryPool)
+	result, err := s.db.ExecContext(ctx, query)
+	tracing.End(span, err)
+	if err != nil {
+		return 0, errors.InternalWrap(err, "failed to purge idempotency keys")
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.InternalWrap(err, "failed to count purged idempotency keys")
+	}
+	return purged, nil
+}
+
+// Verify that MySQLTaskStore implements the IdempotencyStore interface
+var _ IdempotencyStore = (*MySQLTaskStore)(nil)
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/tracing"
+)
+
+// ImportTasks implements TaskImporter with a single transaction on the
+// primary
+func (s *MySQLTaskStore) ImportTasks(ctx context.Context, tasks []NewTask) (_ []*taskv1.Task, err error) {
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	for _, task := range tasks {
+		if task.Description == "" {
+			return nil, errors.Validation("description", "task description cannot be empty")
+		}
+	}
+
+	ctx, span := tracer.Start(ctx, "MySQLTaskStore.ImportTasks")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to begin import")
+	}
+	defer tx.Rollback()
+
+	insertQuery := `INSERT INTO tasks (description, completed, created_at, updated_at)
+		VALUES (?, ?, COALESCE(?, CURRENT_TIMESTAMP(6)), COALESCE(?, CURRENT_TIMESTAMP(6)))`
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to prepare import")
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, len(tasks))
+	for i, task := range tasks {
+		var createdAt sql.NullTime
+		if !task.CreatedAt.IsZero() {
+			createdAt = sql.NullTime{Time: task.CreatedAt, Valid: true}
+		}
+
+		spanCtx, span := startQuerySpan(ctx, "INSERT", insertQuery, primaryPool)
+		result, err := stmt.ExecContext(spanCtx, task.Description, task.Completed, createdAt, createdAt)
+		tracing.End(span, err)
+		if err != nil {
+			return nil, errors.InternalWrap(err, "failed to import task")
+		}
+		if ids[i], err = result.LastInsertId(); err != nil {
+			return nil, errors.InternalWrap(err, "failed to get last insert ID")
+		}
+	}
+
+	imported, err := s.selectTasks(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.InternalWrap(err, "failed to commit import")
+	}
+	return imported, nil
+}
+
+// selectTasks loads the tasks with the given IDs, in that order, within tx
+func (s *MySQLTaskStore) selectTasks(ctx context.Context, tx *sql.Tx, ids []int64) (_ []*taskv1.Task, err error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	query := `SELECT id, description, completed, created_at, updated_at FROM tasks WHERE id IN (` + placeholders + `)`
+	ctx, span := startQuerySpan(ctx, "SELECT", query, primaryPool)
+	defer func() { tracing.End(span, err) }()
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalWrap(err, "failed to query imported tasks")
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]*taskv1.Task, len(ids))
+	for rows.Next() {
+		var task taskv1.Task
+		var taskID int64
+		var createdAt, updatedAt time.Time
+
+		if err := rows.Scan(&taskID, &task.Description, &task.Completed, &createdAt, &updatedAt); err != nil {
+			return nil, errors.InternalWrap(err, "failed to scan task")
+		}
+
+		task.Id = strconv.FormatInt(taskID, 10)
+		task.CreatedAt = timestamppb.New(createdAt)
+		task.UpdatedAt = timestamppb.New(updatedAt)
+		byID[taskID] = &task
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.InternalWrap(err, "error iterating over task rows")
+	}
+
+	tasks := make([]*taskv1.Task, len(ids))
+	for i, id := range ids {
+		if tasks[i] = byID[id]; tasks[i] == nil {
+			return nil, errors.Internal("imported task " + strconv.FormatInt(id, 10) + " was not found")
+		}
+	}
+	return tasks, nil
+}
+
+// Verify that MySQLTaskStore implements the TaskImporter interface
+var _ TaskImporter = (*MySQLTaskStore)(nil)
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/tracing"
+)
+
+// PurgeCompletedTasks deletes completed tasks last updated before cutoff and
+// returns how many were deleted. A task's last update is when it was marked
+// completed unless it was edited afterwards.
+func (s *MySQLTaskStore) PurgeCompletedTasks(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM tasks WHERE completed = TRUE AND updated_at < ?`
+	ctx, span := startQuerySpan(ctx, "DELETE", query, primaryPool)
+	result, err := s.db.ExecContext(ctx, query, cutoff)
+	tracing.End(span, err)
+	if err != nil {
+		return 0, errors.InternalWrap(err, "failed to purge completed tasks")
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.InternalWrap(err, "failed to get rows affected")
+	}
+	return purged, nil
+}
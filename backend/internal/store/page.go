@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+)
+
+// TaskCursor is the position of a task in listing order: creation time
+// then ID, both descending
+type TaskCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// TaskPager reads one page of tasks at a time, so the cost of a page does
+// not grow with the table. Repositories that support keyset pagination
+// implement it alongside TaskRepository.
+type TaskPager interface {
+	// ListTasksAfter returns up to limit tasks that sort after cursor,
+	// newest first. A nil cursor starts at the newest task.
+	ListTasksAfter(ctx context.Context, cursor *TaskCursor, limit int) ([]*taskv1.Task, error)
+}
+
+// ListTasksAfter reads a page of tasks through repo. Repositories without
+// TaskPager are listed in full and the page is cut out in memory.
+func ListTasksAfter(ctx context.Context, repo TaskRepository, cursor *TaskCursor, limit int) ([]*taskv1.Task, error) {
+	if pager, ok := repo.(TaskPager); ok {
+		return pager.ListTasksAfter(ctx, cursor, limit)
+	}
+
+	tasks, err := repo.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// ListTasks returns tasks in listing order, so the page starts at the
+	// first task past the cursor
+	start := 0
+	if cursor != nil {
+		for start < len(tasks) && !cursor.before(tasks[start]) {
+			start++
+		}
+	}
+	return tasks[start:min(start+limit, len(tasks))], nil
+}
+
+// before reports whether task sorts after the cursor position
+func (c *TaskCursor) before(task *taskv1.Task) bool {
+	createdAt := task.CreatedAt.AsTime()
+	if !createdAt.Equal(c.CreatedAt) {
+		return createdAt.Before(c.CreatedAt)
+	}
+	return compareIDs(task.Id, c.ID) < 0
+}
+
+// compareIDs orders task IDs numerically, as the tasks table does, falling
+// back to string order for IDs that are not numbers
+func compareIDs(a, b string) int {
+	ai, errA := strconv.ParseInt(a, 10, 64)
+	bi, errB := strconv.ParseInt(b, 10, 64)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	switch {
+	case ai < bi:
+		return -1
+	case ai > bi:
+		return 1
+	default:
+		return 0
+	}
+}
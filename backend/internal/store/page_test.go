@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+func TestListTasksAfter_Fallback(t *testing.T) {
+	repo := testutil.NewMockStore()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 4; i++ {
+		// Two tasks share each creation time, so ties break by ID
+		repo.AddTask(&taskv1.Task{
+			Id:        strconv.Itoa(i),
+			CreatedAt: timestamppb.New(createdAt.Add(time.Duration(i/2) * time.Minute)),
+		})
+	}
+
+	ids := func(tasks []*taskv1.Task) []string {
+		var ids []string
+		for _, task := range tasks {
+			ids = append(ids, task.Id)
+		}
+		return ids
+	}
+
+	page, err := ListTasksAfter(context.Background(), repo, nil, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "3", "2"}, ids(page))
+
+	cursor := &TaskCursor{CreatedAt: createdAt.Add(time.Minute), ID: "3"}
+	page, err = ListTasksAfter(context.Background(), repo, cursor, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "1"}, ids(page))
+}
+
+func TestCompareIDs(t *testing.T) {
+	assert.Equal(t, -1, compareIDs("9", "10"))
+	assert.Equal(t, 1, compareIDs("10", "9"))
+	assert.Equal(t, 0, compareIDs("7", "7"))
+	assert.Equal(t, -1, compareIDs("a", "b"))
+}
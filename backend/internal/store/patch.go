@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+)
+
+// TaskPatch lists the fields of a partial update; nil fields are unchanged
+type TaskPatch struct {
+	Description *string
+	Completed   *bool
+}
+
+// TaskPatcher updates only the fields set in a patch, in a single write, so
+// fields the patch leaves out keep whatever a concurrent update wrote.
+// Repositories that support partial updates implement it alongside
+// TaskRepository.
+type TaskPatcher interface {
+	// PatchTask applies patch to the task and returns the updated task
+	PatchTask(ctx context.Context, id string, patch TaskPatch) (*taskv1.Task, error)
+}
+
+// PatchTask applies patch through repo. Repositories without TaskPatcher
+// get a read-modify-write whose read goes to the primary; an update that
+// lands between the read and the write can still be overwritten.
+func PatchTask(ctx context.Context, repo TaskRepository, id string, patch TaskPatch) (*taskv1.Task, error) {
+	if patcher, ok := repo.(TaskPatcher); ok {
+		return patcher.PatchTask(ctx, id, patch)
+	}
+
+	current, err := repo.GetTask(WithPrimaryReads(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	description, completed := current.Description, current.Completed
+	if patch.Description != nil {
+		description = *patch.Description
+	}
+	if patch.Completed != nil {
+		completed = *patch.Completed
+	}
+	return repo.UpdateTask(ctx, id, description, completed)
+}
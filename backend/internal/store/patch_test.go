@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// replicaLagStore serves reads from a stale copy of the task unless the read
+// asks for the primary
+type replicaLagStore struct {
+	*testutil.MockStore
+	stale *taskv1.Task
+}
+
+func (s *replicaLagStore) GetTask(ctx context.Context, id string) (*taskv1.Task, error) {
+	if !PrimaryReadsRequested(ctx) {
+		return s.stale, nil
+	}
+	return s.MockStore.GetTask(ctx, id)
+}
+
+func TestPatchTask_FallbackReadsPrimary(t *testing.T) {
+	ctx := context.Background()
+	repo := &replicaLagStore{MockStore: testutil.NewMockStore()}
+	created, err := repo.CreateTask(ctx, "Original")
+	require.NoError(t, err)
+	repo.stale = created
+
+	// The replica has not seen the completion yet
+	_, err = repo.UpdateTask(ctx, created.Id, "", true)
+	require.NoError(t, err)
+
+	description := "Patched"
+	patched, err := PatchTask(ctx, repo, created.Id, TaskPatch{Description: &description})
+	require.NoError(t, err)
+	assert.Equal(t, "Patched", patched.Description)
+	assert.True(t, patched.Completed, "the patch must not write back the stale completion state")
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+// readConsistencyKey is the context key for forcing primary reads
+type readConsistencyKey struct{}
+
+// WithPrimaryReads returns a context whose reads are served by the primary.
+// Use it right after a mutation when the caller must observe its own write
+// regardless of replica lag.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readConsistencyKey{}, true)
+}
+
+// PrimaryReadsRequested reports whether ctx asks for primary reads
+func PrimaryReadsRequested(ctx context.Context) bool {
+	primary, _ := ctx.Value(readConsistencyKey{}).(bool)
+	return primary
+}
+
+// replica is a single read-only connection pool
+type replica struct {
+	addr      string
+	db        *sql.DB
+	connector *credentialConnector
+	healthy   atomic.Bool
+}
+
+// name returns the pool name used in metrics and traces
+func (r *replica) name() string {
+	return "replica-" + r.addr
+}
+
+// replicaSet routes reads across replicas with health-aware round robin
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// newReplicaSet opens a pool for every configured replica. Replicas that are
+// unreachable at startup are marked unhealthy rather than failing the store.
+func newReplicaSet(cfg *config.DatabaseConfig) (*replicaSet, error) {
+	set := &replicaSet{stop: make(chan struct{})}
+
+	for _, rc := range cfg.ReadReplicas {
+		replicaCfg := cfg.ForReplica(rc)
+		db, connector, err := openDB(&replicaCfg)
+		if err != nil {
+			set.close()
+			return nil, fmt.Errorf("failed to open read replica %s: %w", rc, err)
+		}
+
+		set.replicas = append(set.replicas, &replica{addr: rc.String(), db: db, connector: connector})
+	}
+	set.checkHealth(cfg.ReplicaHealthTimeout)
+
+	return set, nil
+}
+
+// pick returns the next healthy replica, or nil if none is available
+func (s *replicaSet) pick() *replica {
+	n := uint64(len(s.replicas))
+	if n == 0 {
+		return nil
+	}
+
+	start := s.next.Add(1) - 1
+	for i := uint64(0); i < n; i++ {
+		r := s.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// markUnhealthy takes a replica out of rotation until the next health check
+func (s *replicaSet) markUnhealthy(r *replica) {
+	r.healthy.Store(false)
+}
+
+// startHealthChecks pings every replica on the given interval, giving each
+// ping at most timeout to answer
+func (s *replicaSet) startHealthChecks(interval, timeout time.Duration) {
+	if len(s.replicas) == 0 || interval <= 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.checkHealth(timeout)
+			}
+		}
+	}()
+}
+
+// checkHealth pings every replica concurrently and records the results, so a
+// hung replica delays the round by at most timeout
+func (s *replicaSet) checkHealth(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, r := range s.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+			r.healthy.Store(r.db.PingContext(ctx) == nil)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// close stops health checks and closes every replica pool
+func (s *replicaSet) close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.wg.Wait()
+
+	var firstErr error
+	for _, r := range s.replicas {
+		if err := r.db.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close read replica %s: %w", r.addr, err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestReplicaSet builds a replica set whose pools are never dialed
+func newTestReplicaSet(t *testing.T, addrs ...string) *replicaSet {
+	t.Helper()
+
+	set := &replicaSet{stop: make(chan struct{})}
+	for _, addr := range addrs {
+		db, err := sql.Open("mysql", "user:pass@tcp("+addr+")/db")
+		require.NoError(t, err)
+
+		r := &replica{addr: addr, db: db}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+	t.Cleanup(func() { set.close() })
+
+	return set
+}
+
+func TestReplicaSet_RoundRobin(t *testing.T) {
+	set := newTestReplicaSet(t, "replica-a:3306", "replica-b:3306", "replica-c:3306")
+
+	var picked []string
+	for i := 0; i < 6; i++ {
+		picked = append(picked, set.pick().addr)
+	}
+
+	assert.Equal(t, []string{
+		"replica-a:3306", "replica-b:3306", "replica-c:3306",
+		"replica-a:3306", "replica-b:3306", "replica-c:3306",
+	}, picked)
+}
+
+func TestReplicaSet_SkipsUnhealthy(t *testing.T) {
+	set := newTestReplicaSet(t, "replica-a:3306", "replica-b:3306")
+	set.markUnhealthy(set.replicas[0])
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, "replica-b:3306", set.pick().addr)
+	}
+}
+
+func TestReplicaSet_AllUnhealthy(t *testing.T) {
+	set := newTestReplicaSet(t, "replica-a:3306", "replica-b:3306")
+	for _, r := range set.replicas {
+		set.markUnhealthy(r)
+	}
+
+	assert.Nil(t, set.pick())
+}
+
+func TestReplicaSet_Empty(t *testing.T) {
+	set := newTestReplicaSet(t)
+
+	assert.Nil(t, set.pick())
+	assert.NoError(t, set.close())
+}
+
+// blockingReplica accepts connections but never sends the MySQL handshake,
+// so pings against it hang until their context expires
+func blockingReplica(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var conns []net.Conn
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	return ln.Addr().String()
+}
+
+func TestReplicaSet_CheckHealthBlockingReplica(t *testing.T) {
+	set := newTestReplicaSet(t, blockingReplica(t), blockingReplica(t), blockingReplica(t))
+
+	const timeout = 200 * time.Millisecond
+	start := time.Now()
+	set.checkHealth(timeout)
+	elapsed := time.Since(start)
+
+	// Pings run concurrently, so the round costs one timeout, not one per replica
+	assert.Less(t, elapsed, 2*timeout)
+	for _, r := range set.replicas {
+		assert.False(t, r.healthy.Load(), r.addr)
+	}
+	assert.Nil(t, set.pick())
+}
+
+func TestWithPrimaryReads(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, PrimaryReadsRequested(ctx))
+
+	ctx = WithPrimaryReads(ctx)
+	assert.True(t, PrimaryReadsRequested(ctx))
+}
+
+func TestMySQLTaskStore_ReadReplica(t *testing.T) {
+	set := newTestReplicaSet(t, "replica-a:3306")
+
+	t.Run("NoReplicas", func(t *testing.T) {
+		s := &MySQLTaskStore{}
+		assert.Nil(t, s.readReplica(context.Background()))
+	})
+
+	t.Run("RoutesToReplica", func(t *testing.T) {
+		s := &MySQLTaskStore{replicas: set}
+		r := s.readReplica(context.Background())
+		require.NotNil(t, r)
+		assert.Equal(t, "replica-a:3306", r.addr)
+	})
+
+	t.Run("PrimaryReadsRequested", func(t *testing.T) {
+		s := &MySQLTaskStore{replicas: set}
+		assert.Nil(t, s.readReplica(WithPrimaryReads(context.Background())))
+	})
+}
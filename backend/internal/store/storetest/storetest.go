@@ -0,0 +1,531 @@
+// Package storetest provides a conformance suite for store.TaskRepository
+// implementations. Every backend, including test doubles, should pass Run so
+// that callers can rely on identical behavior regardless of the store in use.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/errors"
+	"github.com/wcygan/todo/backend/internal/store"
+)
+
+// Factory returns an empty TaskRepository for a single subtest. Any resources
+// it allocates should be released through t.Cleanup.
+type Factory func(t *testing.T) store.TaskRepository
+
+// missingID is a well-formed task ID that no test ever creates
+const missingID = "99999"
+
+// invalidIDs are task IDs that no repository may accept
+var invalidIDs = []string{"", "invalid-id", "12abc", "1.5"}
+
+// Run exercises the full TaskRepository contract against repositories
+// produced by newRepo
+func Run(t *testing.T, newRepo Factory) {
+	t.Helper()
+
+	t.Run("CreateTask", func(t *testing.T) {
+		testCreateTask(t, newRepo)
+	})
+
+	t.Run("GetTask", func(t *testing.T) {
+		testGetTask(t, newRepo)
+	})
+
+	t.Run("ListTasks", func(t *testing.T) {
+		testListTasks(t, newRepo)
+	})
+
+	t.Run("UpdateTask", func(t *testing.T) {
+		testUpdateTask(t, newRepo)
+	})
+
+	t.Run("PatchTask", func(t *testing.T) {
+		testPatchTask(t, newRepo)
+	})
+	t.Run("DeleteTask", func(t *testing.T) {
+		testDeleteTask(t, newRepo)
+	})
+
+	t.Run("InvalidIDs", func(t *testing.T) {
+		testInvalidIDs(t, newRepo)
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		testTimestamps(t, newRepo)
+	})
+
+	t.Run("ConcurrentWrites", func(t *testing.T) {
+		testConcurrentWrites(t, newRepo)
+	})
+
+	t.Run("CancelledContext", func(t *testing.T) {
+		testCancelledContext(t, newRepo)
+	})
+}
+
+func testCreateTask(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		repo := newRepo(t)
+
+		task, err := repo.CreateTask(ctx, "Conformance task")
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		assert.NotEmpty(t, task.Id)
+		assert.Equal(t, "Conformance task", task.Description)
+		assert.False(t, task.Completed)
+		assert.NotNil(t, task.CreatedAt)
+		assert.NotNil(t, task.UpdatedAt)
+	})
+
+	t.Run("UniqueIDs", func(t *testing.T) {
+		repo := newRepo(t)
+
+		seen := make(map[string]bool)
+		for i := 0; i < 5; i++ {
+			task, err := repo.CreateTask(ctx, fmt.Sprintf("Task %d", i))
+			require.NoError(t, err)
+			assert.False(t, seen[task.Id], "duplicate task ID: %s", task.Id)
+			seen[task.Id] = true
+		}
+	})
+
+	t.Run("EmptyDescription", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.CreateTask(ctx, "")
+		require.Error(t, err)
+		assert.True(t, errors.IsValidation(err), "expected validation error, got: %v", err)
+	})
+
+	t.Run("Unicode", func(t *testing.T) {
+		repo := newRepo(t)
+
+		description := "测试任务 🚀 émojis и unicode"
+		created, err := repo.CreateTask(ctx, description)
+		require.NoError(t, err)
+
+		retrieved, err := repo.GetTask(ctx, created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, description, retrieved.Description)
+	})
+}
+
+func testGetTask(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("Existing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Task to retrieve")
+		require.NoError(t, err)
+
+		retrieved, err := repo.GetTask(ctx, created.Id)
+		require.NoError(t, err)
+		assertSameTask(t, created, retrieved)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetTask(ctx, missingID)
+		require.Error(t, err)
+		assert.True(t, errors.IsNotFound(err), "expected not found error, got: %v", err)
+	})
+}
+
+func testListTasks(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("Empty", func(t *testing.T) {
+		repo := newRepo(t)
+
+		tasks, err := repo.ListTasks(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("NewestFirst", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var created []*taskv1.Task
+		for i := 0; i < 3; i++ {
+			task, err := repo.CreateTask(ctx, fmt.Sprintf("Task %d", i))
+			require.NoError(t, err)
+			created = append(created, task)
+		}
+
+		tasks, err := repo.ListTasks(ctx)
+		require.NoError(t, err)
+		require.Len(t, tasks, len(created))
+
+		for i, task := range tasks {
+			assertSameTask(t, created[len(created)-1-i], task)
+		}
+	})
+
+	t.Run("Pages", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for i := 0; i < 5; i++ {
+			_, err := repo.CreateTask(ctx, fmt.Sprintf("Task %d", i))
+			require.NoError(t, err)
+		}
+		all, err := repo.ListTasks(ctx)
+		require.NoError(t, err)
+
+		// store.ListTasksAfter uses the repository's own TaskPager when it
+		// has one; pages must follow ListTasks order exactly
+		var paged []*taskv1.Task
+		var cursor *store.TaskCursor
+		for {
+			page, err := store.ListTasksAfter(ctx, repo, cursor, 2)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page), 2)
+			if len(page) == 0 {
+				break
+			}
+			paged = append(paged, page...)
+			last := page[len(page)-1]
+			cursor = &store.TaskCursor{CreatedAt: last.CreatedAt.AsTime(), ID: last.Id}
+		}
+		require.Len(t, paged, len(all))
+		for i := range all {
+			assertSameTask(t, all[i], paged[i])
+		}
+	})
+
+	t.Run("ReflectsUpdates", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Task to complete")
+		require.NoError(t, err)
+
+		_, err = repo.UpdateTask(ctx, created.Id, "", true)
+		require.NoError(t, err)
+
+		tasks, err := repo.ListTasks(ctx)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.True(t, tasks[0].Completed)
+	})
+}
+
+func testUpdateTask(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("DescriptionAndCompletion", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Task to update")
+		require.NoError(t, err)
+
+		updated, err := repo.UpdateTask(ctx, created.Id, "Updated description", true)
+		require.NoError(t, err)
+		assert.Equal(t, created.Id, updated.Id)
+		assert.Equal(t, "Updated description", updated.Description)
+		assert.True(t, updated.Completed)
+
+		retrieved, err := repo.GetTask(ctx, created.Id)
+		require.NoError(t, err)
+		assertSameTask(t, updated, retrieved)
+	})
+
+	t.Run("EmptyDescriptionKeepsExisting", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Original description")
+		require.NoError(t, err)
+
+		updated, err := repo.UpdateTask(ctx, created.Id, "", true)
+		require.NoError(t, err)
+		assert.Equal(t, "Original description", updated.Description)
+		assert.True(t, updated.Completed)
+	})
+
+	t.Run("Uncomplete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Task to toggle")
+		require.NoError(t, err)
+
+		_, err = repo.UpdateTask(ctx, created.Id, "", true)
+		require.NoError(t, err)
+
+		updated, err := repo.UpdateTask(ctx, created.Id, "", false)
+		require.NoError(t, err)
+		assert.False(t, updated.Completed)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.UpdateTask(ctx, missingID, "Should fail", false)
+		require.Error(t, err)
+		assert.True(t, errors.IsNotFound(err), "expected not found error, got: %v", err)
+	})
+}
+
+// testPatchTask covers store.PatchTask, which uses the repository's own
+// TaskPatcher when it has one
+func testPatchTask(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("OnlySetFields", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Original description")
+		require.NoError(t, err)
+
+		completed := true
+		patched, err := store.PatchTask(ctx, repo, created.Id, store.TaskPatch{Completed: &completed})
+		require.NoError(t, err)
+		assert.Equal(t, "Original description", patched.Description)
+		assert.True(t, patched.Completed)
+
+		description := "Patched description"
+		patched, err = store.PatchTask(ctx, repo, created.Id, store.TaskPatch{Description: &description})
+		require.NoError(t, err)
+		assert.Equal(t, "Patched description", patched.Description)
+		assert.True(t, patched.Completed)
+
+		retrieved, err := repo.GetTask(ctx, created.Id)
+		require.NoError(t, err)
+		assertSameTask(t, patched, retrieved)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		completed := true
+		_, err := store.PatchTask(ctx, repo, missingID, store.TaskPatch{Completed: &completed})
+		require.Error(t, err)
+		assert.True(t, errors.IsNotFound(err), "expected not found error, got: %v", err)
+	})
+}
+
+func testDeleteTask(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("Existing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		keep, err := repo.CreateTask(ctx, "Task to keep")
+		require.NoError(t, err)
+		remove, err := repo.CreateTask(ctx, "Task to delete")
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteTask(ctx, remove.Id))
+
+		_, err = repo.GetTask(ctx, remove.Id)
+		assert.True(t, errors.IsNotFound(err), "expected not found error, got: %v", err)
+
+		tasks, err := repo.ListTasks(ctx)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, keep.Id, tasks[0].Id)
+	})
+
+	t.Run("Twice", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Task to delete twice")
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteTask(ctx, created.Id))
+
+		err = repo.DeleteTask(ctx, created.Id)
+		require.Error(t, err)
+		assert.True(t, errors.IsNotFound(err), "expected not found error, got: %v", err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.DeleteTask(ctx, missingID)
+		require.Error(t, err)
+		assert.True(t, errors.IsNotFound(err), "expected not found error, got: %v", err)
+	})
+}
+
+func testInvalidIDs(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	for _, id := range invalidIDs {
+		t.Run(fmt.Sprintf("ID_%q", id), func(t *testing.T) {
+			_, err := repo.GetTask(ctx, id)
+			require.Error(t, err)
+			assert.True(t, errors.IsValidation(err), "GetTask: expected validation error, got: %v", err)
+
+			_, err = repo.UpdateTask(ctx, id, "Should fail", true)
+			require.Error(t, err)
+			assert.True(t, errors.IsValidation(err), "UpdateTask: expected validation error, got: %v", err)
+
+			err = repo.DeleteTask(ctx, id)
+			require.Error(t, err)
+			assert.True(t, errors.IsValidation(err), "DeleteTask: expected validation error, got: %v", err)
+		})
+	}
+}
+
+func testTimestamps(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("CreatedNotAfterUpdated", func(t *testing.T) {
+		repo := newRepo(t)
+
+		task, err := repo.CreateTask(ctx, "Timestamp task")
+		require.NoError(t, err)
+		assert.False(t, task.UpdatedAt.AsTime().Before(task.CreatedAt.AsTime()),
+			"updated_at %v precedes created_at %v", task.UpdatedAt.AsTime(), task.CreatedAt.AsTime())
+	})
+
+	t.Run("CreationOrder", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var previous *taskv1.Task
+		for i := 0; i < 3; i++ {
+			task, err := repo.CreateTask(ctx, fmt.Sprintf("Task %d", i))
+			require.NoError(t, err)
+			if previous != nil {
+				assert.False(t, task.CreatedAt.AsTime().Before(previous.CreatedAt.AsTime()),
+					"created_at went backwards between tasks %s and %s", previous.Id, task.Id)
+			}
+			previous = task
+		}
+	})
+
+	t.Run("UpdateAdvancesUpdatedAt", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Task to update")
+		require.NoError(t, err)
+
+		// Sleep so that timestamp precision cannot hide the change
+		time.Sleep(10 * time.Millisecond)
+
+		updated, err := repo.UpdateTask(ctx, created.Id, "Updated", true)
+		require.NoError(t, err)
+		assert.True(t, updated.UpdatedAt.AsTime().After(created.UpdatedAt.AsTime()),
+			"updated_at did not advance: before %v, after %v", created.UpdatedAt.AsTime(), updated.UpdatedAt.AsTime())
+		assert.True(t, updated.CreatedAt.AsTime().Equal(created.CreatedAt.AsTime()),
+			"created_at changed on update: before %v, after %v", created.CreatedAt.AsTime(), updated.CreatedAt.AsTime())
+	})
+}
+
+func testConcurrentWrites(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	t.Run("Creates", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const workers = 10
+		const perWorker = 5
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		ids := make(map[string]bool)
+		errs := make(chan error, workers*perWorker)
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					task, err := repo.CreateTask(ctx, fmt.Sprintf("Worker %d task %d", worker, i))
+					if err != nil {
+						errs <- err
+						continue
+					}
+					mu.Lock()
+					ids[task.Id] = true
+					mu.Unlock()
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+		assert.Len(t, ids, workers*perWorker, "concurrent creates produced duplicate IDs")
+
+		tasks, err := repo.ListTasks(ctx)
+		require.NoError(t, err)
+		assert.Len(t, tasks, workers*perWorker)
+	})
+
+	t.Run("UpdatesSameTask", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTask(ctx, "Contended task")
+		require.NoError(t, err)
+
+		const workers = 10
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				_, err := repo.UpdateTask(ctx, created.Id, fmt.Sprintf("Update %d", worker), worker%2 == 0)
+				if err != nil {
+					errs <- err
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		task, err := repo.GetTask(ctx, created.Id)
+		require.NoError(t, err)
+		assert.Regexp(t, `^Update \d+$`, task.Description)
+	})
+}
+
+func testCancelledContext(t *testing.T, newRepo Factory) {
+	repo := newRepo(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.CreateTask(ctx, "Should fail")
+	assert.Error(t, err, "CreateTask should fail with a cancelled context")
+
+	_, err = repo.ListTasks(ctx)
+	assert.Error(t, err, "ListTasks should fail with a cancelled context")
+}
+
+// assertSameTask checks that two snapshots describe the same stored task
+func assertSameTask(t *testing.T, expected, actual *taskv1.Task) {
+	t.Helper()
+
+	require.NotNil(t, expected)
+	require.NotNil(t, actual)
+	assert.Equal(t, expected.Id, actual.Id)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.Equal(t, expected.Completed, actual.Completed)
+	assert.True(t, expected.CreatedAt.AsTime().Equal(actual.CreatedAt.AsTime()),
+		"created_at mismatch: %v != %v", expected.CreatedAt.AsTime(), actual.CreatedAt.AsTime())
+	assert.True(t, expected.UpdatedAt.AsTime().Equal(actual.UpdatedAt.AsTime()),
+		"updated_at mismatch: %v != %v", expected.UpdatedAt.AsTime(), actual.UpdatedAt.AsTime())
+}
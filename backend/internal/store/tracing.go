@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/wcygan/todo/backend/internal/store")
+
+// startQuerySpan starts a client span around a single SQL statement executed
+// against the named connection pool
+func startQuerySpan(ctx context.Context, operation, statement, pool string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "mysql "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mysql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", statement),
+			attribute.String("db.pool", pool),
+		),
+	)
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/wcygan/todo/backend/internal/config"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		wantErr  bool
+	}{
+		{name: "none", exporter: "none"},
+		{name: "empty_defaults_to_none", exporter: ""},
+		{name: "stdout", exporter: "stdout"},
+		{name: "unknown", exporter: "zipkin", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), &config.TracingConfig{
+				Exporter:    tt.exporter,
+				ServiceName: "todo-backend-test",
+				SampleRatio: 1.0,
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, shutdown(context.Background()))
+		})
+	}
+}
+
+func TestMiddleware_ExtractsTraceparent(t *testing.T) {
+	_, err := Setup(context.Background(), &config.TracingConfig{Exporter: "none"})
+	require.NoError(t, err)
+
+	var spanCtx trace.SpanContext
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanCtx = trace.SpanContextFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/task.v1.TaskService/GetTask", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.True(t, spanCtx.IsValid())
+	assert.True(t, spanCtx.IsRemote())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanCtx.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spanCtx.SpanID().String())
+}
+
+func TestMiddleware_WithoutTraceparent(t *testing.T) {
+	var spanCtx trace.SpanContext
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanCtx = trace.SpanContextFromContext(r.Context())
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	assert.False(t, spanCtx.IsValid())
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, okSpan := tracer.Start(context.Background(), "ok")
+	End(okSpan, nil)
+
+	_, failedSpan := tracer.Start(context.Background(), "failed")
+	End(failedSpan, errors.New("boom"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Empty(t, spans[0].Events())
+
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "boom", spans[1].Status().Description)
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "exception", spans[1].Events()[0].Name)
+}
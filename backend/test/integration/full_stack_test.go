@@ -2,8 +2,8 @@ package integration
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"testing"
 
@@ -11,6 +11,8 @@ import (
 	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/wcygan/todo/backend/internal/health"
 )
 
 // setupIntegrationTest sets up the shared integration test suite
@@ -199,20 +201,38 @@ func TestIntegration_HealthEndpoint(t *testing.T) {
 
 	suite := setupIntegrationTest(t)
 
-	t.Run("HealthCheck_DatabaseConnected", func(t *testing.T) {
-		resp, err := http.Get(suite.Server.URL + "/health")
-		require.NoError(t, err)
-		defer resp.Body.Close()
+	for _, path := range []string{"/livez", "/readyz", "/startupz", "/health"} {
+		t.Run("Probe"+path, func(t *testing.T) {
+			resp, err := http.Get(suite.Server.URL + path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
 
-		assert.Equal(t, http.StatusOK, resp.StatusCode)
-		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			var report health.Report
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+			assert.Equal(t, health.StatusOK, report.Status)
+			for _, check := range report.Checks {
+				assert.Equal(t, health.StatusOK, check.Status, check.Name)
+			}
+		})
+	}
 
-		body, err := io.ReadAll(resp.Body)
+	t.Run("Readiness_ReportsDatabaseChecks", func(t *testing.T) {
+		resp, err := http.Get(suite.Server.URL + "/readyz")
 		require.NoError(t, err)
+		defer resp.Body.Close()
 
-		bodyStr := string(body)
-		assert.Contains(t, bodyStr, `"status":"healthy"`)
-		assert.Contains(t, bodyStr, `"database":"mysql"`)
+		var report health.Report
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+
+		names := make([]string, 0, len(report.Checks))
+		for _, check := range report.Checks {
+			names = append(names, check.Name)
+		}
+		assert.Contains(t, names, "database")
+		assert.Contains(t, names, "migrations")
 	})
 }
 
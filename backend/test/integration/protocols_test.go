@@ -0,0 +1,402 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
+	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/wcygan/todo/backend/internal/app"
+	"github.com/wcygan/todo/backend/internal/config"
+	taskv2 "github.com/wcygan/todo/backend/internal/gen/task/v2"
+	"github.com/wcygan/todo/backend/internal/gen/task/v2/taskv2connect"
+	"github.com/wcygan/todo/backend/internal/handler"
+	"github.com/wcygan/todo/backend/internal/health"
+	"github.com/wcygan/todo/backend/internal/logger"
+	"github.com/wcygan/todo/backend/internal/metrics"
+	"github.com/wcygan/todo/backend/internal/service"
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+// browserOrigin is the origin of the web frontend in these tests
+const browserOrigin = "https://app.todo.test"
+
+// protocol is one way of calling the TaskService
+type protocol struct {
+	name string
+	// http2 calls over HTTP/2 without TLS (h2c) instead of HTTP/1.1
+	http2   bool
+	options []connect.ClientOption
+}
+
+// protocols covers every protocol and transport browsers and backend
+// clients use. gRPC requires HTTP/2; Connect and gRPC-Web work on both.
+var protocols = []protocol{
+	{name: "connect", options: nil},
+	{name: "connect_json", options: []connect.ClientOption{connect.WithProtoJSON()}},
+	{name: "connect_h2c", http2: true},
+	{name: "grpc_h2c", http2: true, options: []connect.ClientOption{connect.WithGRPC()}},
+	{name: "grpc_web", options: []connect.ClientOption{connect.WithGRPCWeb()}},
+	{name: "grpc_web_h2c", http2: true, options: []connect.ClientOption{connect.WithGRPCWeb()}},
+}
+
+// newAppHandler builds the handler main.go serves, backed by repo. CORS
+// allows browserOrigin with credentials, as a deployed frontend would.
+func newAppHandler(repo store.TaskRepository) (http.Handler, error) {
+	resolved, err := config.Resolve("")
+	if err != nil {
+		return nil, err
+	}
+	resolved.Config.Logger.Level = "error"
+	resolved.Config.Server.CORS.AllowedOrigins = []string{browserOrigin}
+	resolved.Config.Server.CORS.AllowCredentials = true
+	cfgStore := config.NewStore(resolved)
+
+	taskService := service.NewTaskService(repo,
+		service.WithIdempotency(store.NewMemoryIdempotencyStore(), time.Hour),
+	)
+	return app.NewHandler(cfgStore, logger.New(resolved.Config), app.Deps{
+		TaskService: taskService,
+		Health:      health.NewRegistry("todo-backend", time.Second),
+		Metrics:     metrics.New(),
+	})
+}
+
+// importStore adds store.TaskImporter to the mock store, as the MySQL store
+// provides it, so ImportTasks can be exercised
+type importStore struct {
+	*testutil.MockStore
+}
+
+func (s *importStore) ImportTasks(ctx context.Context, tasks []store.NewTask) ([]*taskv1.Task, error) {
+	imported := make([]*taskv1.Task, len(tasks))
+	for i, task := range tasks {
+		created, err := s.CreateTask(ctx, task.Description)
+		if err != nil {
+			return nil, err
+		}
+		if imported[i], err = s.UpdateTask(ctx, created.Id, task.Description, task.Completed); err != nil {
+			return nil, err
+		}
+	}
+	return imported, nil
+}
+
+// startAppServer serves the application handler over HTTP/1.1 and h2c,
+// like the plaintext listener in main.go
+func startAppServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	h, err := newAppHandler(&importStore{MockStore: testutil.NewMockStore()})
+	require.NoError(t, err)
+	server := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// httpClient returns a client for p's transport that sends Origin like a
+// browser would
+func (p protocol) httpClient() *http.Client {
+	var transport http.RoundTripper = &http.Transport{}
+	if p.http2 {
+		transport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, addr)
+			},
+		}
+	}
+	return &http.Client{Transport: originTransport{transport}}
+}
+
+// originTransport adds the browser origin to every request
+type originTransport struct {
+	next http.RoundTripper
+}
+
+func (t originTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Origin", browserOrigin)
+	return t.next.RoundTrip(req)
+}
+
+// forEachProtocol runs test against a fresh server for every protocol
+func forEachProtocol(t *testing.T, test func(t *testing.T, server *httptest.Server, p protocol)) {
+	for _, p := range protocols {
+		t.Run(p.name, func(t *testing.T) {
+			test(t, startAppServer(t), p)
+		})
+	}
+}
+
+func TestProtocols_TaskV1(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, server *httptest.Server, p protocol) {
+		client := taskconnect.NewTaskServiceClient(p.httpClient(), server.URL, p.options...)
+		ctx := context.Background()
+
+		created, err := client.CreateTask(ctx, connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Buy milk"}))
+		require.NoError(t, err)
+		task := created.Msg.Task
+		assert.Equal(t, "Buy milk", task.Description)
+		assert.Equal(t, browserOrigin, created.Header().Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, created.Header().Get("Access-Control-Expose-Headers"), "Grpc-Status")
+
+		got, err := client.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: task.Id}))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(task, got.Msg.Task))
+
+		_, err = client.CreateTask(ctx, connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Walk dog"}))
+		require.NoError(t, err)
+		all, err := client.GetAllTasks(ctx, connect.NewRequest(&taskv1.GetAllTasksRequest{}))
+		require.NoError(t, err)
+		assert.Len(t, all.Msg.Tasks, 2)
+
+		updated, err := client.UpdateTask(ctx, connect.NewRequest(&taskv1.UpdateTaskRequest{
+			Id: task.Id, Description: "Buy oat milk", Completed: true,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "Buy oat milk", updated.Msg.Task.Description)
+		assert.True(t, updated.Msg.Task.Completed)
+
+		deleted, err := client.DeleteTask(ctx, connect.NewRequest(&taskv1.DeleteTaskRequest{Id: task.Id}))
+		require.NoError(t, err)
+		assert.True(t, deleted.Msg.Success)
+
+		// Error codes survive every protocol, including gRPC trailers
+		_, err = client.GetTask(ctx, connect.NewRequest(&taskv1.GetTaskRequest{Id: task.Id}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+		_, err = client.CreateTask(ctx, connect.NewRequest(&taskv1.CreateTaskRequest{}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+}
+
+func TestProtocols_TaskV2(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, server *httptest.Server, p protocol) {
+		client := taskv2connect.NewTaskServiceClient(p.httpClient(), server.URL, p.options...)
+		ctx := context.Background()
+
+		created, err := client.CreateTask(ctx, connect.NewRequest(&taskv2.CreateTaskRequest{
+			Task: &taskv2.Task{Description: "Buy milk"},
+		}))
+		require.NoError(t, err)
+		task := created.Msg
+		assert.True(t, strings.HasPrefix(task.Name, "tasks/"), task.Name)
+
+		got, err := client.GetTask(ctx, connect.NewRequest(&taskv2.GetTaskRequest{Name: task.Name}))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(task, got.Msg))
+
+		updated, err := client.UpdateTask(ctx, connect.NewRequest(&taskv2.UpdateTaskRequest{
+			Task:       &taskv2.Task{Name: task.Name, Completed: true},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"completed"}},
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "Buy milk", updated.Msg.Description)
+		assert.True(t, updated.Msg.Completed)
+
+		imported, err := client.ImportTasks(ctx, connect.NewRequest(&taskv2.ImportTasksRequest{
+			Format: taskv2.TaskFormat_TASK_FORMAT_MARKDOWN,
+			Data:   []byte("- [ ] Walk dog\n- [x] Water plants\n"),
+		}))
+		require.NoError(t, err)
+		assert.Len(t, imported.Msg.Tasks, 2)
+		assert.Empty(t, imported.Msg.Errors)
+
+		list, err := client.ListTasks(ctx, connect.NewRequest(&taskv2.ListTasksRequest{PageSize: 2}))
+		require.NoError(t, err)
+		assert.Len(t, list.Msg.Tasks, 2)
+		require.NotEmpty(t, list.Msg.NextPageToken)
+		list, err = client.ListTasks(ctx, connect.NewRequest(&taskv2.ListTasksRequest{PageSize: 2, PageToken: list.Msg.NextPageToken}))
+		require.NoError(t, err)
+		assert.Len(t, list.Msg.Tasks, 1)
+
+		stream, err := client.ExportTasks(ctx, connect.NewRequest(&taskv2.ExportTasksRequest{
+			Format: taskv2.TaskFormat_TASK_FORMAT_MARKDOWN,
+		}))
+		require.NoError(t, err)
+		var exported []byte
+		for stream.Receive() {
+			exported = append(exported, stream.Msg().Data...)
+		}
+		require.NoError(t, stream.Err())
+		require.NoError(t, stream.Close())
+		assert.ElementsMatch(t, []string{"- [x] Buy milk", "- [ ] Walk dog", "- [x] Water plants"},
+			strings.Split(strings.TrimSuffix(string(exported), "\n"), "\n"))
+
+		_, err = client.DeleteTask(ctx, connect.NewRequest(&taskv2.DeleteTaskRequest{Name: task.Name}))
+		require.NoError(t, err)
+		_, err = client.GetTask(ctx, connect.NewRequest(&taskv2.GetTaskRequest{Name: task.Name}))
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	})
+}
+
+func TestProtocols_ServerStreaming(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, server *httptest.Server, p protocol) {
+		client := taskv2connect.NewTaskServiceClient(p.httpClient(), server.URL, p.options...)
+		ctx := context.Background()
+
+		// Enough tasks for ExportTasks to send several messages
+		var data bytes.Buffer
+		for i := 0; i < 2000; i++ {
+			fmt.Fprintf(&data, "- [ ] Streamed task number %d with some padding\n", i)
+		}
+		_, err := client.ImportTasks(ctx, connect.NewRequest(&taskv2.ImportTasksRequest{
+			Format: taskv2.TaskFormat_TASK_FORMAT_MARKDOWN,
+			Data:   data.Bytes(),
+		}))
+		require.NoError(t, err)
+
+		stream, err := client.ExportTasks(ctx, connect.NewRequest(&taskv2.ExportTasksRequest{
+			Format: taskv2.TaskFormat_TASK_FORMAT_MARKDOWN,
+		}))
+		require.NoError(t, err)
+		messages, size := 0, 0
+		for stream.Receive() {
+			messages++
+			size += len(stream.Msg().Data)
+		}
+		require.NoError(t, stream.Err())
+		assert.Greater(t, messages, 1, "the export arrives as a stream of messages")
+		assert.Equal(t, data.Len(), size)
+
+		// Errors in a stream arrive in its trailers
+		stream, err = client.ExportTasks(ctx, connect.NewRequest(&taskv2.ExportTasksRequest{}))
+		require.NoError(t, err)
+		for stream.Receive() {
+		}
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(stream.Err()))
+	})
+}
+
+func TestProtocols_IdempotencyKey(t *testing.T) {
+	forEachProtocol(t, func(t *testing.T, server *httptest.Server, p protocol) {
+		client := taskconnect.NewTaskServiceClient(p.httpClient(), server.URL, p.options...)
+		ctx := context.Background()
+
+		create := func() *taskv1.Task {
+			req := connect.NewRequest(&taskv1.CreateTaskRequest{Description: "Pay rent"})
+			req.Header().Set(handler.IdempotencyKeyHeader, "rent-"+p.name)
+			resp, err := client.CreateTask(ctx, req)
+			require.NoError(t, err)
+			return resp.Msg.Task
+		}
+		first, retried := create(), create()
+		assert.Equal(t, first.Id, retried.Id, "a retried request must not create a second task")
+	})
+}
+
+func TestProtocols_BrowserPreflight(t *testing.T) {
+	server := startAppServer(t)
+
+	for _, tt := range []struct {
+		name      string
+		procedure string
+		headers   string
+	}{
+		{"connect", taskconnect.TaskServiceCreateTaskProcedure, "content-type,connect-protocol-version,connect-timeout-ms,idempotency-key"},
+		{"grpc_web", taskconnect.TaskServiceCreateTaskProcedure, "content-type,x-grpc-web,x-user-agent,grpc-timeout"},
+		{"grpc_web_stream", taskv2connect.TaskServiceExportTasksProcedure, "content-type,x-grpc-web,x-user-agent"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodOptions, server.URL+tt.procedure, nil)
+			require.NoError(t, err)
+			req.Header.Set("Origin", browserOrigin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", tt.headers)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			assert.Equal(t, browserOrigin, resp.Header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+			assert.Contains(t, resp.Header.Get("Access-Control-Allow-Methods"), http.MethodPost)
+			assert.NotEmpty(t, resp.Header.Get("Access-Control-Max-Age"))
+			allowed := strings.ToLower(resp.Header.Get("Access-Control-Allow-Headers"))
+			for _, header := range strings.Split(tt.headers, ",") {
+				assert.Contains(t, allowed, header)
+			}
+		})
+	}
+
+	// Other origins get no CORS headers, so browsers refuse the call
+	req, err := http.NewRequest(http.MethodOptions, server.URL+taskconnect.TaskServiceCreateTaskProcedure, nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://evil.test")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+}
+
+// grpcWebFrame encodes msg as a gRPC-Web data frame
+func grpcWebFrame(t *testing.T, msg proto.Message) []byte {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	frame := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+// TestProtocols_GRPCWebTrailers calls the server the way a browser
+// gRPC-Web client does, without a Connect client, and checks that the
+// status it needs is readable
+func TestProtocols_GRPCWebTrailers(t *testing.T) {
+	server := startAppServer(t)
+
+	call := func(procedure string, msg proto.Message) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+procedure, bytes.NewReader(grpcWebFrame(t, msg)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("X-Grpc-Web", "1")
+		req.Header.Set("Origin", browserOrigin)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	resp, body := call(taskconnect.TaskServiceCreateTaskProcedure, &taskv1.CreateTaskRequest{Description: "From the browser"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/grpc-web+proto", resp.Header.Get("Content-Type"))
+	assert.Equal(t, browserOrigin, resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, strings.ToLower(string(body)), "grpc-status: 0", "trailers travel in the body")
+
+	// Failures may be trailers-only responses whose status is in headers,
+	// which browser scripts can only read when they are exposed
+	resp, body = call(taskconnect.TaskServiceGetTaskProcedure, &taskv1.GetTaskRequest{Id: "999"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	status := resp.Header.Get("Grpc-Status")
+	if status == "" {
+		assert.Contains(t, strings.ToLower(string(body)), "grpc-status: 5")
+	} else {
+		assert.Equal(t, "5", status)
+	}
+	exposed := strings.Split(resp.Header.Get("Access-Control-Expose-Headers"), ", ")
+	for _, header := range []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"} {
+		assert.Contains(t, exposed, header)
+	}
+}
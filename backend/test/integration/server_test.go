@@ -7,7 +7,6 @@ import (
 	"testing"
 
 	"connectrpc.com/connect"
-	"connectrpc.com/grpcreflect"
 	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
 	taskconnect "buf.build/gen/go/wcygan/todo/connectrpc/go/task/v1/taskv1connect"
 	"github.com/stretchr/testify/assert"
@@ -15,50 +14,18 @@ import (
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
-	"github.com/wcygan/todo/backend/internal/handler"
-	"github.com/wcygan/todo/backend/internal/service"
 	"github.com/wcygan/todo/backend/test/testutil"
 )
 
 // setupTestServer creates a test server with the full application stack
 func setupTestServer() (*httptest.Server, taskconnect.TaskServiceClient) {
-	// Create dependencies
-	taskStore := testutil.NewMockStore()
-	taskService := service.NewTaskService(taskStore)
-	taskHandler := handler.NewTaskHandler(taskService)
-	
-	// Create HTTP mux
-	mux := http.NewServeMux()
-	
-	// Register TaskService
-	path, serviceHandler := taskconnect.NewTaskServiceHandler(taskHandler)
-	mux.Handle(path, serviceHandler)
-	
-	// Add reflection support
-	reflector := grpcreflect.NewStaticReflector(
-		taskconnect.TaskServiceName,
-	)
-	mux.Handle(grpcreflect.NewHandlerV1(reflector))
-	mux.Handle(grpcreflect.NewHandlerV1Alpha(reflector))
-	
-	// Add CORS support
-	corsHandler := func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms")
-		
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		
-		mux.ServeHTTP(w, r)
+	h, err := newAppHandler(testutil.NewMockStore())
+	if err != nil {
+		panic(err)
 	}
-	
+
 	// Create test server with HTTP/2 support
-	server := httptest.NewUnstartedServer(
-		h2c.NewHandler(http.HandlerFunc(corsHandler), &http2.Server{}),
-	)
+	server := httptest.NewUnstartedServer(h2c.NewHandler(h, &http2.Server{}))
 	server.EnableHTTP2 = true
 	server.Start()
 	
@@ -141,12 +108,11 @@ func TestIntegration_FullTaskWorkflow(t *testing.T) {
 	assert.Equal(t, task2.Description, remainingTask.Description)
 	
 	// 7. Try to delete non-existent task
-	deleteResp, err = client.DeleteTask(ctx, connect.NewRequest(&taskv1.DeleteTaskRequest{
+	_, err = client.DeleteTask(ctx, connect.NewRequest(&taskv1.DeleteTaskRequest{
 		Id: "999",
 	}))
-	require.NoError(t, err)
-	assert.False(t, deleteResp.Msg.Success, "Deletion of non-existent task should fail")
-	assert.Contains(t, deleteResp.Msg.Message, "not found")
+	require.Error(t, err, "Deletion of non-existent task should fail")
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
 	
 	// 8. Verify task count unchanged
 	getAllResp, err = client.GetAllTasks(ctx, connect.NewRequest(&taskv1.GetAllTasksRequest{}))
@@ -217,13 +183,12 @@ func TestIntegration_ServerErrorHandling(t *testing.T) {
 	ctx := context.Background()
 	
 	// Test deleting non-existent task
-	deleteResp, err := client.DeleteTask(ctx, connect.NewRequest(&taskv1.DeleteTaskRequest{
-		Id: "non-existent-id",
+	_, err := client.DeleteTask(ctx, connect.NewRequest(&taskv1.DeleteTaskRequest{
+		Id: "99999",
 	}))
-	
-	require.NoError(t, err, "Should not return connection error")
-	assert.False(t, deleteResp.Msg.Success, "Should indicate failure")
-	assert.Contains(t, deleteResp.Msg.Message, "not found", "Should indicate task not found")
+
+	require.Error(t, err, "Should indicate failure")
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err), "Should indicate task not found")
 }
 
 func TestIntegration_EmptyDescriptions(t *testing.T) {
@@ -19,6 +19,7 @@ import (
 
 	"github.com/wcygan/todo/backend/internal/config"
 	"github.com/wcygan/todo/backend/internal/handler"
+	"github.com/wcygan/todo/backend/internal/health"
 	"github.com/wcygan/todo/backend/internal/service"
 	"github.com/wcygan/todo/backend/internal/store"
 )
@@ -139,21 +140,14 @@ func setupSharedIntegrationSuite() (*SharedIntegrationSuite, error) {
 	mux.Handle(grpcreflect.NewHandlerV1(reflector))
 	mux.Handle(grpcreflect.NewHandlerV1Alpha(reflector))
 
-	// Add health endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		healthCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-		defer cancel()
-
-		if err := manager.HealthCheck(healthCtx); err != nil {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte(`{"status":"unhealthy","error":"database_unavailable"}`))
-			return
-		}
-
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"healthy","database":"mysql"}`))
-	})
+	// Add health endpoints
+	healthRegistry := health.NewRegistry("todo-backend", 5*time.Second)
+	healthRegistry.AddReadinessCheck("database", manager.HealthCheck)
+	healthRegistry.AddReadinessCheck("migrations", manager.CheckMigrations)
+	healthRegistry.AddStartupCheck("migrations", manager.CheckMigrations)
+	healthRegistry.MarkStarted()
+	healthRegistry.Register(mux)
+	mux.Handle("/health", healthRegistry.ReadyzHandler())
 
 	// Create test server with HTTP/2 support
 	server := httptest.NewUnstartedServer(
@@ -2,12 +2,15 @@ package testutil
 
 import (
 	"context"
+	"sort"
+	"strconv"
 	"sync"
 	"testing"
 
 	taskv1 "buf.build/gen/go/wcygan/todo/protocolbuffers/go/task/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/wcygan/todo/backend/internal/errors"
@@ -139,14 +142,18 @@ func (m *MockStore) CreateTask(ctx context.Context, description string) (*taskv1
 	if m.failing {
 		return nil, errors.Internal("mock store is failing")
 	}
+
+	if description == "" {
+		return nil, errors.Validation("description", "task description cannot be empty")
+	}
 	
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	
-	task := CreateTestTaskWithID(string(rune(m.nextID+'0')), description)
+	task := CreateTestTaskWithID(strconv.Itoa(m.nextID), description)
 	m.tasks[task.Id] = task
 	m.nextID++
-	return task, nil
+	return cloneTask(task), nil
 }
 
 // GetTask mock implementation
@@ -161,6 +168,10 @@ func (m *MockStore) GetTask(ctx context.Context, id string) (*taskv1.Task, error
 	if m.failing {
 		return nil, errors.NotFound("task", id)
 	}
+
+	if err := validateTaskID(id); err != nil {
+		return nil, err
+	}
 	
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -169,7 +180,7 @@ func (m *MockStore) GetTask(ctx context.Context, id string) (*taskv1.Task, error
 	if !exists {
 		return nil, errors.NotFound("task", id)
 	}
-	return task, nil
+	return cloneTask(task), nil
 }
 
 // ListTasks mock implementation
@@ -190,8 +201,19 @@ func (m *MockStore) ListTasks(ctx context.Context) ([]*taskv1.Task, error) {
 	
 	tasks := make([]*taskv1.Task, 0, len(m.tasks))
 	for _, task := range m.tasks {
-		tasks = append(tasks, task)
+		tasks = append(tasks, cloneTask(task))
 	}
+
+	// Match the MySQL store: newest first, ties broken by descending ID
+	sort.Slice(tasks, func(i, j int) bool {
+		ci, cj := tasks[i].CreatedAt.AsTime(), tasks[j].CreatedAt.AsTime()
+		if !ci.Equal(cj) {
+			return ci.After(cj)
+		}
+		idI, _ := strconv.Atoi(tasks[i].Id)
+		idJ, _ := strconv.Atoi(tasks[j].Id)
+		return idI > idJ
+	})
 	return tasks, nil
 }
 
@@ -207,6 +229,10 @@ func (m *MockStore) UpdateTask(ctx context.Context, id, description string, comp
 	if m.failing {
 		return nil, errors.NotFound("task", id)
 	}
+
+	if err := validateTaskID(id); err != nil {
+		return nil, err
+	}
 	
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -222,7 +248,7 @@ func (m *MockStore) UpdateTask(ctx context.Context, id, description string, comp
 	task.Completed = completed
 	task.UpdatedAt = timestamppb.Now()
 	
-	return task, nil
+	return cloneTask(task), nil
 }
 
 // DeleteTask mock implementation
@@ -237,6 +263,10 @@ func (m *MockStore) DeleteTask(ctx context.Context, id string) error {
 	if m.failing {
 		return errors.NotFound("task", id)
 	}
+
+	if err := validateTaskID(id); err != nil {
+		return err
+	}
 	
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -249,6 +279,19 @@ func (m *MockStore) DeleteTask(ctx context.Context, id string) error {
 	return nil
 }
 
+// validateTaskID rejects IDs the MySQL store could never have assigned
+func validateTaskID(id string) error {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return errors.Validation("id", "invalid task ID format: "+id)
+	}
+	return nil
+}
+
+// cloneTask returns a copy so callers never share state with the store
+func cloneTask(task *taskv1.Task) *taskv1.Task {
+	return proto.Clone(task).(*taskv1.Task)
+}
+
 // AddTask directly adds a task to the mock store (for test setup)
 func (m *MockStore) AddTask(task *taskv1.Task) {
 	m.mu.Lock()
@@ -0,0 +1,22 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/internal/store/storetest"
+	"github.com/wcygan/todo/backend/test/testutil"
+)
+
+func TestMockStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.TaskRepository {
+		return testutil.NewMockStore()
+	})
+}
+
+func TestCachingStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.TaskRepository {
+		return store.NewCachingTaskStore(testutil.NewMockStore(), store.NewLRUCache(100), time.Minute)
+	})
+}
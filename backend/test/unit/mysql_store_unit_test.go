@@ -10,8 +10,29 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/wcygan/todo/backend/internal/store"
+	"github.com/wcygan/todo/backend/internal/store/storetest"
 )
 
+func TestMySQLStore_Conformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping MySQL store conformance tests in short mode")
+	}
+
+	ctx := context.Background()
+	container, dbConfig := setupTestMariaDB(t, ctx)
+	defer container.Terminate(ctx)
+
+	mysqlStore, err := store.NewMySQLTaskStore(dbConfig)
+	require.NoError(t, err)
+	defer mysqlStore.Close()
+
+	storetest.Run(t, func(t *testing.T) store.TaskRepository {
+		_, err := mysqlStore.GetDB().ExecContext(ctx, "TRUNCATE TABLE tasks")
+		require.NoError(t, err)
+		return mysqlStore
+	})
+}
+
 func TestMySQLStore_Unit_CRUD(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping MySQL store unit tests in short mode")
@@ -270,6 +291,69 @@ func TestMySQLStore_Unit_EdgeCases(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("IdempotencyKeys", func(t *testing.T) {
+		existing, err := mysqlStore.ReserveIdempotencyKey(ctx, "mysql-key", "hash-a", time.Hour, time.Hour)
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+
+		existing, err = mysqlStore.ReserveIdempotencyKey(ctx, "mysql-key", "hash-b", time.Hour, time.Hour)
+		require.NoError(t, err)
+		require.NotNil(t, existing)
+		assert.Equal(t, "hash-a", existing.RequestHash)
+		assert.False(t, existing.Completed)
+
+		require.NoError(t, mysqlStore.CompleteIdempotencyKey(ctx, "mysql-key", []byte("response")))
+		existing, err = mysqlStore.ReserveIdempotencyKey(ctx, "mysql-key", "hash-a", time.Hour, time.Hour)
+		require.NoError(t, err)
+		require.NotNil(t, existing)
+		assert.True(t, existing.Completed)
+		assert.Equal(t, []byte("response"), existing.Response)
+
+		// Released reservations can be claimed again
+		_, err = mysqlStore.ReserveIdempotencyKey(ctx, "released-key", "hash", time.Hour, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, mysqlStore.ReleaseIdempotencyKey(ctx, "released-key"))
+		existing, err = mysqlStore.ReserveIdempotencyKey(ctx, "released-key", "hash", time.Hour, time.Hour)
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+
+		// A reservation whose lease ran out is taken over; completed keys
+		// outlive their lease
+		_, err = mysqlStore.ReserveIdempotencyKey(ctx, "stale-key", "hash-a", time.Hour, time.Millisecond)
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		existing, err = mysqlStore.ReserveIdempotencyKey(ctx, "stale-key", "hash-b", time.Hour, time.Hour)
+		require.NoError(t, err)
+		assert.Nil(t, existing)
+		existing, err = mysqlStore.ReserveIdempotencyKey(ctx, "mysql-key", "hash-a", time.Hour, time.Hour)
+		require.NoError(t, err)
+		require.NotNil(t, existing)
+		assert.True(t, existing.Completed)
+
+		// Expired keys are replaced and purged
+		_, err = mysqlStore.ReserveIdempotencyKey(ctx, "expiring-key", "hash", time.Millisecond, time.Hour)
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+		purged, err := mysqlStore.PurgeExpiredIdempotencyKeys(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+	})
+
+	t.Run("CheckMigrations_UpToDate", func(t *testing.T) {
+		assert.NoError(t, mysqlStore.CheckMigrations(ctx))
+	})
+
+	t.Run("CheckMigrations_Dirty", func(t *testing.T) {
+		db := mysqlStore.GetDB()
+		_, err := db.ExecContext(ctx, "UPDATE schema_migrations SET dirty = 1")
+		require.NoError(t, err)
+		defer db.ExecContext(ctx, "UPDATE schema_migrations SET dirty = 0")
+
+		err = mysqlStore.CheckMigrations(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dirty")
+	})
+
 	t.Run("Close_Multiple", func(t *testing.T) {
 		// Test that multiple closes don't cause issues
 		tempContainer, tempConfig := setupTestMariaDB(t, ctx)